@@ -0,0 +1,103 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// Analysis results are sent in batches of this many files, or after this interval, whichever
+// comes first. A big drop analyzes thousands of files and full state per file stalls the UI.
+const (
+	analysisBatchSize     = 50
+	analysisBatchInterval = 250 * time.Millisecond
+)
+
+// AnalyzedBatch is emitted as "movies-analyzed" while added files are analyzed. The full
+// state follows once every file is done.
+type AnalyzedBatch struct {
+	Movies  []Movie  `json:"movies"`  // Videos analyzed since the last batch
+	Removed []string `json:"removed"` // IDs of files that were not videos or failed
+	Done    int      `json:"done"`    // Files analyzed so far
+	Total   int      `json:"total"`
+}
+
+// analysisBatcher collects analysis results and emits them as AnalyzedBatch events
+type analysisBatcher struct {
+	s       *SpoilerService
+	mu      sync.Mutex
+	batch   AnalyzedBatch
+	stopped chan struct{}
+	wg      sync.WaitGroup
+}
+
+// newAnalysisBatcher starts sending the results of total files every analysisBatchInterval
+func (s *SpoilerService) newAnalysisBatcher(total int) *analysisBatcher {
+	b := &analysisBatcher{
+		s:       s,
+		batch:   AnalyzedBatch{Movies: []Movie{}, Removed: []string{}, Total: total},
+		stopped: make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(analysisBatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				b.mu.Lock()
+				b.flush()
+				b.mu.Unlock()
+			case <-b.stopped:
+				return
+			}
+		}
+	}()
+	return b
+}
+
+// added records an analyzed video
+func (b *analysisBatcher) added(id string) {
+	movie, exists := b.s.getMovieByID(id)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batch.Done++
+	if exists {
+		b.batch.Movies = append(b.batch.Movies, movie)
+	}
+	if len(b.batch.Movies)+len(b.batch.Removed) >= analysisBatchSize {
+		b.flush()
+	}
+}
+
+// removed records a file that was dropped from the list
+func (b *analysisBatcher) removed(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batch.Done++
+	b.batch.Removed = append(b.batch.Removed, id)
+	if len(b.batch.Movies)+len(b.batch.Removed) >= analysisBatchSize {
+		b.flush()
+	}
+}
+
+// stop sends the last results and stops the ticker
+func (b *analysisBatcher) stop() {
+	close(b.stopped)
+	b.wg.Wait()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.flush()
+}
+
+// flush emits the collected results, if any. Callers hold b.mu.
+func (b *analysisBatcher) flush() {
+	if len(b.batch.Movies) == 0 && len(b.batch.Removed) == 0 {
+		return
+	}
+	if b.s.app != nil {
+		b.s.app.Event.Emit("movies-analyzed", b.batch)
+	}
+	b.batch.Movies = []Movie{}
+	b.batch.Removed = []string{}
+}
@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// AppVersion and AppCommit can be overridden at build time with
+// -ldflags "-X spoilr/backend.AppVersion=... -X spoilr/backend.AppCommit=..."
+var (
+	AppVersion = "1.4.0"
+	AppCommit  = ""
+)
+
+// AppInfo describes the application build and the environment it runs in
+type AppInfo struct {
+	Version        string            `json:"version"`
+	Commit         string            `json:"commit"`
+	GoVersion      string            `json:"goVersion"`
+	OS             string            `json:"os"`
+	Arch           string            `json:"arch"`
+	WebViewVersion string            `json:"webViewVersion"`
+	Tools          map[string]string `json:"tools"` // Detected external tool versions
+}
+
+// GetAppInfo returns version and environment details for the About dialog and debug bundles
+func (s *SpoilerService) GetAppInfo() AppInfo {
+	return AppInfo{
+		Version:        AppVersion,
+		Commit:         appCommit(),
+		GoVersion:      runtime.Version(),
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		WebViewVersion: webViewVersion(),
+		Tools:          detectToolVersions(),
+	}
+}
+
+// appCommit returns the build commit, falling back to the VCS info embedded by the Go toolchain
+func appCommit() string {
+	if AppCommit != "" {
+		return AppCommit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
+// detectToolVersions reports the versions of the external tools used for processing
+func detectToolVersions() map[string]string {
+	return map[string]string{
+		"ffmpeg":    toolVersion("ffmpeg", "-version"),
+		"ffprobe":   toolVersion("ffprobe", "-version"),
+		"mtn":       toolVersion("mtn", "-v"),
+		"mediainfo": toolVersion("mediainfo", "--Version"),
+	}
+}
@@ -0,0 +1,156 @@
+package backend
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+// ArtifactStats reports the disk and memory used by generated media
+type ArtifactStats struct {
+	TempBytes             int64 `json:"tempBytes"`             // Generated files currently in the temp directory
+	PeakTempBytes         int64 `json:"peakTempBytes"`         // Highest temp usage since start
+	GeneratedBytes        int64 `json:"generatedBytes"`        // Total size of all generated files since start
+	UploadBufferBytes     int64 `json:"uploadBufferBytes"`     // Images currently buffered for upload
+	PeakUploadBufferBytes int64 `json:"peakUploadBufferBytes"` // Highest upload buffer usage since start
+	TempLimitBytes        int64 `json:"tempLimitBytes"`        // Configured cap, 0 without one
+}
+
+// artifactTracker accounts the generated files per movie and throttles generation once the
+// temp usage reaches the configured cap
+type artifactTracker struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	movies  map[string]*movieArtifacts // Movies currently processing
+	nextSeq int
+	stats   ArtifactStats
+}
+
+type movieArtifacts struct {
+	seq   int // Processing order, the earliest movie is never throttled
+	bytes int64
+}
+
+func newArtifactTracker() *artifactTracker {
+	t := &artifactTracker{movies: make(map[string]*movieArtifacts)}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+// begin registers a movie whose artifacts are about to be generated
+func (t *artifactTracker) begin(movieID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.movies[movieID]; !exists {
+		t.movies[movieID] = &movieArtifacts{seq: t.nextSeq}
+		t.nextSeq++
+	}
+}
+
+// finish releases a movie's artifacts once its temp files are removed
+func (t *artifactTracker) finish(movieID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if movie, exists := t.movies[movieID]; exists {
+		t.stats.TempBytes -= movie.bytes
+		delete(t.movies, movieID)
+		t.cond.Broadcast()
+	}
+}
+
+// add accounts a generated file
+func (t *artifactTracker) add(movieID, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if movie, exists := t.movies[movieID]; exists {
+		movie.bytes += info.Size()
+	}
+	t.stats.TempBytes += info.Size()
+	t.stats.GeneratedBytes += info.Size()
+	t.stats.PeakTempBytes = max(t.stats.PeakTempBytes, t.stats.TempBytes)
+}
+
+// trackUpload accounts an image buffered in memory for upload, call the returned function
+// once the upload finished
+func (t *artifactTracker) trackUpload(path string) func() {
+	info, err := os.Stat(path)
+	if err != nil {
+		return func() {}
+	}
+
+	t.mu.Lock()
+	t.stats.UploadBufferBytes += info.Size()
+	t.stats.PeakUploadBufferBytes = max(t.stats.PeakUploadBufferBytes, t.stats.UploadBufferBytes)
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		t.stats.UploadBufferBytes -= info.Size()
+		t.mu.Unlock()
+	}
+}
+
+// waitForRoom blocks while the temp usage is at or above limit. The earliest processing movie
+// always proceeds, so it can finish and free its files instead of waiting on the others.
+func (t *artifactTracker) waitForRoom(ctx context.Context, movieID string, limit int64) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		t.mu.Lock()
+		t.cond.Broadcast()
+		t.mu.Unlock()
+	})
+	defer stop()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for t.stats.TempBytes >= limit && !t.isEarliest(movieID) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		t.cond.Wait()
+	}
+	return ctx.Err()
+}
+
+// isEarliest reports whether the movie started processing before every other active movie
+func (t *artifactTracker) isEarliest(movieID string) bool {
+	movie, exists := t.movies[movieID]
+	if !exists {
+		return true
+	}
+	for _, other := range t.movies {
+		if other.seq < movie.seq {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *artifactTracker) snapshot() ArtifactStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+// tempLimitBytes returns the configured temp usage cap in bytes, 0 without one
+func (s *SpoilerService) tempLimitBytes() int64 {
+	return int64(s.settings.MaxTempUsageMB) << 20
+}
+
+// GetArtifactStats reports the size of generated media and in-flight upload buffers
+func (s *SpoilerService) GetArtifactStats() ArtifactStats {
+	stats := s.artifacts.snapshot()
+	stats.TempLimitBytes = s.tempLimitBytes()
+	return stats
+}
@@ -0,0 +1,279 @@
+package backend
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"spoilr/backend/img_uploaders"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// importHostDomains maps image host domains to uploader names
+var importHostDomains = []struct {
+	domain string
+	host   string
+}{
+	{"fastpic.", "fastpic"},
+	{"imgbox.com", "imgbox"},
+	{"hamster.is", "hamster"},
+	{"catbox.moe", "catbox"},
+}
+
+var (
+	importLinePattern       = regexp.MustCompile(`(?m)^\s*([A-Za-z][A-Za-z ]*?)\s*:\s*(.+?)\s*$`)
+	importResolutionPattern = regexp.MustCompile(`^(\d+)\s*[x×]\s*(\d+)$`)
+	importFPSPattern        = regexp.MustCompile(`(?i)^([\d.,]+)\s*fps$`)
+)
+
+// importedImage is a posted image with its link target
+type importedImage struct {
+	host string
+	link string // Viewer page or full image the thumbnail links to, empty for inline images
+	src  string
+	// Paragraph break after the image, the default templates put the contact sheet in its own paragraph
+	ownParagraph bool
+}
+
+// ImportBBCode rebuilds movies from BBCode posted earlier, one per spoiler block, with their
+// media info lines and uploaded images. The movies have no source file and are completed, so
+// they can be rendered under another template but not processed again.
+func (s *SpoilerService) ImportBBCode(text string) ([]string, error) {
+	if s.processing {
+		return nil, fmt.Errorf("processing already in progress")
+	}
+
+	var spoilers []*bbNode
+	collectMovieSpoilers(parseBBCode(text), &spoilers)
+	if len(spoilers) == 0 {
+		return nil, fmt.Errorf("no spoiler blocks found")
+	}
+
+	var movieIDs []string
+	for _, spoiler := range spoilers {
+		movie := s.importMovie(spoiler)
+		s.moviesMu.Lock()
+		s.movies = append(s.movies, movie)
+		s.moviesMu.Unlock()
+		s.recordEvent(movie.ID, "processing", "Imported from BBCode", nil)
+		movieIDs = append(movieIDs, movie.ID)
+	}
+
+	s.emitState()
+	return movieIDs, nil
+}
+
+// collectMovieSpoilers finds the innermost spoilers, so collection spoilers wrapping a
+// group of movies are skipped
+func collectMovieSpoilers(node *bbNode, spoilers *[]*bbNode) {
+	for _, child := range node.children {
+		if child.tag != "spoiler" {
+			collectMovieSpoilers(child, spoilers)
+			continue
+		}
+		before := len(*spoilers)
+		collectMovieSpoilers(child, spoilers)
+		if len(*spoilers) == before {
+			*spoilers = append(*spoilers, child)
+		}
+	}
+}
+
+// importMovie reads the media info lines and images of a single spoiler
+func (s *SpoilerService) importMovie(spoiler *bbNode) Movie {
+	movie := Movie{
+		ID:              uuid.New().String(),
+		Params:          make(map[string]string),
+		Uploads:         make(map[string]*HostUploads),
+		ProcessingState: StateCompleted,
+		Imported:        true,
+	}
+
+	for _, match := range importLinePattern.FindAllStringSubmatch(plainText(spoiler.children), -1) {
+		value := match[2]
+		switch strings.ToLower(match[1]) {
+		case "file", "file name":
+			movie.FileName = value
+		case "size", "file size":
+			movie.FileSize = value
+		case "duration":
+			movie.DurationFormatted = value
+			movie.DurationSeconds = parseImportedDuration(value)
+		case "video":
+			importVideoLine(&movie, value)
+		case "audio":
+			importAudioLine(&movie, value)
+		}
+	}
+
+	// The default titles are "name | size"
+	if movie.FileName == "" {
+		title, _, _ := strings.Cut(spoiler.option, " | ")
+		movie.FileName = strings.TrimSpace(title)
+	}
+	if movie.FileName == "" {
+		movie.FileName = "Imported movie"
+	}
+	normalizeMediaNumbers(&movie)
+	setReleaseParams(&movie)
+
+	s.importImages(&movie, collectImportedImages(spoiler.children))
+	return movie
+}
+
+// importVideoLine reads "codec / fps FPS / WxH / bit rate", in any order after the codec
+func importVideoLine(movie *Movie, line string) {
+	for i, part := range splitImportedLine(line) {
+		if match := importResolutionPattern.FindStringSubmatch(part); match != nil {
+			movie.Width, movie.Height = match[1], match[2]
+		} else if match := importFPSPattern.FindStringSubmatch(part); match != nil {
+			movie.Params["%VIDEO_FPS%"] = match[1]
+		} else if strings.HasSuffix(strings.ToLower(part), "bps") {
+			movie.VideoBitRate = part
+		} else if i == 0 {
+			movie.VideoCodec = part
+		}
+	}
+}
+
+// importAudioLine reads "codec / sample rate / channels / bit rate"
+func importAudioLine(movie *Movie, line string) {
+	for i, part := range splitImportedLine(line) {
+		lower := strings.ToLower(part)
+		switch {
+		case strings.HasSuffix(lower, "hz"):
+			movie.Params["%AUDIO_SAMPLE_RATE%"] = part
+		case strings.Contains(lower, "channel"):
+			movie.Params["%AUDIO_CHANNELS%"] = part
+		case strings.HasSuffix(lower, "bps"):
+			movie.AudioBitRate = part
+		case i == 0:
+			movie.AudioCodec = part
+		}
+	}
+}
+
+// splitImportedLine splits a "a / b / c" line, dropping the "−" of empty placeholders
+func splitImportedLine(line string) []string {
+	var parts []string
+	for _, part := range strings.Split(line, "/") {
+		part = strings.TrimSpace(part)
+		if part == "−" {
+			part = ""
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// parseImportedDuration parses "h:mm:ss" or "m:ss" into seconds, 0 when malformed
+func parseImportedDuration(value string) float64 {
+	var seconds float64
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + float64(n)
+	}
+	return seconds
+}
+
+// collectImportedImages lists the images of known hosts in posting order
+func collectImportedImages(nodes []*bbNode) []importedImage {
+	var images []importedImage
+	var walk func(nodes []*bbNode)
+	walk = func(nodes []*bbNode) {
+		for _, node := range nodes {
+			switch {
+			case node.tag == "" && len(images) > 0 && strings.Contains(node.text, "\n\n"):
+				images[len(images)-1].ownParagraph = true
+			case node.tag == "img":
+				src := strings.TrimSpace(plainText(node.children))
+				if host := importHost(src); host != "" {
+					images = append(images, importedImage{host: host, src: src})
+				}
+			case node.tag == "url" && len(node.children) == 1 && node.children[0].tag == "img":
+				src := strings.TrimSpace(plainText(node.children[0].children))
+				link := node.option
+				host := importHost(link)
+				if host == "" {
+					host = importHost(src)
+				}
+				if host != "" {
+					images = append(images, importedImage{host: host, link: link, src: src})
+				}
+			default:
+				walk(node.children)
+			}
+		}
+	}
+	walk(nodes)
+	return images
+}
+
+// importHost returns the uploader name of an image URL, empty for unknown hosts
+func importHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	for _, known := range importHostDomains {
+		if strings.Contains(strings.ToLower(parsed.Host), known.domain) {
+			return known.host
+		}
+	}
+	return ""
+}
+
+// importImages assigns the images to the hosts. The first image of a host is its contact
+// sheet when it stands alone, either in its own paragraph or as the host's only image.
+func (s *SpoilerService) importImages(movie *Movie, images []importedImage) {
+	dialect := s.bbCodeDialect()
+	byHost := make(map[string][]importedImage)
+	var hosts []string
+	for _, image := range images {
+		if _, seen := byHost[image.host]; !seen {
+			hosts = append(hosts, image.host)
+		}
+		byHost[image.host] = append(byHost[image.host], image)
+	}
+
+	for _, host := range hosts {
+		hostImages := byHost[host]
+		uploads := &HostUploads{}
+		for i, image := range hostImages {
+			code := dialect.Image(image.src)
+			direct := image.src
+			if image.link != "" {
+				code = dialect.LinkedImage(image.link, image.src)
+				if isImageURL(image.link) {
+					direct = image.link
+				}
+			}
+
+			if i == 0 && (image.ownParagraph || len(hostImages) == 1) {
+				uploads.ContactSheetURL = code
+				uploads.ContactSheetBigURL = code
+				uploads.ContactSheetDirectURL = direct
+				continue
+			}
+			uploads.ScreenshotURLs = append(uploads.ScreenshotURLs, code)
+			uploads.ScreenshotBigURLs = append(uploads.ScreenshotBigURLs, code)
+			uploads.ScreenshotDirectURLs = append(uploads.ScreenshotDirectURLs, direct)
+		}
+		movie.Uploads[host] = uploads
+	}
+}
+
+// isImageURL reports whether a link points at an image file rather than a viewer page
+func isImageURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(img_uploaders.ImageContentType(path.Base(parsed.Path)), "image/")
+}
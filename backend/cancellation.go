@@ -0,0 +1,117 @@
+package backend
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// CancelInfo records how far a movie got when processing was cancelled, so a resumed run
+// knows what remains. Cleared when the movie is processed again.
+type CancelInfo struct {
+	Stage       ProcessingState `json:"stage"`           // State the movie was in
+	Done        int             `json:"done,omitempty"`  // Screenshots or uploads finished in that stage
+	Total       int             `json:"total,omitempty"` // Screenshots or uploads of that stage, 0 if unknown
+	Message     string          `json:"message"`         // e.g. "Cancelled during upload 4/8"
+	CancelledAt time.Time       `json:"cancelledAt"`
+}
+
+// BatchSummary is emitted as "processing-finished" when a run ends
+type BatchSummary struct {
+	Cancelled bool         `json:"cancelled"` // Stopped by the user, the remaining movies did not fail
+	Summary   StateSummary `json:"summary"`   // Over the movies of the run
+	Remaining []string     `json:"remaining"` // Movies left pending, in list order
+}
+
+// recordCancellation stores the progress of the run's unfinished movies. It runs before the
+// run is cancelled, while the queue still holds their outstanding jobs.
+func (s *SpoilerService) recordCancellation() {
+	s.cancelled.Store(true)
+
+	queued := make(map[string]map[string]int)
+	for _, job := range s.queue.snapshot().Jobs {
+		if queued[job.MovieID] == nil {
+			queued[job.MovieID] = make(map[string]int)
+		}
+		queued[job.MovieID][job.Kind]++
+	}
+
+	now := time.Now()
+	for _, id := range s.runMovieIDs {
+		s.updateMovieByID(id, func(m *Movie) {
+			if m.ProcessingState.IsFinished() {
+				return
+			}
+			info := s.cancelProgress(*m, queued[m.ID])
+			info.CancelledAt = now
+			m.CancelInfo = &info
+		})
+		s.recordEvent(id, "processing", "Processing cancelled", nil)
+	}
+}
+
+// cancelProgress describes the stage a movie is cancelled in, counting its outstanding jobs
+func (s *SpoilerService) cancelProgress(movie Movie, queued map[string]int) CancelInfo {
+	info := CancelInfo{Stage: movie.ProcessingState}
+	switch movie.ProcessingState {
+	case StatePending:
+		info.Message = "Cancelled before processing started"
+	case StateWaitingForScreenshotSlot, StateGeneratingScreenshots:
+		info.Total = s.movieScreenshotLimits(movie.ID).count
+		info.Done = max(info.Total-queued[QueueJobScreenshot], 0)
+		if info.Total == 0 {
+			info.Message = "Cancelled during contact sheet generation"
+		} else {
+			info.Message = fmt.Sprintf("Cancelled during screenshot generation %d/%d", info.Done, info.Total)
+		}
+	case StateWaitingForUploadSlot, StateUploadingScreenshots:
+		for _, uploads := range movie.Uploads {
+			if uploads == nil {
+				continue
+			}
+			if uploads.ContactSheetURL != "" {
+				info.Done++
+			}
+			for _, url := range uploads.ScreenshotURLs {
+				if url != "" {
+					info.Done++
+				}
+			}
+		}
+		info.Total = info.Done + queued[QueueJobUpload]
+		info.Message = fmt.Sprintf("Cancelled during upload %d/%d", info.Done, info.Total)
+	default:
+		info.Message = "Cancelled"
+	}
+	return info
+}
+
+// emitBatchSummary reports the outcome of the run's movies once it finished
+func (s *SpoilerService) emitBatchSummary() {
+	var movies []Movie
+	var remaining []string
+	s.moviesMu.Lock()
+	for _, movie := range s.movies {
+		if !slices.Contains(s.runMovieIDs, movie.ID) {
+			continue
+		}
+		movies = append(movies, movie)
+		if movie.ProcessingState == StatePending {
+			remaining = append(remaining, movie.ID)
+		}
+	}
+	s.moviesMu.Unlock()
+
+	summary := BatchSummary{
+		Cancelled: s.cancelled.Load(),
+		Summary:   summarizeMovies(movies),
+		Remaining: remaining,
+	}
+	if summary.Cancelled {
+		log.Printf("Processing cancelled with %d of %d movies remaining", len(remaining), len(movies))
+	}
+	if s.app != nil {
+		s.app.Event.Emit("processing-finished", summary)
+	}
+}
@@ -0,0 +1,171 @@
+package backend
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/md4"
+)
+
+// ed2kChunkSize is the eDonkey part size, each part is hashed on its own
+const ed2kChunkSize = 9728000
+
+// checksumProgressInterval throttles the checksum-progress events
+const checksumProgressInterval = 500 * time.Millisecond
+
+// ed2kHash computes the ED2K hash: the MD4 of a file below one part, otherwise the MD4 of
+// the concatenated part hashes. Like eMule, a file ending exactly on a part boundary gets
+// the hash of an empty trailing part.
+type ed2kHash struct {
+	part       hash.Hash
+	partFilled int
+	partHashes []byte
+}
+
+func newED2KHash() *ed2kHash {
+	return &ed2kHash{part: md4.New()}
+}
+
+func (h *ed2kHash) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		n := min(len(p), ed2kChunkSize-h.partFilled)
+		h.part.Write(p[:n])
+		h.partFilled += n
+		p = p[n:]
+		if h.partFilled == ed2kChunkSize {
+			h.partHashes = h.part.Sum(h.partHashes)
+			h.part.Reset()
+			h.partFilled = 0
+		}
+	}
+	return written, nil
+}
+
+func (h *ed2kHash) Sum() []byte {
+	if len(h.partHashes) == 0 {
+		return h.part.Sum(nil)
+	}
+	all := md4.New()
+	all.Write(h.partHashes)
+	all.Write(h.part.Sum(nil))
+	return all.Sum(nil)
+}
+
+// progressReader reports the bytes read so far, at most every checksumProgressInterval
+type progressReader struct {
+	r        io.Reader
+	read     int64
+	lastSent time.Time
+	report   func(read int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if time.Since(p.lastSent) >= checksumProgressInterval {
+		p.lastSent = time.Now()
+		p.report(p.read)
+	}
+	return n, err
+}
+
+// computeChecksums hashes a movie's files in a single pass, split movies as one stream,
+// and returns them as %CRC32%, %MD5%, %SHA1% and %ED2K% placeholders
+func (s *SpoilerService) computeChecksums(movie Movie) (map[string]string, error) {
+	paths := movie.Segments
+	if len(paths) == 0 {
+		paths = []string{movie.FilePath}
+	}
+
+	var readers []io.Reader
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+
+	crc := crc32.NewIEEE()
+	md5Hash := md5.New()
+	sha1Hash := sha1.New()
+	ed2k := newED2KHash()
+
+	reader := &progressReader{
+		r: io.MultiReader(readers...),
+		report: func(read int64) {
+			if s.app == nil || movie.FileSizeBytes <= 0 {
+				return
+			}
+			s.app.Event.Emit("checksum-progress", map[string]any{
+				"movieId":  movie.ID,
+				"progress": float64(read) / float64(movie.FileSizeBytes),
+			})
+		},
+	}
+
+	// The copy stops between buffers once processing is cancelled
+	writer := io.MultiWriter(crc, md5Hash, sha1Hash, ed2k)
+	buf := make([]byte, 1<<20)
+	for {
+		if err := s.cancelCtx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := reader.Read(buf)
+		writer.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]string{
+		"%CRC32%": fmt.Sprintf("%08X", crc.Sum32()),
+		"%MD5%":   hex.EncodeToString(md5Hash.Sum(nil)),
+		"%SHA1%":  hex.EncodeToString(sha1Hash.Sum(nil)),
+		"%ED2K%":  hex.EncodeToString(ed2k.Sum()),
+	}, nil
+}
+
+// startChecksums hashes the movie in the background while its media is generated, when
+// enabled and not done before. The returned function waits for the hashes.
+func (s *SpoilerService) startChecksums(movie Movie) func() {
+	if !s.settings.ComputeChecksums || movie.Imported || movie.Params["%CRC32%"] != "" {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		startedAt := time.Now()
+		checksums, err := s.computeChecksums(movie)
+		s.recordEvent(movie.ID, "checksum", fmt.Sprintf("Checksums computed in %s", time.Since(startedAt).Round(time.Second)), err)
+		if err != nil {
+			if s.cancelCtx.Err() == nil {
+				s.addMovieWarning(movie.ID, fmt.Sprintf("Checksum calculation failed: %v", err))
+				log.Printf("Failed to compute checksums of %s: %v", movie.FileName, err)
+			}
+			return
+		}
+
+		s.updateMovieByID(movie.ID, func(m *Movie) {
+			for key, value := range checksums {
+				m.Params[key] = value
+			}
+		})
+	}()
+	return func() { <-done }
+}
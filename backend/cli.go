@@ -0,0 +1,338 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// CLIFlag is the first argument that starts spoilr without a window
+const CLIFlag = "--cli"
+
+// stdinPath as the only path reads the paths from stdin
+const stdinPath = "-"
+
+// CLI exit codes
+const (
+	ExitOK             = 0 // Every movie was processed without errors
+	ExitFailure        = 1 // The run could not complete, e.g. cancelled or the output could not be written
+	ExitUsage          = 2 // Invalid arguments
+	ExitAnalysisFailed = 3 // No input could be analyzed as a video
+	ExitUploadFailed   = 4 // Every movie failed to process
+	ExitPartialSuccess = 5 // Some movies failed, finished with errors, or some inputs failed analysis
+)
+
+// CLIMovieReport is the outcome of a single movie in the CLI JSON output
+type CLIMovieReport struct {
+	ID       string                  `json:"id"`
+	FileName string                  `json:"fileName"`
+	FilePath string                  `json:"filePath"`
+	State    ProcessingState         `json:"state"`
+	Error    string                  `json:"error,omitempty"`
+	Errors   []string                `json:"errors,omitempty"`
+	Warnings []string                `json:"warnings,omitempty"`
+	Uploads  map[string]*HostUploads `json:"uploads,omitempty"`
+	Spoiler  string                  `json:"spoiler,omitempty"`
+}
+
+// CLIReport is the machine-readable result of a CLI run
+type CLIReport struct {
+	ExitCode         int               `json:"exitCode"`
+	Movies           []CLIMovieReport  `json:"movies"`
+	AnalysisFailures []AnalysisFailure `json:"analysisFailures,omitempty"`
+	Result           string            `json:"result"`
+}
+
+// RunCLI processes the given paths without creating a window and prints the generated
+// result to stdout, or writes it to the file given with -output. It returns the exit code.
+func RunCLI(args []string) int {
+	Dialogs.SetInteractive(false)
+
+	flags := flag.NewFlagSet("spoilr "+CLIFlag, flag.ContinueOnError)
+	output := flags.String("output", "", "write the result to this file instead of stdout")
+	flags.StringVar(output, "o", "", "shorthand for -output")
+	preset := flags.String("preset", "", "template preset name or ID to use instead of the current one")
+	hosts := flags.String("hosts", "", "comma-separated image hosts to upload to, e.g. fastpic,imgbox")
+	jsonOutput := flags.Bool("json", false, "print a JSON report with per-movie results instead of the spoiler text")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: spoilr %s [options] <paths...>\n       find ... | spoilr %s [options] -\n\nOptions:\n", CLIFlag, CLIFlag)
+		flags.PrintDefaults()
+		fmt.Fprintf(flags.Output(), "\nExit codes: %d success, %d failure, %d usage, %d analysis failed, %d upload failed, %d partial success\n",
+			ExitOK, ExitFailure, ExitUsage, ExitAnalysisFailed, ExitUploadFailed, ExitPartialSuccess)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 || (len(paths) > 1 && slices.Contains(paths, stdinPath)) {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	service := NewSpoilerService()
+	if err := service.setRunOverrides(*preset, *hosts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return ExitUsage
+	}
+
+	report, err := service.runHeadless(ctx, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if report == nil {
+			return ExitFailure
+		}
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to encode report: %v\n", err)
+			return ExitFailure
+		}
+		if err := writeCLIOutput(*output, append(data, '\n')); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return ExitFailure
+		}
+		return report.ExitCode
+	}
+
+	if report.ExitCode == ExitAnalysisFailed {
+		return report.ExitCode
+	}
+
+	if *output != "" {
+		if err := service.ExportResult(*output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return ExitFailure
+		}
+		log.Printf("Result written to %s", *output)
+		return report.ExitCode
+	}
+
+	fmt.Print(report.Result)
+	return report.ExitCode
+}
+
+// writeCLIOutput writes data to the given file, or to stdout when no file is given
+func writeCLIOutput(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
+
+// setRunOverrides selects the preset and hosts for this run only, the saved config is untouched
+func (s *SpoilerService) setRunOverrides(preset, hosts string) error {
+	if preset != "" {
+		found, ok := s.configManager.FindPreset(preset)
+		if !ok {
+			return fmt.Errorf("preset %q not found", preset)
+		}
+		s.presetOverride = found.ID
+	}
+	s.applyPresetGeneration()
+
+	if hosts == "" {
+		return nil
+	}
+	return s.setHostFilter(strings.Split(hosts, ","))
+}
+
+// setHostFilter limits uploads of the next run to the given configured hosts
+func (s *SpoilerService) setHostFilter(hosts []string) error {
+	filter, err := s.parseHostFilter(hosts)
+	if err != nil {
+		return err
+	}
+	s.hostFilter = filter
+	return nil
+}
+
+// parseHostFilter normalizes host names, rejecting hosts that are not configured
+func (s *SpoilerService) parseHostFilter(hosts []string) ([]string, error) {
+	available := make(map[string]bool, len(s.uploaders))
+	var names []string
+	for _, uploader := range s.uploaders {
+		available[uploader.Name()] = true
+		names = append(names, uploader.Name())
+	}
+
+	var filter []string
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" {
+			continue
+		}
+		if !available[host] {
+			return nil, fmt.Errorf("unknown or unconfigured host %q (available: %s)", host, strings.Join(names, ", "))
+		}
+		filter = append(filter, host)
+	}
+	return filter, nil
+}
+
+// runHeadless adds the paths and processes them synchronously, reporting per-movie
+// errors through the log. A single "-" path reads newline-delimited paths from stdin.
+// A report is returned with the error when no input could be analyzed, or when reading
+// stdin failed after some paths were processed.
+func (s *SpoilerService) runHeadless(ctx context.Context, paths []string) (*CLIReport, error) {
+	s.processing = true
+	s.cancelCtx, s.cancelFn = context.WithCancel(ctx)
+	defer func() {
+		s.cancelFn()
+		s.processing = false
+	}()
+
+	var movieIDs []string
+	var failures []AnalysisFailure
+	var err, readErr error
+	if len(paths) == 1 && paths[0] == stdinPath {
+		// The movies read before a read error were processed, they are still reported
+		movieIDs, failures, readErr = s.processStream(os.Stdin)
+	} else {
+		movieIDs, failures, err = s.addMovies(paths)
+		if err == nil && len(movieIDs) > 0 {
+			err = s.processAllMoviesConcurrently(s.getPendingMovies())
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("processing cancelled")
+	}
+
+	for _, failure := range failures {
+		log.Printf("Analysis failed for %s: %s", failure.FilePath, failure.Error)
+	}
+	if len(movieIDs) == 0 {
+		report := &CLIReport{ExitCode: ExitAnalysisFailed, Movies: []CLIMovieReport{}, AnalysisFailures: failures}
+		if readErr != nil {
+			return report, readErr
+		}
+		return report, fmt.Errorf("no video files found")
+	}
+
+	report := &CLIReport{AnalysisFailures: failures}
+	failed, withErrors := 0, 0
+	for _, id := range movieIDs {
+		movie, exists := s.getMovieByID(id)
+		if !exists {
+			continue
+		}
+		if movie.ProcessingError != "" {
+			log.Printf("%s failed: %s", movie.FileName, movie.ProcessingError)
+		}
+		for _, movieErr := range movie.Errors {
+			log.Printf("%s: %s", movie.FileName, movieErr)
+		}
+		for _, warning := range movie.Warnings {
+			log.Printf("%s: warning: %s", movie.FileName, warning)
+		}
+
+		movieReport := CLIMovieReport{
+			ID:       movie.ID,
+			FileName: movie.FileName,
+			FilePath: movie.FilePath,
+			State:    movie.ProcessingState,
+			Error:    movie.ProcessingError,
+			Errors:   movie.Errors,
+			Warnings: movie.Warnings,
+			Uploads:  movie.Uploads,
+		}
+		if movie.ProcessingState.IsCompleted() {
+			movieReport.Spoiler = s.GenerateResultForMovie(movie.ID)
+			if len(movie.Errors) > 0 {
+				withErrors++
+			}
+		} else {
+			failed++
+		}
+		report.Movies = append(report.Movies, movieReport)
+	}
+	report.Result = s.GenerateResult()
+
+	switch {
+	case failed == len(report.Movies):
+		report.ExitCode = ExitUploadFailed
+	case failed > 0 || withErrors > 0 || len(failures) > 0:
+		report.ExitCode = ExitPartialSuccess
+	default:
+		report.ExitCode = ExitOK
+	}
+	if readErr != nil && report.ExitCode == ExitOK {
+		report.ExitCode = ExitPartialSuccess
+	}
+	return report, readErr
+}
+
+// processStream adds and starts processing every newline-delimited path as soon as it is
+// read, instead of waiting for the whole list
+func (s *SpoilerService) processStream(r io.Reader) ([]string, []AnalysisFailure, error) {
+	tempDir, uploaders, err := s.prepareProcessingRun()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	var wg sync.WaitGroup
+	var movieIDs []string
+	var failures []AnalysisFailure
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() && s.cancelCtx.Err() == nil {
+		path := strings.TrimSpace(scanner.Text())
+		if path == "" {
+			continue
+		}
+
+		ids, pathFailures, err := s.addMovies([]string{path})
+		if err != nil {
+			log.Printf("Skipping %s: %v", path, err)
+			continue
+		}
+		failures = append(failures, pathFailures...)
+
+		for _, id := range ids {
+			movie, exists := s.getMovieByID(id)
+			if !exists {
+				continue
+			}
+			movieIDs = append(movieIDs, id)
+
+			wg.Add(1)
+			go func(movie Movie) {
+				defer wg.Done()
+				s.processMovieWithLimits(movie, tempDir, uploaders, func() {})
+			}(movie)
+		}
+	}
+	wg.Wait()
+
+	if err := scanner.Err(); err != nil {
+		return movieIDs, failures, fmt.Errorf("failed to read paths from stdin: %v", err)
+	}
+	return movieIDs, failures, nil
+}
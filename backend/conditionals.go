@@ -0,0 +1,69 @@
+package backend
+
+import "strings"
+
+const (
+	conditionOpen  = "[if:"
+	conditionClose = "[/if]"
+)
+
+// renderConditionalBlocks evaluates "[if:%X%]...[/if]" blocks: the content is kept when the
+// condition renders to a non-empty value and dropped otherwise, together with the line break
+// that follows it. "[if:!%X%]" negates the condition. Blocks can be nested.
+func (s *SpoilerService) renderConditionalBlocks(template string, movie Movie) string {
+	return RenderConditionalBlocks(template, func(condition string) bool {
+		return s.conditionHolds(condition, movie)
+	})
+}
+
+// RenderConditionalBlocks keeps the content of the "[if:...]...[/if]" blocks whose condition
+// holds and drops the others with the line break that follows them, innermost blocks first
+func RenderConditionalBlocks(template string, holds func(condition string) bool) string {
+	for {
+		// The last opening tag has no block inside it, so the innermost block is resolved first
+		start := strings.LastIndex(template, conditionOpen)
+		if start < 0 {
+			return template
+		}
+		condEnd := strings.Index(template[start:], "]")
+		if condEnd < 0 {
+			return template
+		}
+		condEnd += start
+		bodyEnd := strings.Index(template[condEnd:], conditionClose)
+		if bodyEnd < 0 {
+			return template
+		}
+		bodyEnd += condEnd
+
+		condition := template[start+len(conditionOpen) : condEnd]
+		body := template[condEnd+1 : bodyEnd]
+		rest := template[bodyEnd+len(conditionClose):]
+
+		if !holds(condition) {
+			body = ""
+			if strings.HasPrefix(rest, "\r\n") {
+				rest = rest[2:]
+			} else {
+				rest = strings.TrimPrefix(rest, "\n")
+			}
+		}
+		template = template[:start] + body + rest
+	}
+}
+
+// conditionHolds renders the placeholders of a condition and reports whether a value remains
+func (s *SpoilerService) conditionHolds(condition string, movie Movie) bool {
+	negate := strings.HasPrefix(condition, "!")
+	condition = strings.TrimPrefix(condition, "!")
+
+	value := s.replaceBasicPlaceholders(condition, movie)
+	value = s.replaceUploadPlaceholders(value, movie)
+	value = s.replaceParameterPlaceholders(value, movie)
+	value = replaceNFOPlaceholders(value, movie)
+	value = strings.TrimSpace(value)
+
+	// Missing parameters render as the "−" placeholder
+	holds := value != "" && value != "−"
+	return holds != negate
+}
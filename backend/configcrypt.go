@@ -0,0 +1,274 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	encryptedValuePrefix = "enc:v1:" // Followed by base64 salt, nonce and AES-GCM ciphertext
+	configPassphraseEnv  = "SPOILR_CONFIG_PASSPHRASE"
+	configKeyIterations  = 600000
+	configSaltSize       = 16
+)
+
+// The host logins in the config file can be encrypted with a passphrase, so a portable config
+// does not expose them. The passphrase is never saved, it is asked for on every start.
+var (
+	configCryptMu    sync.Mutex
+	configPassphrase string // Empty while the logins are stored in plain text
+	configSalt       []byte // Salt of configKey, shared by all encrypted values of a save
+	configKey        []byte
+	lockedSecrets    map[string]string // Encrypted values of the loaded config that could not be decrypted yet
+)
+
+// secretFields returns the sensitive config fields by their koanf name
+func secretFields(c *SpoilerConfig) map[string]*string {
+	return map[string]*string{
+		"fastpic_sid":      &c.FastpicSID,
+		"hamster_email":    &c.HamsterEmail,
+		"hamster_password": &c.HamsterPassword,
+		"catbox_user_hash": &c.CatboxUserHash,
+		"tmdb_api_key":     &c.TMDBAPIKey,
+		"omdb_api_key":     &c.OMDbAPIKey,
+	}
+}
+
+// configLocked reports whether the config holds encrypted logins the passphrase is missing for
+func configLocked() bool {
+	configCryptMu.Lock()
+	defer configCryptMu.Unlock()
+	return len(lockedSecrets) > 0
+}
+
+// configEncrypted reports whether the logins are saved encrypted
+func configEncrypted() bool {
+	configCryptMu.Lock()
+	defer configCryptMu.Unlock()
+	return configPassphrase != "" || len(lockedSecrets) > 0
+}
+
+// setConfigPassphrase switches the passphrase used by the next save, empty disables encryption
+func setConfigPassphrase(passphrase string) error {
+	configCryptMu.Lock()
+	defer configCryptMu.Unlock()
+
+	if passphrase == "" {
+		configPassphrase, configSalt, configKey = "", nil, nil
+		return nil
+	}
+	salt := make([]byte, configSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %v", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, configKeyIterations, 32)
+	if err != nil {
+		return fmt.Errorf("failed to derive key: %v", err)
+	}
+	configPassphrase, configSalt, configKey = passphrase, salt, key
+	return nil
+}
+
+// unlockConfigSecrets checks the passphrase against the locked values and keeps it on success
+func unlockConfigSecrets(passphrase string) error {
+	configCryptMu.Lock()
+	defer configCryptMu.Unlock()
+
+	if len(lockedSecrets) == 0 {
+		return fmt.Errorf("config is not locked")
+	}
+	for _, value := range lockedSecrets {
+		if _, err := decryptSecret(passphrase, value); err != nil {
+			return err
+		}
+	}
+	configPassphrase = passphrase
+	lockedSecrets = nil
+	return nil
+}
+
+// decryptConfigSecrets decrypts the encrypted logins of a loaded config. Without the right
+// passphrase, from an earlier unlock or the environment, they are left empty and kept in
+// lockedSecrets so saving writes them back unchanged.
+func decryptConfigSecrets(c *SpoilerConfig) {
+	configCryptMu.Lock()
+	defer configCryptMu.Unlock()
+
+	passphrase := configPassphrase
+	if passphrase == "" {
+		passphrase = os.Getenv(configPassphraseEnv)
+	}
+
+	locked := make(map[string]string)
+	decrypted := false
+	for name, field := range secretFields(c) {
+		if !strings.HasPrefix(*field, encryptedValuePrefix) {
+			continue
+		}
+		value, err := decryptSecret(passphrase, *field)
+		if err != nil {
+			locked[name] = *field
+			*field = ""
+			continue
+		}
+		*field = value
+		decrypted = true
+	}
+
+	if decrypted {
+		configPassphrase = passphrase
+	}
+	if len(locked) > 0 {
+		log.Printf("Config logins are encrypted, %d could not be decrypted until the config is unlocked", len(locked))
+		lockedSecrets = locked
+	} else {
+		lockedSecrets = nil
+	}
+}
+
+// encryptConfigSecrets returns the config as it is saved, with the logins encrypted when a
+// passphrase is set and the values of a locked config written back unchanged
+func encryptConfigSecrets(c SpoilerConfig) (SpoilerConfig, error) {
+	configCryptMu.Lock()
+	defer configCryptMu.Unlock()
+
+	for name, field := range secretFields(&c) {
+		if value, locked := lockedSecrets[name]; locked {
+			*field = value
+			continue
+		}
+		if configKey == nil || *field == "" {
+			continue
+		}
+		value, err := encryptSecret(*field)
+		if err != nil {
+			return c, err
+		}
+		*field = value
+	}
+	return c, nil
+}
+
+// encryptSecret seals a value with configKey, configCryptMu must be held
+func encryptSecret(value string) (string, error) {
+	gcm, err := newConfigCipher(configKey)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	sealed := append(append(bytes.Clone(configSalt), nonce...), gcm.Seal(nil, nonce, []byte(value), nil)...)
+	return encryptedValuePrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret opens an encrypted value, configCryptMu must be held. The key of the value's
+// salt is derived once and reused for the following saves.
+func decryptSecret(passphrase, value string) (string, error) {
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase required")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedValuePrefix))
+	if err != nil || len(sealed) < configSaltSize {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	salt := sealed[:configSaltSize]
+
+	key := configKey
+	if passphrase != configPassphrase || !bytes.Equal(salt, configSalt) {
+		if key, err = pbkdf2.Key(sha256.New, passphrase, salt, configKeyIterations, 32); err != nil {
+			return "", fmt.Errorf("failed to derive key: %v", err)
+		}
+	}
+	gcm, err := newConfigCipher(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < configSaltSize+gcm.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[configSaltSize:configSaltSize+gcm.NonceSize()], sealed[configSaltSize+gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("wrong passphrase")
+	}
+
+	configSalt, configKey = bytes.Clone(salt), key
+	return string(plaintext), nil
+}
+
+func newConfigCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// IsConfigLocked reports whether the config holds encrypted host logins that need the
+// passphrase, which the frontend asks for on start
+func (s *SpoilerService) IsConfigLocked() bool {
+	return configLocked()
+}
+
+// IsConfigEncrypted reports whether the host logins are saved encrypted
+func (s *SpoilerService) IsConfigEncrypted() bool {
+	return configEncrypted()
+}
+
+// UnlockConfig decrypts the host logins of an encrypted config with the passphrase
+func (s *SpoilerService) UnlockConfig(passphrase string) error {
+	if s.processing {
+		return fmt.Errorf("processing already in progress")
+	}
+	if err := unlockConfigSecrets(passphrase); err != nil {
+		return err
+	}
+
+	config := s.configManager.GetConfig()
+	s.settingsMu.Lock()
+	settings := s.settings
+	settings.FastpicSID = config.FastpicSID
+	settings.HamsterEmail = config.HamsterEmail
+	settings.HamsterPassword = config.HamsterPassword
+	settings.CatboxUserHash = config.CatboxUserHash
+	settings.TMDBAPIKey = config.TMDBAPIKey
+	settings.OMDbAPIKey = config.OMDbAPIKey
+	s.applySettings(settings)
+	s.settingsMu.Unlock()
+
+	log.Println("Config unlocked")
+	s.emitState()
+	return nil
+}
+
+// SetConfigPassphrase encrypts the host logins in the config file with the passphrase, or
+// saves them in plain text again when it is empty
+func (s *SpoilerService) SetConfigPassphrase(passphrase string) error {
+	if configLocked() {
+		return fmt.Errorf("unlock the config before changing its passphrase")
+	}
+
+	// Read the config before switching keys, the file is still sealed with the old one
+	config := s.configManager.GetConfig()
+	if err := setConfigPassphrase(passphrase); err != nil {
+		return err
+	}
+	if err := s.configManager.UpdateConfig(config); err != nil {
+		return fmt.Errorf("failed to save config: %v", err)
+	}
+	s.emitState()
+	return nil
+}
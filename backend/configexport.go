@@ -0,0 +1,257 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"reflect"
+	"spoilr/backend/img_uploaders"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	configExportFormat  = "spoilr-config"
+	configExportVersion = 1
+)
+
+// ConfigExport is the shareable file written by ExportConfig
+type ConfigExport struct {
+	Format     string        `json:"format"`
+	Version    int           `json:"version"`
+	AppVersion string        `json:"appVersion"`
+	ExportedAt time.Time     `json:"exportedAt"`
+	Config     SpoilerConfig `json:"config"`
+}
+
+// ConfigImportResult reports how an imported config was merged
+type ConfigImportResult struct {
+	PresetsAdded   []string `json:"presetsAdded"`
+	PresetsRenamed []string `json:"presetsRenamed"` // Imported under a new name, a local preset had the name
+	PresetsSkipped []string `json:"presetsSkipped"` // Identical to a local preset
+	// Credentials kept because the local ones are set or the imported ones are encrypted with
+	// another passphrase
+	CredentialsKept []string `json:"credentialsKept"`
+}
+
+// ExportConfig writes the settings and template presets to a single file that can be shared.
+// Host logins and API keys are left out unless the config is encrypted, then they are written
+// encrypted as saved.
+func (s *SpoilerService) ExportConfig(path string) error {
+	return s.exportConfig(path, configEncrypted())
+}
+
+// ExportConfigWithCredentials is ExportConfig including the host logins and API keys, in
+// plain text when the config is not encrypted
+func (s *SpoilerService) ExportConfigWithCredentials(path string) error {
+	return s.exportConfig(path, true)
+}
+
+func (s *SpoilerService) exportConfig(path string, includeCredentials bool) error {
+	if configLocked() {
+		return fmt.Errorf("unlock the config before exporting it")
+	}
+
+	config, err := encryptConfigSecrets(s.configManager.GetConfig())
+	if err != nil {
+		return fmt.Errorf("failed to encrypt config: %v", err)
+	}
+	config.Window = WindowState{} // Window geometry belongs to this machine
+	if !includeCredentials {
+		for _, field := range secretFields(&config) {
+			*field = ""
+		}
+	}
+
+	data, err := json.MarshalIndent(ConfigExport{
+		Format:     configExportFormat,
+		Version:    configExportVersion,
+		AppVersion: AppVersion,
+		ExportedAt: time.Now(),
+		Config:     config,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize config: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config export: %v", err)
+	}
+
+	log.Printf("Config exported to %s", path)
+	return nil
+}
+
+// ImportConfig merges a file written by ExportConfig into the config. The imported settings
+// replace the current ones, while local presets are never overwritten: imported presets are
+// added, renamed when a local preset has the same name, and skipped when identical. Local
+// host logins, host endpoints, window geometry and the current preset are kept.
+func (s *SpoilerService) ImportConfig(path string) (ConfigImportResult, error) {
+	var result ConfigImportResult
+	if s.processing {
+		return result, fmt.Errorf("processing already in progress")
+	}
+	if configLocked() {
+		return result, fmt.Errorf("unlock the config before importing")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return result, fmt.Errorf("failed to read config export: %v", err)
+	}
+	// Settings missing from older exports keep their defaults
+	export := ConfigExport{Config: DefaultSpoilerConfig}
+	export.Config.HostUploadLimits = maps.Clone(DefaultSpoilerConfig.HostUploadLimits)
+	export.Config.TemplatePresets = nil
+	if err := json.Unmarshal(data, &export); err != nil {
+		return result, fmt.Errorf("failed to parse config export: %v", err)
+	}
+	if export.Format != configExportFormat {
+		return result, fmt.Errorf("not a spoilr config export")
+	}
+	if export.Version > configExportVersion {
+		return result, fmt.Errorf("config export version %d needs a newer spoilr", export.Version)
+	}
+	for _, preset := range export.Config.TemplatePresets {
+		if err := validateImportedPreset(preset); err != nil {
+			return result, err
+		}
+	}
+
+	current := s.configManager.GetConfig()
+	merged := export.Config
+	merged.Window = current.Window
+	merged.CurrentPresetID = current.CurrentPresetID
+	// The local logins are sent to these, a shared file must not point them elsewhere
+	merged.FastpicBaseURL = current.FastpicBaseURL
+	merged.FastpicMirrors = current.FastpicMirrors
+	result.CredentialsKept = mergeImportedCredentials(&merged, current)
+	merged.TemplatePresets = mergeImportedPresets(current.TemplatePresets, export.Config.TemplatePresets, &result)
+
+	if err := s.configManager.UpdateConfig(merged); err != nil {
+		return result, fmt.Errorf("invalid config export: %v", err)
+	}
+
+	s.settingsMu.Lock()
+	s.applySettings(settingsFromConfig(s.configManager.GetConfig()))
+	s.settingsMu.Unlock()
+
+	log.Printf("Config imported from %s: %d presets added, %d renamed, %d skipped",
+		path, len(result.PresetsAdded), len(result.PresetsRenamed), len(result.PresetsSkipped))
+	return result, nil
+}
+
+// validateImportedPreset rejects presets this version cannot render
+func validateImportedPreset(preset TemplatePreset) error {
+	if strings.TrimSpace(preset.Name) == "" || preset.Template == "" {
+		return fmt.Errorf("imported preset %q has no name or template", preset.Name)
+	}
+	switch preset.TemplateMode {
+	case "", TemplateModePlaceholders:
+	case TemplateModeGo:
+		if err := validateGoTemplate(preset.Template); err != nil {
+			return fmt.Errorf("imported preset %q does not parse: %v", preset.Name, err)
+		}
+	default:
+		return fmt.Errorf("imported preset %q has unknown template mode %q", preset.Name, preset.TemplateMode)
+	}
+	if !isValidOutputFormat(preset.OutputFormat) {
+		return fmt.Errorf("imported preset %q has unknown output format %q", preset.Name, preset.OutputFormat)
+	}
+	if preset.BBCodeDialect != "" && !img_uploaders.IsValidBBCodeDialect(preset.BBCodeDialect) {
+		return fmt.Errorf("imported preset %q has unknown BBCode dialect %q", preset.Name, preset.BBCodeDialect)
+	}
+	if preset.Watermark != nil {
+		if err := preset.Watermark.validate(); err != nil {
+			return fmt.Errorf("imported preset %q: %v", preset.Name, err)
+		}
+	}
+	if err := validatePresetGeneration(preset); err != nil {
+		return fmt.Errorf("imported preset %q: %v", preset.Name, err)
+	}
+	return nil
+}
+
+// mergeImportedCredentials keeps the local host logins that are set, and imported ones that
+// cannot be decrypted with the local passphrase. It returns the names of the kept logins.
+func mergeImportedCredentials(merged *SpoilerConfig, current SpoilerConfig) []string {
+	var kept []string
+	imported := secretFields(merged)
+	for name, local := range secretFields(&current) {
+		field := imported[name]
+		if strings.HasPrefix(*field, encryptedValuePrefix) {
+			configCryptMu.Lock()
+			value, err := decryptSecret(configPassphrase, *field)
+			configCryptMu.Unlock()
+			if err != nil {
+				*field = *local
+				kept = append(kept, name)
+				continue
+			}
+			*field = value
+		}
+		if *local == "" {
+			continue
+		}
+		if *field != "" && *field != *local {
+			kept = append(kept, name)
+		}
+		*field = *local
+	}
+	return kept
+}
+
+// mergeImportedPresets appends the imported presets to the local ones without overwriting any
+func mergeImportedPresets(local, imported []TemplatePreset, result *ConfigImportResult) []TemplatePreset {
+	merged := append([]TemplatePreset(nil), local...)
+	names := make(map[string]bool, len(local))
+	ids := make(map[string]bool, len(local))
+	for _, preset := range local {
+		names[preset.Name] = true
+		ids[preset.ID] = true
+	}
+
+	for _, preset := range imported {
+		if containsEqualPreset(local, preset) {
+			result.PresetsSkipped = append(result.PresetsSkipped, preset.Name)
+			continue
+		}
+		if preset.ID == "" || ids[preset.ID] {
+			preset.ID = uuid.New().String()
+		}
+		if names[preset.Name] {
+			preset.Name = uniquePresetName(preset.Name+" (imported)", names)
+			result.PresetsRenamed = append(result.PresetsRenamed, preset.Name)
+		} else {
+			result.PresetsAdded = append(result.PresetsAdded, preset.Name)
+		}
+		names[preset.Name] = true
+		ids[preset.ID] = true
+		merged = append(merged, preset)
+	}
+	return merged
+}
+
+// containsEqualPreset reports whether a local preset has the same name and content, whatever
+// its ID
+func containsEqualPreset(local []TemplatePreset, preset TemplatePreset) bool {
+	for _, existing := range local {
+		existing.ID = preset.ID
+		if reflect.DeepEqual(existing, preset) {
+			return true
+		}
+	}
+	return false
+}
+
+// uniquePresetName numbers a name until no preset uses it
+func uniquePresetName(name string, names map[string]bool) string {
+	candidate := name
+	for i := 2; names[candidate]; i++ {
+		candidate = fmt.Sprintf("%s %d", name, i)
+	}
+	return candidate
+}
@@ -3,8 +3,11 @@ package backend
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
+	"spoilr/backend/img_uploaders"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/knadh/koanf/parsers/yaml"
@@ -14,18 +17,79 @@ import (
 )
 
 type SpoilerConfig struct {
-	ScreenshotCount          int              `json:"screenshotCount" koanf:"screenshot_count"`
-	FastpicSID               string           `json:"fastpicSid" koanf:"fastpic_sid"`
-	ScreenshotQuality        int              `json:"screenshotQuality" koanf:"screenshot_quality"`
-	MaxConcurrentScreenshots int              `json:"maxConcurrentScreenshots" koanf:"max_concurrent_screenshots"`
-	MaxConcurrentUploads     int              `json:"maxConcurrentUploads" koanf:"max_concurrent_uploads"`
-	CurrentPresetID          string           `json:"currentPresetId" koanf:"current_preset_id"`
-	TemplatePresets          []TemplatePreset `json:"templatePresets" koanf:"template_presets"`
-	MtnArgs                  string           `json:"mtnArgs" koanf:"mtn_args"`
-	ImageMiniatureSize       int              `json:"imageMiniatureSize" koanf:"image_miniature_size"`
+	ScreenshotCount            int              `json:"screenshotCount" koanf:"screenshot_count"`
+	FastpicSID                 string           `json:"fastpicSid" koanf:"fastpic_sid"`
+	ScreenshotQuality          int              `json:"screenshotQuality" koanf:"screenshot_quality"`
+	MaxConcurrentScreenshots   int              `json:"maxConcurrentScreenshots" koanf:"max_concurrent_screenshots"`
+	MaxConcurrentContactSheets int              `json:"maxConcurrentContactSheets" koanf:"max_concurrent_contact_sheets"`
+	MaxConcurrentUploads       int              `json:"maxConcurrentUploads" koanf:"max_concurrent_uploads"`
+	CurrentPresetID            string           `json:"currentPresetId" koanf:"current_preset_id"`
+	TemplatePresets            []TemplatePreset `json:"templatePresets" koanf:"template_presets"`
+	MtnArgs                    string           `json:"mtnArgs" koanf:"mtn_args"`
+	ImageMiniatureSize         int              `json:"imageMiniatureSize" koanf:"image_miniature_size"`
+	// Fastpic upload options
+	FastpicDeleteAfterDays int      `json:"fastpicDeleteAfterDays" koanf:"fastpic_delete_after_days"`
+	FastpicOrigResize      int      `json:"fastpicOrigResize" koanf:"fastpic_orig_resize"`
+	FastpicOptimization    bool     `json:"fastpicOptimization" koanf:"fastpic_optimization"`
+	FastpicBaseURL         string   `json:"fastpicBaseUrl" koanf:"fastpic_base_url"`
+	FastpicMirrors         []string `json:"fastpicMirrors" koanf:"fastpic_mirrors"`
+	// Per-host thumbnail sizes, 0 means use ImageMiniatureSize
+	FastpicMiniatureSize      int            `json:"fastpicMiniatureSize" koanf:"fastpic_miniature_size"`
+	ImgboxMiniatureSize       int            `json:"imgboxMiniatureSize" koanf:"imgbox_miniature_size"`
+	AnonymizeUploads          bool           `json:"anonymizeUploads" koanf:"anonymize_uploads"` // Upload images under random file names
+	RenamePattern             string         `json:"renamePattern" koanf:"rename_pattern"`
+	ReadOnlySources           bool           `json:"readOnlySources" koanf:"read_only_sources"`
+	GroupHeaderTemplate       string         `json:"groupHeaderTemplate" koanf:"group_header_template"`
+	NestGroupSpoilers         bool           `json:"nestGroupSpoilers" koanf:"nest_group_spoilers"`
+	CollectionSpoilerTemplate string         `json:"collectionSpoilerTemplate" koanf:"collection_spoiler_template"`
+	OutputLineEnding          string         `json:"outputLineEnding" koanf:"output_line_ending"`
+	OutputBOM                 bool           `json:"outputBom" koanf:"output_bom"`
+	SpoilerTitleMaxLength     int            `json:"spoilerTitleMaxLength" koanf:"spoiler_title_max_length"`
+	PipelinedUploads          bool           `json:"pipelinedUploads" koanf:"pipelined_uploads"`
+	ProcessingOrder           string         `json:"processingOrder" koanf:"processing_order"`
+	ImgboxFamilySafe          bool           `json:"imgboxFamilySafe" koanf:"imgbox_family_safe"`
+	CatboxUserHash            string         `json:"catboxUserHash" koanf:"catbox_user_hash"`
+	CatboxTemporary           bool           `json:"catboxTemporary" koanf:"catbox_temporary"`
+	LitterboxExpiry           string         `json:"litterboxExpiry" koanf:"litterbox_expiry"`
+	BatchHeaderTemplate       string         `json:"batchHeaderTemplate" koanf:"batch_header_template"`
+	BatchFooterTemplate       string         `json:"batchFooterTemplate" koanf:"batch_footer_template"`
+	ComputeChecksums          bool           `json:"computeChecksums" koanf:"compute_checksums"`
+	MaxTempUsageMB            int            `json:"maxTempUsageMb" koanf:"max_temp_usage_mb"`
+	ScreenshotMode            string         `json:"screenshotMode" koanf:"screenshot_mode"`
+	ScreenshotJitterSeconds   int            `json:"screenshotJitterSeconds" koanf:"screenshot_jitter_seconds"`
+	ScreenshotStartOffset     string         `json:"screenshotStartOffset" koanf:"screenshot_start_offset"`
+	ScreenshotEndOffset       string         `json:"screenshotEndOffset" koanf:"screenshot_end_offset"`
+	BBCodeDialect             string         `json:"bbCodeDialect" koanf:"bbcode_dialect"`
+	WatermarkText             string         `json:"watermarkText" koanf:"watermark_text"`
+	WatermarkImage            string         `json:"watermarkImage" koanf:"watermark_image"`
+	WatermarkPosition         string         `json:"watermarkPosition" koanf:"watermark_position"`
+	WatermarkOpacity          int            `json:"watermarkOpacity" koanf:"watermark_opacity"`
+	ScreenshotFormat          string         `json:"screenshotFormat" koanf:"screenshot_format"`
+	WebPQuality               int            `json:"webpQuality" koanf:"webp_quality"`
+	WebPLossless              bool           `json:"webpLossless" koanf:"webp_lossless"`
+	HostUploadLimits          map[string]int `json:"hostUploadLimits" koanf:"host_upload_limits"`
+	ResultFooterEnabled       bool           `json:"resultFooterEnabled" koanf:"result_footer_enabled"`
+	ResultFooterTemplate      string         `json:"resultFooterTemplate" koanf:"result_footer_template"`
+	DisableSimilarityCheck    bool           `json:"disableSimilarityCheck" koanf:"disable_similarity_check"`
+	LocalOutputDir            string         `json:"localOutputDir" koanf:"local_output_dir"`
+	DisableUploadCache        bool           `json:"disableUploadCache" koanf:"disable_upload_cache"`
+	TMDBAPIKey                string         `json:"tmdbApiKey" koanf:"tmdb_api_key"`
+	OMDbAPIKey                string         `json:"omdbApiKey" koanf:"omdb_api_key"`
+	MetadataLanguage          string         `json:"metadataLanguage" koanf:"metadata_language"`
+	DegradeOnUploadFailures   bool           `json:"degradeOnUploadFailures" koanf:"degrade_on_upload_failures"`
+	DegradeAfterFailures      int            `json:"degradeAfterFailures" koanf:"degrade_after_failures"`
+	DegradedScreenshotCount   int            `json:"degradedScreenshotCount" koanf:"degraded_screenshot_count"`
+	DegradedQuality           int            `json:"degradedQuality" koanf:"degraded_quality"`
+	ChecklistItems            []string       `json:"checklistItems" koanf:"checklist_items"`
+	AddTorrentVideos          bool           `json:"addTorrentVideos" koanf:"add_torrent_videos"`
+	GroupByFolder             bool           `json:"groupByFolder" koanf:"group_by_folder"`
 	// Hamster settings
-	HamsterEmail    string `json:"hamsterEmail" koanf:"hamster_email"`
-	HamsterPassword string `json:"hamsterPassword" koanf:"hamster_password"`
+	HamsterEmail       string `json:"hamsterEmail" koanf:"hamster_email"`
+	HamsterPassword    string `json:"hamsterPassword" koanf:"hamster_password"`
+	HamsterResizeWidth int    `json:"hamsterResizeWidth" koanf:"hamster_resize_width"`
+	HamsterExpiration  string `json:"hamsterExpiration" koanf:"hamster_expiration"`
+	// Window geometry and theme, restored on startup
+	Window WindowState `json:"window" koanf:"window"`
 }
 
 var SpoilerAppConfig SpoilerConfig
@@ -37,7 +101,8 @@ File: %FILE_NAME%
 Size: %FILE_SIZE%
 Duration: %DURATION%
 Video: %VIDEO_CODEC% / %VIDEO_FPS% FPS / %WIDTH%x%HEIGHT% / %VIDEO_BIT_RATE%
-Audio: %AUDIO_CODEC% / %AUDIO_SAMPLE_RATE% / %AUDIO_CHANNELS% / %AUDIO_BIT_RATE%
+[if:%HDR_FORMAT%]HDR: %HDR_FORMAT%
+[/if]Audio: %AUDIO_CODEC% / %AUDIO_SAMPLE_RATE% / %AUDIO_CHANNELS% / %AUDIO_BIT_RATE%
 
 %CONTACT_SHEET_FP%
 
@@ -60,7 +125,8 @@ File: %FILE_NAME%
 Size: %FILE_SIZE%
 Duration: %DURATION%
 Video: %VIDEO_CODEC% / %VIDEO_FPS% FPS / %WIDTH%x%HEIGHT% / %VIDEO_BIT_RATE%
-Audio: %AUDIO_CODEC% / %AUDIO_SAMPLE_RATE% / %AUDIO_CHANNELS% / %AUDIO_BIT_RATE%
+[if:%HDR_FORMAT%]HDR: %HDR_FORMAT%
+[/if]Audio: %AUDIO_CODEC% / %AUDIO_SAMPLE_RATE% / %AUDIO_CHANNELS% / %AUDIO_BIT_RATE%
 
 %CONTACT_SHEET_HAM%
 
@@ -71,17 +137,76 @@ Audio: %AUDIO_CODEC% / %AUDIO_SAMPLE_RATE% / %AUDIO_CHANNELS% / %AUDIO_BIT_RATE%
 }
 
 var DefaultSpoilerConfig = SpoilerConfig{
-	ScreenshotCount:          6,
-	FastpicSID:               "",
-	ScreenshotQuality:        2,
-	MaxConcurrentScreenshots: 3,
-	MaxConcurrentUploads:     2,
-	CurrentPresetID:          "default-pl",
-	TemplatePresets:          getDefaultPresets(),
-	MtnArgs:                  "-b 2 -w 1200 -c 4 -r 4 -g 0 -k 1C1C1C -L 4:2 -F F0FFFF:10",
-	ImageMiniatureSize:       350,
-	HamsterEmail:             "",
-	HamsterPassword:          "",
+	ScreenshotCount:            6,
+	FastpicSID:                 "",
+	FastpicDeleteAfterDays:     0,
+	FastpicOrigResize:          0,
+	FastpicOptimization:        false,
+	FastpicBaseURL:             img_uploaders.DefaultFastpicBaseURL,
+	FastpicMirrors:             []string{},
+	ScreenshotQuality:          2,
+	MaxConcurrentScreenshots:   3,
+	MaxConcurrentContactSheets: 1,
+	MaxConcurrentUploads:       2,
+	CurrentPresetID:            "default-pl",
+	TemplatePresets:            getDefaultPresets(),
+	MtnArgs:                    "-b 2 -w 1200 -c 4 -r 4 -g 0 -k 1C1C1C -L 4:2 -F F0FFFF:10",
+	ImageMiniatureSize:         350,
+	FastpicMiniatureSize:       0,
+	ImgboxMiniatureSize:        0,
+	AnonymizeUploads:           false,
+	RenamePattern:              "",
+	ReadOnlySources:            false,
+	GroupHeaderTemplate:        "[size=16][b]%GROUP_NAME%[/b][/size]",
+	NestGroupSpoilers:          false,
+	CollectionSpoilerTemplate: `[spoiler="%GROUP_NAME% [%GROUP_COUNT% files, %GROUP_SIZE%]"]
+%GROUP_CONTENT%
+[/spoiler]`,
+	OutputLineEnding:        LineEndingLF,
+	OutputBOM:               false,
+	SpoilerTitleMaxLength:   0,
+	PipelinedUploads:        false,
+	ProcessingOrder:         ProcessingOrderList,
+	ImgboxFamilySafe:        false,
+	HamsterResizeWidth:      0,
+	HamsterExpiration:       "",
+	CatboxUserHash:          "",
+	CatboxTemporary:         false,
+	LitterboxExpiry:         "72h",
+	BatchHeaderTemplate:     "",
+	BatchFooterTemplate:     "",
+	ComputeChecksums:        false,
+	MaxTempUsageMB:          0,
+	ScreenshotMode:          ScreenshotModeUniform,
+	ScreenshotJitterSeconds: 0,
+	ScreenshotStartOffset:   "",
+	ScreenshotEndOffset:     "",
+	BBCodeDialect:           string(img_uploaders.BBCodeUppercase),
+	WatermarkText:           "",
+	WatermarkImage:          "",
+	WatermarkPosition:       WatermarkBottomRight,
+	WatermarkOpacity:        50,
+	ScreenshotFormat:        ScreenshotFormatJPEG,
+	WebPQuality:             90,
+	WebPLossless:            false,
+	HostUploadLimits:        map[string]int{"fastpic": 2, "imgbox": 4, "hamster": 1},
+	ResultFooterEnabled:     false,
+	ResultFooterTemplate:    DefaultResultFooterTemplate,
+	DisableSimilarityCheck:  false,
+	LocalOutputDir:          "",
+	DisableUploadCache:      false,
+	TMDBAPIKey:              "",
+	OMDbAPIKey:              "",
+	MetadataLanguage:        "en-US",
+	DegradeOnUploadFailures: false,
+	DegradeAfterFailures:    5,
+	DegradedScreenshotCount: 3,
+	DegradedQuality:         8,
+	ChecklistItems:          []string{"Sample cut", "NFO written", "Posted"},
+	AddTorrentVideos:        false,
+	GroupByFolder:           false,
+	HamsterEmail:            "",
+	HamsterPassword:         "",
 }
 
 type ConfigService struct{}
@@ -106,7 +231,7 @@ func (g *ConfigService) GetConfig() SpoilerConfig {
 		SpoilerAppConfig = loadSpoilerAppConfig()
 	}
 
-	log.Println("Spoiler Config", SpoilerAppConfig)
+	log.Println("Spoiler Config", sanitizeConfig(SpoilerAppConfig))
 	return SpoilerAppConfig
 }
 
@@ -118,15 +243,106 @@ func (g *ConfigService) UpdateConfig(config SpoilerConfig) error {
 	if config.MaxConcurrentScreenshots < 1 {
 		return fmt.Errorf("max concurrent screenshots must be at least 1")
 	}
+	if config.MaxConcurrentContactSheets < 1 {
+		return fmt.Errorf("max concurrent contact sheets must be at least 1")
+	}
 	if config.MaxConcurrentUploads < 1 {
 		return fmt.Errorf("max concurrent uploads must be at least 1")
 	}
+	for host, limit := range config.HostUploadLimits {
+		if limit < 1 {
+			return fmt.Errorf("upload limit of %s must be at least 1", host)
+		}
+	}
 	if config.ScreenshotQuality < 1 || config.ScreenshotQuality > 31 {
 		return fmt.Errorf("screenshot quality must be between 1 and 31")
 	}
 	if config.ImageMiniatureSize < 100 || config.ImageMiniatureSize > 800 {
 		return fmt.Errorf("image miniature size must be between 100 and 800")
 	}
+	if config.FastpicDeleteAfterDays < 0 {
+		return fmt.Errorf("fastpic delete after days cannot be negative")
+	}
+	if config.MaxTempUsageMB < 0 {
+		return fmt.Errorf("max temp usage cannot be negative")
+	}
+	if config.ScreenshotJitterSeconds < 0 || config.ScreenshotJitterSeconds > 60 {
+		return fmt.Errorf("screenshot jitter must be between 0 and 60 seconds")
+	}
+	if config.FastpicOrigResize != 0 && (config.FastpicOrigResize < 100 || config.FastpicOrigResize > 10000) {
+		return fmt.Errorf("fastpic resize width must be 0 or between 100 and 10000")
+	}
+	if config.FastpicBaseURL != "" && !isValidBaseURL(config.FastpicBaseURL) {
+		return fmt.Errorf("fastpic base URL must be an http(s) URL")
+	}
+	if err := validateChecklistItems(config.ChecklistItems); err != nil {
+		return err
+	}
+	for _, mirror := range config.FastpicMirrors {
+		if !isValidBaseURL(mirror) {
+			return fmt.Errorf("fastpic mirror %q must be an http(s) URL", mirror)
+		}
+	}
+	if config.HamsterResizeWidth != 0 && (config.HamsterResizeWidth < 100 || config.HamsterResizeWidth > 10000) {
+		return fmt.Errorf("hamster resize width must be 0 or between 100 and 10000")
+	}
+	if !img_uploaders.IsValidHamsterExpiration(config.HamsterExpiration) {
+		return fmt.Errorf("hamster expiration must be an interval like PT1H, P1D, P1W, P1M or P1Y")
+	}
+	if !img_uploaders.IsValidLitterboxExpiry(config.LitterboxExpiry) {
+		return fmt.Errorf("litterbox expiry must be one of %s", strings.Join(img_uploaders.LitterboxExpiries, ", "))
+	}
+	if config.OutputLineEnding != LineEndingLF && config.OutputLineEnding != LineEndingCRLF {
+		return fmt.Errorf("output line ending must be %q or %q", LineEndingLF, LineEndingCRLF)
+	}
+	if config.SpoilerTitleMaxLength < 0 {
+		return fmt.Errorf("spoiler title max length cannot be negative")
+	}
+	if !isValidProcessingOrder(config.ProcessingOrder) {
+		return fmt.Errorf("unknown processing order %q", config.ProcessingOrder)
+	}
+	if !img_uploaders.IsValidBBCodeDialect(config.BBCodeDialect) {
+		return fmt.Errorf("unknown BBCode dialect %q", config.BBCodeDialect)
+	}
+	if !isValidWatermarkPosition(config.WatermarkPosition) {
+		return fmt.Errorf("unknown watermark position %q", config.WatermarkPosition)
+	}
+	if config.WatermarkOpacity < 0 || config.WatermarkOpacity > 100 {
+		return fmt.Errorf("watermark opacity must be between 0 and 100")
+	}
+	if !isValidScreenshotFormat(config.ScreenshotFormat) {
+		return fmt.Errorf("unknown screenshot format %q", config.ScreenshotFormat)
+	}
+	if config.WebPQuality < 0 || config.WebPQuality > 100 {
+		return fmt.Errorf("WebP quality must be between 0 and 100")
+	}
+	if !isValidScreenshotMode(config.ScreenshotMode) {
+		return fmt.Errorf("unknown screenshot mode %q", config.ScreenshotMode)
+	}
+	if !isValidScreenshotOffset(config.ScreenshotStartOffset) || !isValidScreenshotOffset(config.ScreenshotEndOffset) {
+		return fmt.Errorf("screenshot offsets must be a percentage like 5%% or seconds like 90")
+	}
+	if !isValidHostMiniatureSize(config.FastpicMiniatureSize) {
+		return fmt.Errorf("fastpic miniature size must be 0 or between 100 and 800")
+	}
+	if !isValidHostMiniatureSize(config.ImgboxMiniatureSize) {
+		return fmt.Errorf("imgbox miniature size must be 0 or between 100 and 800")
+	}
+	if config.LocalOutputDir != "" && !filepath.IsAbs(config.LocalOutputDir) {
+		return fmt.Errorf("output folder must be an absolute path")
+	}
+	if !isValidMetadataLanguage(config.MetadataLanguage) {
+		return fmt.Errorf("metadata language must look like \"en\" or \"en-US\"")
+	}
+	if config.DegradeAfterFailures < 1 || config.DegradeAfterFailures > 100 {
+		return fmt.Errorf("degradation failure threshold must be between 1 and 100")
+	}
+	if config.DegradedScreenshotCount < 0 || config.DegradedScreenshotCount > 20 {
+		return fmt.Errorf("degraded screenshot count must be between 0 and 20")
+	}
+	if config.DegradedQuality < 1 || config.DegradedQuality > 31 {
+		return fmt.Errorf("degraded screenshot quality must be between 1 and 31")
+	}
 
 	// Ensure we always have at least one preset
 	if len(config.TemplatePresets) == 0 {
@@ -150,6 +366,36 @@ func (g *ConfigService) UpdateConfig(config SpoilerConfig) error {
 	return saveSpoilerAppConfig()
 }
 
+// isValidHostMiniatureSize accepts 0 (inherit global size) or a regular miniature size
+func isValidHostMiniatureSize(size int) bool {
+	return size == 0 || (size >= 100 && size <= 800)
+}
+
+// isValidBaseURL reports whether a host base URL is an absolute http(s) URL
+func isValidBaseURL(baseURL string) bool {
+	parsed, err := url.Parse(baseURL)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// SaveWindowState persists the window geometry and theme preference
+func (g *ConfigService) SaveWindowState(state WindowState) error {
+	if state.Width < 0 || state.Height < 0 {
+		return fmt.Errorf("window size cannot be negative")
+	}
+	switch state.Theme {
+	case "", "light", "dark", "system":
+	default:
+		return fmt.Errorf("unknown theme %q", state.Theme)
+	}
+	if state.Theme == "" {
+		state.Theme = DefaultSpoilerConfig.Window.Theme
+	}
+
+	config := g.GetConfig()
+	config.Window = state
+	return g.UpdateConfig(config)
+}
+
 func (g *ConfigService) SaveTemplatePreset(preset TemplatePreset) error {
 	config := g.GetConfig()
 
@@ -211,25 +457,62 @@ func (g *ConfigService) SetCurrentPreset(presetID string) error {
 	return fmt.Errorf("preset not found")
 }
 
-func (g *ConfigService) GetCurrentTemplate() string {
+// GetCurrentPreset returns the active preset, falling back to the first one
+func (g *ConfigService) GetCurrentPreset() (TemplatePreset, bool) {
 	config := g.GetConfig()
 
 	// Find current preset
 	for _, preset := range config.TemplatePresets {
 		if preset.ID == config.CurrentPresetID {
-			return preset.Template
+			return preset, true
 		}
 	}
 
 	// Fallback to first preset if current preset not found
 	if len(config.TemplatePresets) > 0 {
-		return config.TemplatePresets[0].Template
+		return config.TemplatePresets[0], true
+	}
+
+	return TemplatePreset{}, false
+}
+
+func (g *ConfigService) GetCurrentTemplate() string {
+	if preset, ok := g.GetCurrentPreset(); ok {
+		return preset.Template
 	}
 
 	// Ultimate fallback
 	return getDefaultTemplate()
 }
 
+// FindPreset returns the preset with the given ID or, case-insensitively, name
+func (g *ConfigService) FindPreset(idOrName string) (TemplatePreset, bool) {
+	config := g.GetConfig()
+	for _, preset := range config.TemplatePresets {
+		if preset.ID == idOrName {
+			return preset, true
+		}
+	}
+	for _, preset := range config.TemplatePresets {
+		if strings.EqualFold(preset.Name, idOrName) {
+			return preset, true
+		}
+	}
+	return TemplatePreset{}, false
+}
+
+// updatePreset applies updateFn to the preset with the given ID and saves the config
+func (g *ConfigService) updatePreset(presetID string, updateFn func(*TemplatePreset)) error {
+	config := g.GetConfig()
+	for i := range config.TemplatePresets {
+		if config.TemplatePresets[i].ID == presetID {
+			updateFn(&config.TemplatePresets[i])
+			return g.UpdateConfig(config)
+		}
+	}
+	return fmt.Errorf("preset not found")
+}
+
 func initSpoilerConfigPath() {
 	// First try portable config in executable directory
 	wdDir, err := os.Getwd()
@@ -248,6 +531,12 @@ func initSpoilerConfigPath() {
 	ConfigPath = filepath.Join(userConfigDir, "spoilr.config")
 }
 
+// getConfigDir returns the directory holding the config file and other app data
+func getConfigDir() string {
+	initSpoilerConfigPath()
+	return filepath.Dir(ConfigPath)
+}
+
 func getUserConfigDir() string {
 	dirname, err := os.UserConfigDir()
 	if err != nil {
@@ -260,7 +549,11 @@ func saveSpoilerAppConfig() error {
 	initSpoilerConfigPath()
 	k := koanf.New(".")
 
-	err := k.Load(structs.Provider(SpoilerAppConfig, "koanf"), nil)
+	stored, err := encryptConfigSecrets(SpoilerAppConfig)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt config: %v", err)
+	}
+	err = k.Load(structs.Provider(stored, "koanf"), nil)
 	if err != nil {
 		fmt.Println(err)
 		return err
@@ -277,6 +570,7 @@ func saveSpoilerAppConfig() error {
 		return err
 	}
 
+	rememberSavedConfig(b)
 	err = os.WriteFile(ConfigPath, b, 0644)
 	if err != nil {
 		fmt.Println(err)
@@ -298,6 +592,7 @@ func loadSpoilerAppConfig() SpoilerConfig {
 		log.Printf("error unmarshaling spoiler app config: %v", err)
 		return DefaultSpoilerConfig
 	}
+	decryptConfigSecrets(&c)
 
 	// Validate and set defaults for invalid values
 	if c.ScreenshotCount < 0 || c.ScreenshotCount > 20 {
@@ -306,18 +601,116 @@ func loadSpoilerAppConfig() SpoilerConfig {
 	if c.MaxConcurrentScreenshots < 1 {
 		c.MaxConcurrentScreenshots = DefaultSpoilerConfig.MaxConcurrentScreenshots
 	}
+	if c.MaxConcurrentContactSheets < 1 {
+		c.MaxConcurrentContactSheets = DefaultSpoilerConfig.MaxConcurrentContactSheets
+	}
 	if c.MaxConcurrentUploads < 1 {
 		c.MaxConcurrentUploads = DefaultSpoilerConfig.MaxConcurrentUploads
 	}
+	for host, limit := range c.HostUploadLimits {
+		if limit < 1 {
+			delete(c.HostUploadLimits, host)
+		}
+	}
 	if c.ScreenshotQuality < 1 || c.ScreenshotQuality > 31 {
 		c.ScreenshotQuality = DefaultSpoilerConfig.ScreenshotQuality
 	}
 	if c.ImageMiniatureSize < 100 || c.ImageMiniatureSize > 800 {
 		c.ImageMiniatureSize = DefaultSpoilerConfig.ImageMiniatureSize
 	}
+	if c.FastpicDeleteAfterDays < 0 {
+		c.FastpicDeleteAfterDays = DefaultSpoilerConfig.FastpicDeleteAfterDays
+	}
+	if c.MaxTempUsageMB < 0 {
+		c.MaxTempUsageMB = DefaultSpoilerConfig.MaxTempUsageMB
+	}
+	if c.ScreenshotJitterSeconds < 0 || c.ScreenshotJitterSeconds > 60 {
+		c.ScreenshotJitterSeconds = DefaultSpoilerConfig.ScreenshotJitterSeconds
+	}
+	if c.FastpicOrigResize != 0 && (c.FastpicOrigResize < 100 || c.FastpicOrigResize > 10000) {
+		c.FastpicOrigResize = DefaultSpoilerConfig.FastpicOrigResize
+	}
+	if !isValidBaseURL(c.FastpicBaseURL) {
+		c.FastpicBaseURL = DefaultSpoilerConfig.FastpicBaseURL
+	}
+	if c.HamsterResizeWidth != 0 && (c.HamsterResizeWidth < 100 || c.HamsterResizeWidth > 10000) {
+		c.HamsterResizeWidth = DefaultSpoilerConfig.HamsterResizeWidth
+	}
+	if !img_uploaders.IsValidHamsterExpiration(c.HamsterExpiration) {
+		c.HamsterExpiration = DefaultSpoilerConfig.HamsterExpiration
+	}
+	if !img_uploaders.IsValidLitterboxExpiry(c.LitterboxExpiry) {
+		c.LitterboxExpiry = DefaultSpoilerConfig.LitterboxExpiry
+	}
+	if !isValidHostMiniatureSize(c.FastpicMiniatureSize) {
+		c.FastpicMiniatureSize = DefaultSpoilerConfig.FastpicMiniatureSize
+	}
+	if !isValidHostMiniatureSize(c.ImgboxMiniatureSize) {
+		c.ImgboxMiniatureSize = DefaultSpoilerConfig.ImgboxMiniatureSize
+	}
 	if c.MtnArgs == "" {
 		c.MtnArgs = DefaultSpoilerConfig.MtnArgs
 	}
+	if c.GroupHeaderTemplate == "" {
+		c.GroupHeaderTemplate = DefaultSpoilerConfig.GroupHeaderTemplate
+	}
+	if c.OutputLineEnding != LineEndingLF && c.OutputLineEnding != LineEndingCRLF {
+		c.OutputLineEnding = DefaultSpoilerConfig.OutputLineEnding
+	}
+	if c.SpoilerTitleMaxLength < 0 {
+		c.SpoilerTitleMaxLength = DefaultSpoilerConfig.SpoilerTitleMaxLength
+	}
+	if !isValidProcessingOrder(c.ProcessingOrder) {
+		c.ProcessingOrder = DefaultSpoilerConfig.ProcessingOrder
+	}
+	if !img_uploaders.IsValidBBCodeDialect(c.BBCodeDialect) {
+		c.BBCodeDialect = DefaultSpoilerConfig.BBCodeDialect
+	}
+	if validateChecklistItems(c.ChecklistItems) != nil {
+		c.ChecklistItems = DefaultSpoilerConfig.ChecklistItems
+	}
+	if !isValidWatermarkPosition(c.WatermarkPosition) {
+		c.WatermarkPosition = DefaultSpoilerConfig.WatermarkPosition
+	}
+	if c.WatermarkOpacity < 0 || c.WatermarkOpacity > 100 {
+		c.WatermarkOpacity = DefaultSpoilerConfig.WatermarkOpacity
+	}
+	if !isValidScreenshotFormat(c.ScreenshotFormat) {
+		c.ScreenshotFormat = DefaultSpoilerConfig.ScreenshotFormat
+	}
+	if c.WebPQuality < 0 || c.WebPQuality > 100 {
+		c.WebPQuality = DefaultSpoilerConfig.WebPQuality
+	}
+	if !isValidScreenshotMode(c.ScreenshotMode) {
+		c.ScreenshotMode = DefaultSpoilerConfig.ScreenshotMode
+	}
+	if !isValidScreenshotOffset(c.ScreenshotStartOffset) {
+		c.ScreenshotStartOffset = DefaultSpoilerConfig.ScreenshotStartOffset
+	}
+	if !isValidScreenshotOffset(c.ScreenshotEndOffset) {
+		c.ScreenshotEndOffset = DefaultSpoilerConfig.ScreenshotEndOffset
+	}
+	if c.Window.Theme == "" {
+		c.Window.Theme = DefaultSpoilerConfig.Window.Theme
+	}
+	if c.CollectionSpoilerTemplate == "" {
+		c.CollectionSpoilerTemplate = DefaultSpoilerConfig.CollectionSpoilerTemplate
+	}
+	if c.LocalOutputDir != "" && !filepath.IsAbs(c.LocalOutputDir) {
+		c.LocalOutputDir = DefaultSpoilerConfig.LocalOutputDir
+	}
+	if !isValidMetadataLanguage(c.MetadataLanguage) {
+		c.MetadataLanguage = DefaultSpoilerConfig.MetadataLanguage
+	}
+	if c.DegradeAfterFailures < 1 || c.DegradeAfterFailures > 100 {
+		c.DegradeAfterFailures = DefaultSpoilerConfig.DegradeAfterFailures
+	}
+	if c.DegradedScreenshotCount < 0 || c.DegradedScreenshotCount > 20 {
+		c.DegradedScreenshotCount = DefaultSpoilerConfig.DegradedScreenshotCount
+	}
+	if c.DegradedQuality < 1 || c.DegradedQuality > 31 {
+		c.DegradedQuality = DefaultSpoilerConfig.DegradedQuality
+	}
 
 	// Ensure we have presets and current preset ID
 	if len(c.TemplatePresets) == 0 {
@@ -327,6 +720,16 @@ func loadSpoilerAppConfig() SpoilerConfig {
 		c.CurrentPresetID = c.TemplatePresets[0].ID
 	}
 
+	// Invalid generation overrides fall back to the global settings
+	for i := range c.TemplatePresets {
+		if err := validatePresetGeneration(c.TemplatePresets[i]); err != nil {
+			log.Printf("Ignoring generation overrides of preset %q: %v", c.TemplatePresets[i].Name, err)
+			c.TemplatePresets[i].MtnArgs = nil
+			c.TemplatePresets[i].ScreenshotCount = nil
+			c.TemplatePresets[i].SheetWidth = nil
+		}
+	}
+
 	// Validate current preset ID exists
 	found := false
 	for _, preset := range c.TemplatePresets {
@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// configReloadDelay waits for editors that write the config in several steps
+const configReloadDelay = 300 * time.Millisecond
+
+var (
+	savedConfigMu   sync.Mutex
+	savedConfigData []byte // Last content written by the app, changes to it are not reloaded
+)
+
+// rememberSavedConfig records the content the app itself wrote to the config file
+func rememberSavedConfig(data []byte) {
+	savedConfigMu.Lock()
+	defer savedConfigMu.Unlock()
+	savedConfigData = data
+}
+
+func isSavedConfig(data []byte) bool {
+	savedConfigMu.Lock()
+	defer savedConfigMu.Unlock()
+	return bytes.Equal(data, savedConfigData)
+}
+
+// Watch calls onChange with the reloaded config whenever the config file is edited outside
+// the app. The directory is watched as editors often replace the file instead of writing it.
+// The returned function stops watching.
+func (g *ConfigService) Watch(onChange func(SpoilerConfig)) (func(), error) {
+	initSpoilerConfigPath()
+	configPath := ConfigPath
+	if data, err := os.ReadFile(configPath); err == nil {
+		rememberSavedConfig(data)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config watcher: %v", err)
+	}
+	if err := watcher.Add(filepath.Dir(configPath)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch config directory: %v", err)
+	}
+
+	var timerMu sync.Mutex
+	var timer *time.Timer
+	reload := func() {
+		data, err := os.ReadFile(configPath)
+		if err != nil || len(data) == 0 || isSavedConfig(data) {
+			return
+		}
+		rememberSavedConfig(data)
+		log.Println("Config file changed, reloading")
+		onChange(g.GetConfig())
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(configPath) || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+					continue
+				}
+				timerMu.Lock()
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(configReloadDelay, reload)
+				timerMu.Unlock()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Config watcher error: %v", err)
+			}
+		}
+	}()
+
+	return func() {
+		watcher.Close()
+		timerMu.Lock()
+		if timer != nil {
+			timer.Stop()
+		}
+		timerMu.Unlock()
+	}, nil
+}
+
+// ServiceStartup starts watching the config file, so external edits apply without a restart
+func (s *SpoilerService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
+	stop, err := s.configManager.Watch(s.applyExternalConfig)
+	if err != nil {
+		log.Printf("Config hot-reload disabled: %v", err)
+		return nil
+	}
+	s.stopConfigWatch = stop
+	return nil
+}
+
+// applyExternalConfig switches to a config edited outside the app and tells the frontend.
+// During processing the settings are staged like those saved from the app.
+func (s *SpoilerService) applyExternalConfig(config SpoilerConfig) {
+	settings := settingsFromConfig(config)
+
+	s.settingsMu.Lock()
+	if s.processing {
+		s.pendingSettings = &settings
+	} else {
+		s.applySettings(settings)
+	}
+	s.settingsMu.Unlock()
+
+	if s.app != nil {
+		s.app.Event.Emit("config-updated", map[string]any{
+			"settings":        settings,
+			"templatePresets": config.TemplatePresets,
+			"currentPresetId": config.CurrentPresetID,
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package backend
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Layout of the built-in contact sheet
+const (
+	contactSheetColumns    = 4
+	contactSheetRows       = 4
+	contactSheetThumbWidth = 400
+	contactSheetGap        = 6
+	contactSheetHeaderText = 2 // Font scale of the header lines
+	contactSheetStampText  = 2 // Font scale of the frame timestamps
+)
+
+var (
+	contactSheetBackground = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	contactSheetForeground = color.RGBA{A: 0xFF}
+	contactSheetStampBox   = color.RGBA{A: 0xB0}
+)
+
+// generateNativeContactSheet builds a contact sheet without mtn: frames are extracted with
+// ffmpeg and composed into a grid below a header with the file info
+func (s *SpoilerService) generateNativeContactSheet(movie Movie, tempDir string) (string, error) {
+	if movie.DurationSeconds <= 0 {
+		return "", fmt.Errorf("video duration is unknown")
+	}
+
+	frameDir := filepath.Join(tempDir, "contact_sheet_frames")
+	if err := os.MkdirAll(frameDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create frame directory: %v", err)
+	}
+	defer os.RemoveAll(frameDir)
+
+	count := contactSheetColumns * contactSheetRows
+	interval := movie.DurationSeconds / float64(count+1)
+
+	frames := make([]image.Image, count)
+	timestamps := make([]float64, count)
+	extracted := 0
+	for i := range frames {
+		if s.cancelCtx.Err() != nil {
+			return "", fmt.Errorf("contact sheet generation cancelled: %v", s.cancelCtx.Err())
+		}
+
+		timestamps[i] = interval * float64(i+1)
+		frame, err := s.extractContactSheetFrame(movie.mediaInput(), filepath.Join(frameDir, fmt.Sprintf("frame_%d.jpg", i+1)), timestamps[i])
+		if err != nil {
+			continue // A missing frame leaves an empty cell
+		}
+		frames[i] = frame
+		extracted++
+	}
+	if extracted == 0 {
+		return "", fmt.Errorf("no frames could be extracted")
+	}
+
+	thumbHeight := 0
+	for _, frame := range frames {
+		if frame != nil {
+			thumbHeight = max(thumbHeight, frame.Bounds().Dy())
+		}
+	}
+
+	header := contactSheetHeader(movie)
+	lineHeight := (glyphHeight + 3) * contactSheetHeaderText
+	headerHeight := contactSheetGap*2 + len(header)*lineHeight
+
+	width := contactSheetColumns*contactSheetThumbWidth + (contactSheetColumns+1)*contactSheetGap
+	height := headerHeight + contactSheetRows*thumbHeight + contactSheetRows*contactSheetGap
+	sheet := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(sheet, sheet.Bounds(), &image.Uniform{C: contactSheetBackground}, image.Point{}, draw.Src)
+
+	maxChars := (width - 2*contactSheetGap) / ((glyphWidth + 1) * contactSheetHeaderText)
+	for i, line := range header {
+		line = strings.ReplaceAll(TruncateGraphemes(line, maxChars-2), "…", "...")
+		drawText(sheet, contactSheetGap, contactSheetGap+i*lineHeight, line, contactSheetHeaderText, contactSheetForeground)
+	}
+
+	for i, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		x := contactSheetGap + (i%contactSheetColumns)*(contactSheetThumbWidth+contactSheetGap)
+		y := headerHeight + (i/contactSheetColumns)*(thumbHeight+contactSheetGap)
+		cell := image.Rect(x, y, x+frame.Bounds().Dx(), y+frame.Bounds().Dy())
+		draw.Draw(sheet, cell, frame, frame.Bounds().Min, draw.Src)
+		drawTimestamp(sheet, cell, timestamps[i])
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(movie.FilePath), filepath.Ext(movie.FilePath))
+	outputPath := filepath.Join(tempDir, baseName+"_s.jpg")
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create contact sheet: %v", err)
+	}
+	defer file.Close()
+
+	if err := jpeg.Encode(file, sheet, &jpeg.Options{Quality: 90}); err != nil {
+		return "", fmt.Errorf("failed to encode contact sheet: %v", err)
+	}
+	return outputPath, nil
+}
+
+// extractContactSheetFrame grabs a single frame scaled to the contact sheet thumbnail width
+func (s *SpoilerService) extractContactSheetFrame(videoPath, outputPath string, timestamp float64) (image.Image, error) {
+	cmd := exec.CommandContext(s.cancelCtx, toolPath("ffmpeg"),
+		"-ss", fmt.Sprintf("%.2f", timestamp),
+		"-i", videoPath,
+		"-vframes", "1",
+		"-vf", fmt.Sprintf("scale=%d:-2", contactSheetThumbWidth),
+		"-q:v", "3",
+		"-y",
+		outputPath,
+	)
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg command failed: %v", err)
+	}
+
+	file, err := os.Open(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return jpeg.Decode(file)
+}
+
+// contactSheetHeader returns the file info lines printed above the grid
+func contactSheetHeader(movie Movie) []string {
+	lines := []string{
+		"File: " + movie.FileName,
+		fmt.Sprintf("Size: %s   Duration: %s", movie.FileSize, movie.DurationFormatted),
+	}
+
+	var video []string
+	if movie.VideoCodec != "" {
+		video = append(video, movie.VideoCodec)
+	}
+	if movie.Width != "" && movie.Height != "" {
+		video = append(video, movie.Width+"x"+movie.Height)
+	}
+	if fps := movie.Params["%VIDEO_FPS%"]; fps != "" {
+		video = append(video, fps+" fps")
+	}
+	if movie.VideoBitRate != "" {
+		video = append(video, movie.VideoBitRate)
+	}
+	if len(video) > 0 {
+		lines = append(lines, "Video: "+strings.Join(video, " / "))
+	}
+
+	var audio []string
+	if movie.AudioCodec != "" {
+		audio = append(audio, movie.AudioCodec)
+	}
+	if channels := movie.Params["%AUDIO_CHANNELS%"]; channels != "" {
+		audio = append(audio, channels)
+	}
+	if movie.AudioBitRate != "" {
+		audio = append(audio, movie.AudioBitRate)
+	}
+	if len(audio) > 0 {
+		lines = append(lines, "Audio: "+strings.Join(audio, " / "))
+	}
+	return lines
+}
+
+// drawTimestamp prints the frame position in the bottom-right corner of a cell
+func drawTimestamp(sheet *image.RGBA, cell image.Rectangle, timestamp float64) {
+	text := FormatDuration(time.Duration(timestamp * float64(time.Second)))
+	padding := contactSheetStampText * 2
+	boxWidth := textWidth(text, contactSheetStampText) + padding
+	boxHeight := glyphHeight*contactSheetStampText + padding*2
+
+	box := image.Rect(cell.Max.X-boxWidth, cell.Max.Y-boxHeight, cell.Max.X, cell.Max.Y)
+	draw.Draw(sheet, box, &image.Uniform{C: contactSheetStampBox}, image.Point{}, draw.Over)
+	drawText(sheet, box.Min.X+padding, box.Min.Y+padding, text, contactSheetStampText, color.White)
+}
@@ -0,0 +1,143 @@
+package backend
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const redactedValue = "<redacted>"
+
+// CreateDebugBundle zips recent logs, the sanitized config, tool versions and the timelines of
+// failed movies into a single file and returns its path
+func (s *SpoilerService) CreateDebugBundle() (string, error) {
+	bundleDir := filepath.Join(getConfigDir(), "debug")
+	if err := os.MkdirAll(bundleDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create debug directory: %v", err)
+	}
+
+	bundlePath := filepath.Join(bundleDir, fmt.Sprintf("spoilr-debug-%s.zip", time.Now().Format("20060102-150405")))
+	file, err := os.Create(bundlePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create debug bundle: %v", err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+
+	current := s.configManager.GetConfig()
+	config, err := json.MarshalIndent(sanitizeConfig(current), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize config: %v", err)
+	}
+
+	timelines, err := json.MarshalIndent(s.failedMovieTimelines(), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize timelines: %v", err)
+	}
+
+	appInfo, err := json.MarshalIndent(s.GetAppInfo(), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize app info: %v", err)
+	}
+
+	entries := map[string][]byte{
+		"logs.txt":       []byte(scrubSecrets(recentLogs.String(), current)),
+		"config.json":    config,
+		"app_info.json":  appInfo,
+		"timelines.json": timelines,
+	}
+
+	for name, data := range entries {
+		w, err := archive.Create(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to add %s to bundle: %v", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return "", fmt.Errorf("failed to write %s to bundle: %v", name, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize debug bundle: %v", err)
+	}
+
+	log.Printf("Debug bundle created: %s", bundlePath)
+	return bundlePath, nil
+}
+
+// movieTimeline is the timeline of a movie in a debug bundle
+type movieTimeline struct {
+	FileName string          `json:"fileName"`
+	Events   []TimelineEvent `json:"events"`
+}
+
+// failedMovieTimelines returns the timelines of movies that failed or finished with errors or
+// warnings by movie ID, as files in different folders can share a name
+func (s *SpoilerService) failedMovieTimelines() map[string]movieTimeline {
+	var failed []Movie
+	s.moviesMu.Lock()
+	for _, movie := range s.movies {
+		if movie.ProcessingState == StateError || len(movie.Errors) > 0 || len(movie.Warnings) > 0 {
+			failed = append(failed, movie)
+		}
+	}
+	s.moviesMu.Unlock()
+
+	timelines := make(map[string]movieTimeline, len(failed))
+	for _, movie := range failed {
+		timelines[movie.ID] = movieTimeline{FileName: movie.FileName, Events: s.timelines.get(movie.ID)}
+	}
+	return timelines
+}
+
+// sanitizeConfig strips credentials from a config copy
+func sanitizeConfig(config SpoilerConfig) SpoilerConfig {
+	redact := func(value string) string {
+		if value == "" {
+			return ""
+		}
+		return redactedValue
+	}
+
+	for _, field := range secretFields(&config) {
+		*field = redact(*field)
+	}
+	return config
+}
+
+// scrubSecrets replaces every configured credential in text, such as the log, with a placeholder
+func scrubSecrets(text string, config SpoilerConfig) string {
+	for _, field := range secretFields(&config) {
+		value := *field
+		if len(value) < 4 {
+			continue // Too short to tell apart from ordinary text
+		}
+		text = strings.ReplaceAll(text, value, redactedValue)
+		text = strings.ReplaceAll(text, url.QueryEscape(value), redactedValue)
+	}
+	return text
+}
+
+// toolVersion returns the first line of a tool's version output, or a note when it is missing
+func toolVersion(name string, args ...string) string {
+	path, err := findTool(name)
+	if err != nil {
+		return "not found"
+	}
+
+	output, err := exec.Command(path, args...).CombinedOutput()
+	if err != nil && len(output) == 0 {
+		return fmt.Sprintf("found at %s (version unknown: %v)", path, err)
+	}
+
+	firstLine := strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+	return firstLine
+}
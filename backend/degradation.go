@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+)
+
+// degradedWebPQuality caps the WebP quality once a run is degraded
+const degradedWebPQuality = 75
+
+// uploadRejectionPattern matches upload errors of hosts refusing an image for its size or
+// the account's quota, as opposed to network failures
+var uploadRejectionPattern = regexp.MustCompile(`(?i)\b(413|429|507)\b|too large|too big|file ?size|size limit|quota|limit exceeded|insufficient storage|too many`)
+
+// screenshotLimits are the screenshot count and qualities a movie is generated with
+type screenshotLimits struct {
+	count       int
+	quality     int
+	webPQuality int
+}
+
+// degradePolicy counts the size and quota rejections of the hosts in a run
+type degradePolicy struct {
+	mu         sync.Mutex
+	rejections map[string]int              // Rejections in a row per host
+	trigger    string                      // Host whose rejections degraded the run, empty until then
+	limits     screenshotLimits            // Limits of movies starting after the run degraded
+	movies     map[string]screenshotLimits // Limits captured by the movies that started degraded
+}
+
+func newDegradePolicy() *degradePolicy {
+	return &degradePolicy{rejections: make(map[string]int), movies: make(map[string]screenshotLimits)}
+}
+
+// reset starts a new run
+func (d *degradePolicy) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rejections = make(map[string]int)
+	d.movies = make(map[string]screenshotLimits)
+	d.trigger = ""
+}
+
+// record counts an upload result of a host. It returns true once, when the host's rejections
+// in a row reach the threshold, and from then on movies starting capture the degraded limits.
+// Successful uploads reset the count, other failures keep it.
+func (d *degradePolicy) record(host string, err error, threshold int, degraded screenshotLimits) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err == nil {
+		d.rejections[host] = 0
+		return false
+	}
+	if !uploadRejectionPattern.MatchString(err.Error()) {
+		return false
+	}
+	d.rejections[host]++
+	if d.trigger != "" || d.rejections[host] < threshold {
+		return false
+	}
+	d.trigger = host
+	d.limits = degraded
+	return true
+}
+
+// capture makes a movie starting now keep the degraded limits for the rest of its processing.
+// It returns the host that degraded the run, empty while it is not degraded.
+func (d *degradePolicy) capture(movieID string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.trigger != "" {
+		d.movies[movieID] = d.limits
+	}
+	return d.trigger
+}
+
+// limitsFor returns the limits a movie captured when it started degraded
+func (d *degradePolicy) limitsFor(movieID string) (screenshotLimits, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	limits, ok := d.movies[movieID]
+	return limits, ok
+}
+
+// recordUploadResult feeds an upload result to the degradation policy
+func (s *SpoilerService) recordUploadResult(host string, err error) {
+	if !s.settings.DegradeOnUploadFailures {
+		return
+	}
+	configured := s.configuredScreenshotLimits()
+	degraded := screenshotLimits{
+		count:       min(configured.count, s.settings.DegradedScreenshotCount),
+		quality:     max(configured.quality, s.settings.DegradedQuality),
+		webPQuality: min(configured.webPQuality, degradedWebPQuality),
+	}
+	if s.degradation.record(host, err, s.settings.DegradeAfterFailures, degraded) {
+		s.degradeRun(host, degraded.count)
+	}
+}
+
+// degradeRun announces that the movies starting from now on get fewer screenshots at a lower
+// quality, so a host that keeps rejecting uploads still gets usable output. The settings are
+// left alone, movies already in progress finish with the limits they started with.
+func (s *SpoilerService) degradeRun(host string, count int) {
+	message := fmt.Sprintf("%s keeps rejecting uploads, the remaining movies get %d screenshots at a lower quality", hostLabel(host), count)
+	log.Print(message)
+	if s.app != nil {
+		s.app.Event.Emit("processing-degraded", map[string]string{
+			"host":    host,
+			"message": message,
+		})
+	}
+}
+
+// configuredScreenshotLimits returns the screenshot count and qualities of the settings
+func (s *SpoilerService) configuredScreenshotLimits() screenshotLimits {
+	return screenshotLimits{
+		count:       s.settings.ScreenshotCount,
+		quality:     s.settings.ScreenshotQuality,
+		webPQuality: s.settings.WebPQuality,
+	}
+}
+
+// movieScreenshotLimits returns the screenshot count and qualities a movie is generated with:
+// the degraded ones when it started in a degraded run, the configured ones otherwise
+func (s *SpoilerService) movieScreenshotLimits(movieID string) screenshotLimits {
+	if limits, ok := s.degradation.limitsFor(movieID); ok {
+		return limits
+	}
+	return s.configuredScreenshotLimits()
+}
+
+// noteDegradation captures the degraded limits for a movie starting in a degraded run and
+// warns it that it gets less than configured
+func (s *SpoilerService) noteDegradation(movieID string) {
+	if host := s.degradation.capture(movieID); host != "" {
+		s.addMovieWarning(movieID, fmt.Sprintf("Generated with fewer screenshots at a lower quality after %s rejected uploads", hostLabel(host)))
+	}
+}
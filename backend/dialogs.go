@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"log"
+	"os"
+	"sync"
+
+	"github.com/sqweek/dialog"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// nonInteractiveEnv disables native dialogs for automation, e.g. "SPOILR_NONINTERACTIVE=1"
+const nonInteractiveEnv = "SPOILR_NONINTERACTIVE"
+
+// DialogService shows native message dialogs one at a time. Until the app window is attached,
+// e.g. during the startup checks, they are standalone dialogs; afterwards they are attached to
+// the window so they stay in front of it instead of opening behind it or stealing focus from
+// other apps. In non-interactive mode nothing is shown: messages are only logged and questions
+// get their default answer.
+type DialogService struct {
+	mu          sync.Mutex // Queues dialogs, only one is open at a time
+	stateMu     sync.Mutex // Guards the fields below, never held while a dialog is open
+	app         *application.App
+	window      application.Window
+	interactive bool
+}
+
+// Dialogs is the dialog service shared by the startup checks and the app
+var Dialogs = NewDialogService()
+
+func NewDialogService() *DialogService {
+	return &DialogService{interactive: os.Getenv(nonInteractiveEnv) == ""}
+}
+
+// AttachWindow shows the following dialogs through the app, in front of its window
+func (d *DialogService) AttachWindow(app *application.App, window application.Window) {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	d.app, d.window = app, window
+}
+
+// SetInteractive turns dialogs off for headless runs
+func (d *DialogService) SetInteractive(interactive bool) {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	d.interactive = interactive
+}
+
+// Interactive reports whether dialogs are shown
+func (d *DialogService) Interactive() bool {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	return d.interactive
+}
+
+func (d *DialogService) target() (*application.App, application.Window, bool) {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	return d.app, d.window, d.interactive
+}
+
+// Info shows a message
+func (d *DialogService) Info(title, message string) {
+	d.show(title, message, false)
+}
+
+// Error shows an error message
+func (d *DialogService) Error(title, message string) {
+	d.show(title, message, true)
+}
+
+func (d *DialogService) show(title, message string, isError bool) {
+	app, window, interactive := d.target()
+	if !interactive {
+		log.Printf("%s: %s", title, message)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if app == nil {
+		if isError {
+			dialog.Message("%s", message).Title(title).Error()
+		} else {
+			dialog.Message("%s", message).Title(title).Info()
+		}
+		return
+	}
+
+	messageDialog := app.Dialog.Info()
+	if isError {
+		messageDialog = app.Dialog.Error()
+	}
+	messageDialog.SetTitle(title).SetMessage(message)
+	if window != nil {
+		messageDialog.AttachToWindow(window)
+	}
+	messageDialog.Show()
+}
+
+// YesNo asks a question. Non-interactive runs get fallback without asking.
+func (d *DialogService) YesNo(title, message string, fallback bool) bool {
+	app, window, interactive := d.target()
+	if !interactive {
+		log.Printf("%s: %s (answered %t, non-interactive)", title, message, fallback)
+		return fallback
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if app == nil {
+		return dialog.Message("%s", message).Title(title).YesNo()
+	}
+
+	answer := make(chan bool, 1)
+	question := app.Dialog.Question().SetTitle(title).SetMessage(message)
+	question.AddButton("Yes").SetAsDefault().OnClick(func() { answer <- true })
+	question.AddButton("No").SetAsCancel().OnClick(func() { answer <- false })
+	if window != nil {
+		question.AttachToWindow(window)
+	}
+	question.Show()
+	return <-answer
+}
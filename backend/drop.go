@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"fmt"
+	"log"
+)
+
+// DropContext describes the list area the user is dragging files over
+type DropContext struct {
+	GroupID string `json:"groupId"` // Group to append dropped files to, empty for the flat list
+}
+
+// SetDropContext is called by the frontend while files are dragged over a list or group,
+// so the next drop lands in the right place
+func (s *SpoilerService) SetDropContext(ctx DropContext) error {
+	if ctx.GroupID != "" {
+		if _, exists := s.getGroupByID(ctx.GroupID); !exists {
+			return fmt.Errorf("group not found")
+		}
+	}
+	s.dropMu.Lock()
+	s.dropContext = ctx
+	s.dropMu.Unlock()
+	return nil
+}
+
+// HandleDroppedFiles adds dropped files using the current drop context, then resets the context
+func (s *SpoilerService) HandleDroppedFiles(paths []string) error {
+	s.dropMu.Lock()
+	ctx := s.dropContext
+	s.dropContext = DropContext{}
+	s.dropMu.Unlock()
+
+	movieIDs, _, err := s.addMovies(paths)
+	if err != nil {
+		return err
+	}
+
+	if ctx.GroupID == "" || len(movieIDs) == 0 {
+		return nil
+	}
+
+	if err := s.AssignMoviesToGroup(ctx.GroupID, movieIDs); err != nil {
+		log.Printf("Failed to add dropped files to group: %v", err)
+		return err
+	}
+	return nil
+}
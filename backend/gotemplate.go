@@ -0,0 +1,172 @@
+package backend
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Template modes of a preset
+const (
+	TemplateModePlaceholders = "placeholders" // %PLACEHOLDER% replacement, the default
+	TemplateModeGo           = "gotemplate"   // Go text/template with the Movie as data
+)
+
+// usesGoTemplate reports whether a preset renders with text/template
+func (p TemplatePreset) usesGoTemplate() bool {
+	return p.TemplateMode == TemplateModeGo
+}
+
+// goTemplateFuncs are sprig-style helpers. Like in sprig, the piped value is the last
+// argument, so {{.FileName | trunc 40}} and {{.Params.X | default "n/a"}} work.
+var goTemplateFuncs = template.FuncMap{
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      titleCase,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, replacement, s string) string { return strings.ReplaceAll(s, old, replacement) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"repeat":     func(count int, s string) string { return strings.Repeat(s, max(count, 0)) },
+	"trunc":      truncateRunes,
+	"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	"join":       func(sep string, list []string) string { return strings.Join(list, sep) },
+	"compact":    compactStrings,
+	"first":      func(list []string) string { return listItem(list, 0) },
+	"last":       func(list []string) string { return listItem(list, len(list)-1) },
+	"default":    defaultValue,
+	"empty":      func(value any) bool { return !truthy(value) },
+	"coalesce":   coalesce,
+	"ternary":    ternary,
+	"indent":     indentLines,
+	"nindent":    func(spaces int, s string) string { return "\n" + indentLines(spaces, s) },
+	"add":        func(a, b int) int { return a + b },
+	"add1":       func(a int) int { return a + 1 },
+	"sub":        func(a, b int) int { return a - b },
+	"mul":        func(a, b int) int { return a * b },
+	"until":      until,
+}
+
+// renderGoTemplate renders a text/template with the movie as data. Besides the movie fields
+// it provides "param" for analyzed fields by name, with or without the surrounding percent
+// signs, and "host" for the upload results of an image host by name or placeholder suffix.
+func (s *SpoilerService) renderGoTemplate(text string, movie Movie) string {
+	funcs := template.FuncMap{
+		"param": func(name string) string {
+			return movie.Params["%"+strings.Trim(name, "%")+"%"]
+		},
+		"host": func(name string) HostUploads {
+			for _, uploader := range s.uploaders {
+				if strings.EqualFold(uploader.Name(), name) || strings.EqualFold(uploader.PlaceholderSuffix(), name) {
+					return s.hostUploads(movie, uploader.Name())
+				}
+			}
+			return HostUploads{}
+		},
+	}
+
+	tmpl, err := template.New("spoiler").Funcs(goTemplateFuncs).Funcs(funcs).Parse(text)
+	if err != nil {
+		log.Printf("Failed to parse template: %v", err)
+		return fmt.Sprintf("Template error: %v", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, movie); err != nil {
+		log.Printf("Failed to render template for %s: %v", movie.FileName, err)
+		return fmt.Sprintf("Template error: %v", err)
+	}
+	return out.String()
+}
+
+// validateGoTemplate parses a template so syntax errors are reported when it is saved
+func validateGoTemplate(text string) error {
+	_, err := template.New("spoiler").Funcs(goTemplateFuncs).Funcs(template.FuncMap{
+		"param": func(string) string { return "" },
+		"host":  func(string) HostUploads { return HostUploads{} },
+	}).Parse(text)
+	return err
+}
+
+func titleCase(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if i == 0 || unicode.IsSpace(runes[i-1]) {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(runes)
+}
+
+func truncateRunes(length int, s string) string {
+	runes := []rune(s)
+	if length < 0 || len(runes) <= length {
+		return s
+	}
+	return string(runes[:length])
+}
+
+// compactStrings drops empty entries, e.g. screenshots that failed to upload
+func compactStrings(list []string) []string {
+	var compacted []string
+	for _, item := range list {
+		if item != "" {
+			compacted = append(compacted, item)
+		}
+	}
+	return compacted
+}
+
+func listItem(list []string, index int) string {
+	if index < 0 || index >= len(list) {
+		return ""
+	}
+	return list[index]
+}
+
+func defaultValue(fallback, value any) any {
+	if truthy(value) {
+		return value
+	}
+	return fallback
+}
+
+func ternary(yes, no any, cond bool) any {
+	if cond {
+		return yes
+	}
+	return no
+}
+
+func coalesce(values ...any) any {
+	for _, value := range values {
+		if truthy(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+// truthy follows the template package's notion of an empty value
+func truthy(value any) bool {
+	ok, _ := template.IsTrue(value)
+	return ok
+}
+
+func indentLines(spaces int, s string) string {
+	pad := strings.Repeat(" ", max(spaces, 0))
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func until(count int) []int {
+	seq := make([]int, max(count, 0))
+	for i := range seq {
+		seq[i] = i
+	}
+	return seq
+}
@@ -0,0 +1,307 @@
+package backend
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MovieGroup is a user-defined section of the movie list rendered with its own heading
+type MovieGroup struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"` // Shared description, available as %GROUP_DESCRIPTION%
+	Params      map[string]string `json:"params"`      // Shared params inherited by every movie in the group
+}
+
+// GroupMove assigns the movies dragged in a reorder to the group they were dropped into
+type GroupMove struct {
+	MovieIDs []string `json:"movieIds"`
+	GroupID  string   `json:"groupId"` // Empty for the ungrouped list
+}
+
+// GetGroups returns the manual movie groups
+func (s *SpoilerService) GetGroups() []MovieGroup {
+	return s.groups
+}
+
+// CreateGroup creates a named group and assigns the given movies to it
+func (s *SpoilerService) CreateGroup(name string, movieIDs []string) (MovieGroup, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return MovieGroup{}, fmt.Errorf("group name cannot be empty")
+	}
+
+	group := MovieGroup{
+		ID:     uuid.New().String(),
+		Name:   name,
+		Params: make(map[string]string),
+	}
+	s.groups = append(s.groups, group)
+
+	if err := s.AssignMoviesToGroup(group.ID, movieIDs); err != nil {
+		return MovieGroup{}, err
+	}
+	return group, nil
+}
+
+// RenameGroup changes the heading of a group
+func (s *SpoilerService) RenameGroup(groupID, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	for i := range s.groups {
+		if s.groups[i].ID == groupID {
+			s.groups[i].Name = name
+			s.emitState()
+			return nil
+		}
+	}
+	return fmt.Errorf("group not found")
+}
+
+// SetGroupDescription sets the shared description of a group
+func (s *SpoilerService) SetGroupDescription(groupID, description string) error {
+	for i := range s.groups {
+		if s.groups[i].ID == groupID {
+			s.groups[i].Description = description
+			s.emitState()
+			return nil
+		}
+	}
+	return fmt.Errorf("group not found")
+}
+
+// SetGroupParams replaces the shared params of a group. Keys may be given with or without
+// surrounding percent signs.
+func (s *SpoilerService) SetGroupParams(groupID string, params map[string]string) error {
+	for i := range s.groups {
+		if s.groups[i].ID == groupID {
+			normalized := make(map[string]string, len(params))
+			for key, value := range params {
+				key = strings.Trim(strings.TrimSpace(key), "%")
+				if key == "" {
+					continue
+				}
+				normalized["%"+key+"%"] = value
+			}
+			s.groups[i].Params = normalized
+			s.emitState()
+			return nil
+		}
+	}
+	return fmt.Errorf("group not found")
+}
+
+// DeleteGroup removes a group, leaving its movies ungrouped
+func (s *SpoilerService) DeleteGroup(groupID string) error {
+	for i, group := range s.groups {
+		if group.ID == groupID {
+			s.groups = append(s.groups[:i], s.groups[i+1:]...)
+			for j := range s.movies {
+				if s.movies[j].GroupID == groupID {
+					s.movies[j].GroupID = ""
+				}
+			}
+			s.emitState()
+			return nil
+		}
+	}
+	return fmt.Errorf("group not found")
+}
+
+// AssignMoviesToGroup moves movies into a group and keeps the group contiguous in the list.
+// An empty groupID removes the movies from their groups.
+func (s *SpoilerService) AssignMoviesToGroup(groupID string, movieIDs []string) error {
+	if groupID != "" {
+		if _, exists := s.getGroupByID(groupID); !exists {
+			return fmt.Errorf("group not found")
+		}
+	}
+
+	for _, id := range movieIDs {
+		if !s.updateMovieByID(id, func(m *Movie) { m.GroupID = groupID }) {
+			return fmt.Errorf("movie with ID %s not found", id)
+		}
+	}
+
+	if groupID != "" {
+		s.compactGroup(groupID)
+	}
+	s.emitState()
+	return nil
+}
+
+func (s *SpoilerService) getGroupByID(id string) (MovieGroup, bool) {
+	for _, group := range s.groups {
+		if group.ID == id {
+			return group, true
+		}
+	}
+	return MovieGroup{}, false
+}
+
+// compactGroup gathers all movies of a group right after its first member
+func (s *SpoilerService) compactGroup(groupID string) {
+	first := -1
+	var members, rest []Movie
+	for i, movie := range s.movies {
+		if movie.GroupID == groupID {
+			if first < 0 {
+				first = i
+			}
+			members = append(members, movie)
+		} else {
+			rest = append(rest, movie)
+		}
+	}
+	if first < 0 {
+		return
+	}
+
+	compacted := make([]Movie, 0, len(s.movies))
+	compacted = append(compacted, rest[:first]...)
+	compacted = append(compacted, members...)
+	compacted = append(compacted, rest[first:]...)
+	s.movies = compacted
+}
+
+// movieRun is a sequence of movies sharing the same group
+type movieRun struct {
+	groupID string
+	folder  string // Folder of ungrouped movies with GroupByFolder, see folderGroup
+	movies  []Movie
+}
+
+// groupRuns splits movies into runs by group. With GroupByFolder, ungrouped movies from
+// different folders are split by folder too. The movies of a group or folder are gathered at
+// the position of its first movie, so an interleaved list gets each heading once; ungrouped
+// movies without a folder keep their place.
+func (s *SpoilerService) groupRuns(movies []Movie) []movieRun {
+	byFolder := s.settings.GroupByFolder && ungroupedFolderCount(movies) > 1
+
+	var runs []movieRun
+	runIndex := make(map[[2]string]int) // Run of each group or folder, by group ID and folder
+	for _, movie := range movies {
+		folder := ""
+		if byFolder && movie.GroupID == "" && !movie.Imported {
+			folder = filepath.Dir(movie.FilePath)
+		}
+		if movie.GroupID != "" || folder != "" {
+			key := [2]string{movie.GroupID, folder}
+			if i, exists := runIndex[key]; exists {
+				runs[i].movies = append(runs[i].movies, movie)
+				continue
+			}
+			runIndex[key] = len(runs)
+		} else if len(runs) > 0 && runs[len(runs)-1].groupID == "" && runs[len(runs)-1].folder == "" {
+			runs[len(runs)-1].movies = append(runs[len(runs)-1].movies, movie)
+			continue
+		}
+		runs = append(runs, movieRun{groupID: movie.GroupID, folder: folder, movies: []Movie{movie}})
+	}
+	return runs
+}
+
+// ungroupedFolderCount counts the folders the movies outside manual groups come from
+func ungroupedFolderCount(movies []Movie) int {
+	folders := make(map[string]bool)
+	for _, movie := range movies {
+		if movie.GroupID == "" && !movie.Imported {
+			folders[filepath.Dir(movie.FilePath)] = true
+		}
+	}
+	return len(folders)
+}
+
+// runGroup returns the group a run is rendered under: its manual group, or a group named
+// after the folder, e.g. "Season 01"
+func (s *SpoilerService) runGroup(run movieRun) (MovieGroup, bool) {
+	if group, exists := s.getGroupByID(run.groupID); exists {
+		return group, true
+	}
+	if run.folder == "" {
+		return MovieGroup{}, false
+	}
+	return MovieGroup{Name: filepath.Base(run.folder)}, true
+}
+
+// groupPlaceholders computes the aggregate placeholders for a group of movies
+func groupPlaceholders(group MovieGroup, movies []Movie) map[string]string {
+	var totalSize int64
+	var totalDuration float64
+	for _, movie := range movies {
+		totalSize += movie.FileSizeBytes
+		totalDuration += movie.DurationSeconds
+	}
+
+	return map[string]string{
+		"%GROUP_NAME%":        group.Name,
+		"%GROUP_DESCRIPTION%": group.Description,
+		"%GROUP_COUNT%":       strconv.Itoa(len(movies)),
+		"%GROUP_SIZE%":        FormatFileSize(totalSize),
+		"%GROUP_DURATION%":    FormatDuration(time.Duration(totalDuration * float64(time.Second))),
+	}
+}
+
+// renderGroupHeader renders the group heading template for a group
+func (s *SpoilerService) renderGroupHeader(group MovieGroup, movies []Movie) string {
+	template := s.settings.GroupHeaderTemplate
+	if template == "" {
+		template = DefaultSpoilerConfig.GroupHeaderTemplate
+	}
+	return replacePlaceholders(template, groupPlaceholders(group, movies))
+}
+
+// renderCollectionSpoiler wraps the rendered spoilers of a group in an outer collection spoiler
+func (s *SpoilerService) renderCollectionSpoiler(group MovieGroup, movies []Movie, content string) string {
+	template := s.settings.CollectionSpoilerTemplate
+	if template == "" {
+		template = DefaultSpoilerConfig.CollectionSpoilerTemplate
+	}
+
+	rendered := replacePlaceholders(template, groupPlaceholders(group, movies))
+	return strings.ReplaceAll(rendered, "%GROUP_CONTENT%", strings.TrimRight(content, "\n"))
+}
+
+// replacePlaceholders replaces every placeholder from the map in the template
+func replacePlaceholders(template string, replacements map[string]string) string {
+	for placeholder, value := range replacements {
+		template = strings.ReplaceAll(template, placeholder, value)
+	}
+	return template
+}
+
+// withGroupParams returns a copy of the movie whose params inherit its group's shared values.
+// With GroupByFolder, ungrouped movies get their folder's name as %GROUP_NAME%. Values set on
+// the movie itself take precedence.
+func (s *SpoilerService) withGroupParams(movie Movie) Movie {
+	group, exists := s.getGroupByID(movie.GroupID)
+	if !exists && s.settings.GroupByFolder && movie.GroupID == "" && !movie.Imported && movie.FilePath != "" {
+		group, exists = MovieGroup{Name: filepath.Base(filepath.Dir(movie.FilePath))}, true
+	}
+	if !exists {
+		return movie
+	}
+
+	params := make(map[string]string, len(movie.Params)+len(group.Params)+2)
+	for key, value := range group.Params {
+		params[key] = value
+	}
+	params["%GROUP_NAME%"] = group.Name
+	params["%GROUP_DESCRIPTION%"] = group.Description
+	for key, value := range movie.Params {
+		if value != "" {
+			params[key] = value
+		}
+	}
+
+	movie.Params = params
+	return movie
+}
@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"encoding/json"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dvProfilePattern finds the profile in mediainfo's "dvhe.08" or "Profile 8.1" notation
+var dvProfilePattern = regexp.MustCompile(`(?i)(?:dv[a-z0-9]{2}\.0?(\d+)|profile\s+(\d+(?:\.\d+)?))`)
+
+// detectHDRFormat names the HDR formats of the video stream, e.g. "Dolby Vision Profile 8.1, HDR10+, HDR10",
+// empty for SDR video. The mediainfo fields are preferred, ffprobe's stream data fills in without
+// mediainfo. HDR10+ metadata is only in the frames, so without mediainfo the first frame is probed.
+func detectHDRFormat(filePath string, mediaInfo MediaInfo, fields map[string]string) string {
+	hdrFormat := fields["%MI_VIDEO_HDR_FORMAT%"]
+	compatibility := fields["%MI_VIDEO_HDR_FORMAT_COMPATIBILITY%"]
+	transfer := strings.ToLower(fields["%MI_VIDEO_TRANSFER_CHARACTERISTICS%"] + " " + mediaInfo.Video["color_transfer"])
+	known := hdrFormat + " " + compatibility
+
+	var formats []string
+	profile := dolbyVisionProfile(mediaInfo, fields)
+	if profile != "" {
+		formats = append(formats, "Dolby Vision Profile "+profile)
+	} else if strings.Contains(hdrFormat, "Dolby Vision") {
+		formats = append(formats, "Dolby Vision")
+	}
+
+	pq := strings.Contains(transfer, "pq") || strings.Contains(transfer, "smpte2084")
+	hdr10Plus := strings.Contains(known, "2094 App 4") || strings.Contains(known, "HDR10+")
+	if !hdr10Plus && pq && hdrFormat == "" {
+		hdr10Plus = hasHDR10PlusMetadata(filePath)
+	}
+	if hdr10Plus {
+		formats = append(formats, "HDR10+")
+	}
+
+	// Profile 5 is PQ without an HDR10 base layer
+	if pq && profile == "5" {
+		pq = false
+	}
+	if pq || strings.Contains(hdrFormat, "2086") || strings.Contains(strings.ReplaceAll(known, "HDR10+", ""), "HDR10") {
+		formats = append(formats, "HDR10")
+	} else if strings.Contains(transfer, "hlg") || strings.Contains(transfer, "arib-std-b67") || strings.Contains(known, "HLG") {
+		formats = append(formats, "HLG")
+	}
+	return strings.Join(formats, ", ")
+}
+
+// dolbyVisionProfile returns the Dolby Vision profile, with the base layer compatibility for
+// profile 8 ("8.1" for HDR10, "8.4" for HLG), or empty without Dolby Vision
+func dolbyVisionProfile(mediaInfo MediaInfo, fields map[string]string) string {
+	if profile := mediaInfo.Video["dv_profile"]; profile != "" {
+		if compatibility := mediaInfo.Video["dv_compatibility"]; profile == "8" && compatibility != "" && compatibility != "0" {
+			return profile + "." + compatibility
+		}
+		return profile
+	}
+
+	if !strings.Contains(fields["%MI_VIDEO_HDR_FORMAT%"], "Dolby Vision") {
+		return ""
+	}
+	for _, key := range []string{"%MI_VIDEO_HDR_FORMAT_PROFILE%", "%MI_VIDEO_HDR_FORMAT%"} {
+		match := dvProfilePattern.FindStringSubmatch(fields[key])
+		if match == nil {
+			continue
+		}
+		if match[2] != "" {
+			return match[2]
+		}
+		profile := match[1]
+		if profile == "8" {
+			compatibility := fields["%MI_VIDEO_HDR_FORMAT_COMPATIBILITY%"]
+			switch {
+			case strings.Contains(compatibility, "HDR10"):
+				profile += ".1"
+			case strings.Contains(compatibility, "HLG"):
+				profile += ".4"
+			}
+		}
+		return profile
+	}
+	return ""
+}
+
+// hasHDR10PlusMetadata reports whether the first video frame carries HDR10+ dynamic metadata
+func hasHDR10PlusMetadata(filePath string) bool {
+	output, err := exec.Command(toolPath("ffprobe"),
+		"-v", "quiet",
+		"-select_streams", "v:0",
+		"-read_intervals", "%+#1",
+		"-show_entries", "frame=side_data_list",
+		"-print_format", "json",
+		filePath,
+	).Output()
+	if err != nil {
+		return false
+	}
+
+	var result struct {
+		Frames []struct {
+			SideDataList []map[string]any `json:"side_data_list"`
+		} `json:"frames"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return false
+	}
+	for _, frame := range result.Frames {
+		for _, sideData := range frame.SideDataList {
+			if sideDataType, _ := sideData["side_data_type"].(string); strings.Contains(sideDataType, "SMPTE2094-40") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dolbyVisionConfig returns the profile and base layer compatibility of the "DOVI configuration
+// record" in a stream's side data, empty strings without one
+func dolbyVisionConfig(sideDataList []map[string]any) (string, string) {
+	for _, sideData := range sideDataList {
+		if sideDataType, _ := sideData["side_data_type"].(string); !strings.Contains(sideDataType, "DOVI") {
+			continue
+		}
+		profile, ok := sideData["dv_profile"].(float64)
+		if !ok {
+			return "", ""
+		}
+		compatibility, _ := sideData["dv_bl_signal_compatibility_id"].(float64)
+		return strconv.Itoa(int(profile)), strconv.Itoa(int(compatibility))
+	}
+	return "", ""
+}
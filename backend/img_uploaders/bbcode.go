@@ -0,0 +1,72 @@
+package img_uploaders
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// BBCodeDialect selects how image BBCode is written, as forums differ in what they accept
+type BBCodeDialect string
+
+const (
+	BBCodeUppercase BBCodeDialect = "uppercase" // [URL=…][IMG]…[/IMG][/URL], the default
+	BBCodeLowercase BBCodeDialect = "lowercase" // [url=…][img]…[/img][/url]
+	BBCodeQuoted    BBCodeDialect = "quoted"    // [url="…"][img]…[/img][/url]
+	BBCodeThumb     BBCodeDialect = "thumb"     // [thumb]…[/thumb] thumbnails, lowercase otherwise
+)
+
+var (
+	linkedImagePattern = regexp.MustCompile(`(?is)^\[url=("?)([^\]"]+)"?\]\[img\](.+?)\[/img\]\[/url\]$`)
+	imagePattern       = regexp.MustCompile(`(?is)^\[img\](.+?)\[/img\]$`)
+)
+
+// IsValidBBCodeDialect reports whether dialect is a known BBCode dialect
+func IsValidBBCodeDialect(dialect string) bool {
+	switch BBCodeDialect(dialect) {
+	case BBCodeUppercase, BBCodeLowercase, BBCodeQuoted, BBCodeThumb:
+		return true
+	}
+	return false
+}
+
+// Image renders an inline image
+func (d BBCodeDialect) Image(image string) string {
+	if d == BBCodeUppercase || d == "" {
+		return fmt.Sprintf("[IMG]%s[/IMG]", image)
+	}
+	return fmt.Sprintf("[img]%s[/img]", image)
+}
+
+// LinkedImage renders an image that links to link
+func (d BBCodeDialect) LinkedImage(link, image string) string {
+	switch d {
+	case BBCodeLowercase, BBCodeThumb:
+		return fmt.Sprintf("[url=%s][img]%s[/img][/url]", link, image)
+	case BBCodeQuoted:
+		return fmt.Sprintf(`[url="%s"][img]%s[/img][/url]`, link, image)
+	default:
+		return fmt.Sprintf("[URL=%s][IMG]%s[/IMG][/URL]", link, image)
+	}
+}
+
+// rewrite renders a linked or inline image BBCode in the dialect, unknown markup is kept
+func (d BBCodeDialect) rewrite(code string) string {
+	if match := linkedImagePattern.FindStringSubmatch(code); match != nil {
+		return d.LinkedImage(match[2], match[3])
+	}
+	if match := imagePattern.FindStringSubmatch(code); match != nil {
+		return d.Image(match[1])
+	}
+	return code
+}
+
+// WithDialect returns the result with its BBCode rewritten in the dialect. The thumb dialect
+// lets the forum build the thumbnail from the direct link.
+func (r UploadResult) WithDialect(d BBCodeDialect) UploadResult {
+	r.BBThumb = d.rewrite(r.BBThumb)
+	r.BBBig = d.rewrite(r.BBBig)
+	if d == BBCodeThumb && r.Direct != "" {
+		r.BBThumb = fmt.Sprintf("[thumb]%s[/thumb]", r.Direct)
+	}
+	return r
+}
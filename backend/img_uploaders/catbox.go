@@ -0,0 +1,149 @@
+package img_uploaders
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	catboxAPIURL    = "https://catbox.moe/user/api.php"
+	litterboxAPIURL = "https://litterbox.catbox.moe/resources/internals/api.php"
+)
+
+// LitterboxExpiries lists the retention periods Litterbox accepts
+var LitterboxExpiries = []string{"1h", "12h", "24h", "72h"}
+
+// CatboxService uploads to Catbox, or to its temporary sibling Litterbox
+type CatboxService struct {
+	userHash  string
+	temporary bool   // Upload to Litterbox instead of Catbox
+	expiry    string // Litterbox retention period
+}
+
+// CatboxOptions holds the Catbox upload settings
+type CatboxOptions struct {
+	UserHash  string // Optional account hash so uploads show up in the Catbox account
+	Temporary bool   // Use Litterbox, files are deleted after Expiry
+	Expiry    string // One of LitterboxExpiries, "72h" when empty
+}
+
+func NewCatboxService(options CatboxOptions) *CatboxService {
+	expiry := options.Expiry
+	if expiry == "" {
+		expiry = "72h"
+	}
+	return &CatboxService{
+		userHash:  options.UserHash,
+		temporary: options.Temporary,
+		expiry:    expiry,
+	}
+}
+
+// IsValidLitterboxExpiry reports whether Litterbox accepts the retention period
+func IsValidLitterboxExpiry(expiry string) bool {
+	for _, valid := range LitterboxExpiries {
+		if expiry == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CatboxService) Name() string { return "catbox" }
+
+func (c *CatboxService) PlaceholderSuffix() string { return "CB" }
+
+// Init is a no-op, Catbox needs no session
+func (c *CatboxService) Init(ctx context.Context) error {
+	return nil
+}
+
+// Upload implements ImageUploader
+func (c *CatboxService) Upload(ctx context.Context, filePath, fileName string) (*UploadResult, error) {
+	log.Printf("Starting upload of %s to %s...", fileName, c.hostName())
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+
+	fields := map[string]string{
+		"reqtype": "fileupload",
+	}
+	apiURL := catboxAPIURL
+	if c.temporary {
+		apiURL = litterboxAPIURL
+		fields["time"] = c.expiry
+	} else if c.userHash != "" {
+		fields["userhash"] = c.userHash
+	}
+
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			return nil, fmt.Errorf("failed to write form field %s: %v", key, err)
+		}
+	}
+
+	if err := writeImagePart(writer, "fileToUpload", fileName, file); err != nil {
+		return nil, err
+	}
+	writer.Close()
+
+	payload, size := uploadBody(ctx, &buffer)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+
+	// Large contact sheets are the point of this host, allow slow uploads
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("upload cancelled: %v", ctx.Err())
+		}
+		return nil, fmt.Errorf("upload request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	link := strings.TrimSpace(string(body))
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(link, "https://") {
+		return nil, fmt.Errorf("%s returned status %d: %s", c.hostName(), resp.StatusCode, link)
+	}
+
+	log.Printf("Upload completed. URL: %s", link)
+
+	// Catbox does not generate thumbnails, the full image is linked in both variants
+	return &UploadResult{
+		Direct:  link,
+		BBThumb: BBCodeUppercase.LinkedImage(link, link),
+		BBBig:   BBCodeUppercase.Image(link),
+	}, nil
+}
+
+func (c *CatboxService) hostName() string {
+	if c.temporary {
+		return "litterbox"
+	}
+	return "catbox"
+}
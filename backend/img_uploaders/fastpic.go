@@ -19,10 +19,24 @@ import (
 	"golang.org/x/net/html"
 )
 
+// DefaultFastpicBaseURL is the fastpic domain used when no other is configured
+const DefaultFastpicBaseURL = "https://new.fastpic.org"
+
 type FastpicService struct {
 	sid                string
 	uploadID           string
+	baseURL            string // Domain that served the current upload session
 	imageMiniatureSize int
+	options            FastpicOptions
+}
+
+// FastpicOptions holds optional server-side processing settings for uploads
+type FastpicOptions struct {
+	DeleteAfterDays int      // Auto-delete images after N days, 0 keeps them forever
+	OrigResizeWidth int      // Server-side resize width for originals, 0 disables resizing
+	Optimization    bool     // Let fastpic optimize uploaded images
+	BaseURL         string   // Preferred domain, DefaultFastpicBaseURL when empty
+	Mirrors         []string // Fallback domains probed in order when the preferred one fails
 }
 
 type FastpicUploadResult struct {
@@ -32,17 +46,56 @@ type FastpicUploadResult struct {
 	BBBig     string `json:"bbBig"`
 }
 
-func NewFastpicService(sid string, imageMiniatureSize int) *FastpicService {
+func NewFastpicService(sid string, imageMiniatureSize int, options FastpicOptions) *FastpicService {
 	return &FastpicService{
 		sid:                sid,
 		imageMiniatureSize: imageMiniatureSize,
+		options:            options,
+		baseURL:            DefaultFastpicBaseURL,
+	}
+}
+
+// candidateBaseURLs returns the preferred domain followed by the mirrors and the default, without duplicates
+func (f *FastpicService) candidateBaseURLs() []string {
+	candidates := append([]string{f.options.BaseURL}, f.options.Mirrors...)
+	candidates = append(candidates, DefaultFastpicBaseURL)
+
+	var unique []string
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		candidate = strings.TrimRight(strings.TrimSpace(candidate), "/")
+		if candidate == "" || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		unique = append(unique, candidate)
 	}
+	return unique
 }
 
+// GetFastpicUploadID starts an upload session, probing the mirrors until one responds
 func (f *FastpicService) GetFastpicUploadID(ctx context.Context) error {
+	var errs []string
+	for _, baseURL := range f.candidateBaseURLs() {
+		err := f.requestUploadID(ctx, baseURL)
+		if err == nil {
+			f.baseURL = baseURL
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		log.Printf("Fastpic mirror %s unavailable: %v", baseURL, err)
+		errs = append(errs, fmt.Sprintf("%s: %v", baseURL, err))
+	}
+	return fmt.Errorf("no fastpic mirror available (%s)", strings.Join(errs, "; "))
+}
+
+// requestUploadID fetches a new upload ID from a single fastpic domain
+func (f *FastpicService) requestUploadID(ctx context.Context, baseURL string) error {
 	client := &http.Client{Timeout: 30 * time.Second}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://new.fastpic.org/", nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/", nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %v", err)
 	}
@@ -72,7 +125,7 @@ func (f *FastpicService) GetFastpicUploadID(ctx context.Context) error {
 		for _, cookie := range resp.Cookies() {
 			if cookie.Name == "fp_sid" && cookie.Value != "" {
 				f.sid = cookie.Value
-				log.Printf("Automatically obtained fp_sid: %s", f.sid)
+				log.Printf("Automatically obtained fp_sid")
 				break
 			}
 		}
@@ -111,7 +164,7 @@ func (f *FastpicService) GetFastpicUploadID(ctx context.Context) error {
 
 	uploadID := matches[1]
 	f.uploadID = uploadID
-	log.Printf("Successfully obtained fastpic upload ID: %s", uploadID)
+	log.Printf("Successfully obtained fastpic upload ID from %s: %s", baseURL, uploadID)
 	return nil
 }
 
@@ -132,6 +185,11 @@ func (f *FastpicService) UploadToFastpic(ctx context.Context, filePath, fileName
 	var buffer bytes.Buffer
 	writer := multipart.NewWriter(&buffer)
 
+	origResize := "1200"
+	if f.options.OrigResizeWidth > 0 {
+		origResize = strconv.Itoa(f.options.OrigResizeWidth)
+	}
+
 	fields := map[string]string{
 		"uploading":                 "1",
 		"fp":                        "not-loaded",
@@ -140,12 +198,12 @@ func (f *FastpicService) UploadToFastpic(ctx context.Context, filePath, fileName
 		"thumb_text":                "",
 		"thumb_size":                strconv.Itoa(f.imageMiniatureSize),
 		"check_thumb_size_vertical": "false",
-		"check_orig_resize":         "false",
-		"orig_resize":               "1200",
+		"check_orig_resize":         strconv.FormatBool(f.options.OrigResizeWidth > 0),
+		"orig_resize":               origResize,
 		"check_resize_frontend":     "false",
-		"check_optimization":        "false",
+		"check_optimization":        strconv.FormatBool(f.options.Optimization),
 		"check_poster":              "false",
-		"delete_after":              "0",
+		"delete_after":              strconv.Itoa(f.options.DeleteAfterDays),
 	}
 
 	for key, value := range fields {
@@ -154,21 +212,18 @@ func (f *FastpicService) UploadToFastpic(ctx context.Context, filePath, fileName
 		}
 	}
 
-	part, err := writer.CreateFormFile("file1", fileName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %v", err)
-	}
-
-	if _, err := io.Copy(part, file); err != nil {
-		return nil, fmt.Errorf("failed to copy file data: %v", err)
+	if err := writeImagePart(writer, "file1", fileName, file); err != nil {
+		return nil, err
 	}
 
 	writer.Close()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://new.fastpic.org/v2upload/", &buffer)
+	payload, size := uploadBody(ctx, &buffer)
+	req, err := http.NewRequestWithContext(ctx, "POST", f.baseURL+"/v2upload/", payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
+	req.ContentLength = size
 
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
@@ -206,7 +261,7 @@ func (f *FastpicService) UploadToFastpic(ctx context.Context, filePath, fileName
 	}
 
 	result := &FastpicUploadResult{
-		AlbumLink: "https://new.fastpic.org" + respJSON.AlbumLink,
+		AlbumLink: f.baseURL + respJSON.AlbumLink,
 		Direct:    extractDirectLink(respJSON.Codes),
 	}
 
@@ -221,7 +276,7 @@ func (f *FastpicService) UploadToFastpic(ctx context.Context, filePath, fileName
 // extractDirectLink extracts the direct image URL from the codes HTML
 func extractDirectLink(codesHTML string) string {
 	// Use regex to find the direct link input value
-	re := regexp.MustCompile(`<input[^>]*value="(https://[^"]*\.jpg)"[^>]*>`)
+	re := regexp.MustCompile(`<input[^>]*value="(https://[^"]*\.(?:jpe?g|png|webp))"[^>]*>`)
 	matches := re.FindStringSubmatch(codesHTML)
 	if len(matches) > 1 {
 		return matches[1]
@@ -251,9 +306,9 @@ func extractBBCodes(codesHTML string) (bbThumb, bbBig string) {
 			// Check if it's a BBCode format
 			if strings.HasPrefix(value, "[URL=") && strings.Contains(value, "[IMG]") {
 				// Distinguish between thumbnail and big image BBCode
-				if strings.Contains(value, "/thumb/") && strings.Contains(value, ".jpeg") {
+				if strings.Contains(value, "/thumb/") {
 					bbThumb = value
-				} else if strings.Contains(value, "/big/") && strings.Contains(value, ".jpg") {
+				} else if strings.Contains(value, "/big/") {
 					bbBig = value
 				}
 			}
@@ -267,3 +322,26 @@ func extractBBCodes(codesHTML string) (bbThumb, bbBig string) {
 	parseNode(doc)
 	return bbThumb, bbBig
 }
+
+func (f *FastpicService) Name() string { return "fastpic" }
+
+func (f *FastpicService) PlaceholderSuffix() string { return "FP" }
+
+// Init requests the upload session ID
+func (f *FastpicService) Init(ctx context.Context) error {
+	return f.GetFastpicUploadID(ctx)
+}
+
+// Upload implements ImageUploader
+func (f *FastpicService) Upload(ctx context.Context, filePath, fileName string) (*UploadResult, error) {
+	result, err := f.UploadToFastpic(ctx, filePath, fileName)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadResult{
+		Direct:    result.Direct,
+		BBThumb:   result.BBThumb,
+		BBBig:     result.BBBig,
+		AlbumLink: result.AlbumLink,
+	}, nil
+}
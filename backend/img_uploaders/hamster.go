@@ -26,9 +26,48 @@ type HamsterService struct {
 	password  string
 	authToken string
 	loggedIn  bool
+	options   HamsterOptions
 	client    tls_client.HttpClient
 }
 
+// HamsterOptions holds optional Chevereto upload settings
+type HamsterOptions struct {
+	ResizeWidth int    // Server-side resize width, 0 uploads originals
+	Expiration  string // Auto-delete interval in ISO 8601 duration format (e.g. "P1M"), empty keeps images
+}
+
+// hamsterExpirationPattern matches the date intervals Chevereto accepts for expiration
+var hamsterExpirationPattern = regexp.MustCompile(`^P(T\d+[HMS]|\d+[DWMY])$`)
+
+// IsValidHamsterExpiration reports whether an expiration interval is accepted by Chevereto
+func IsValidHamsterExpiration(expiration string) bool {
+	return expiration == "" || hamsterExpirationPattern.MatchString(expiration)
+}
+
+// HamsterExpirationDuration returns how long Chevereto keeps images with the expiration
+// interval, counting months as 30 days and years as 365. It returns 0 for no or an invalid
+// interval.
+func HamsterExpirationDuration(expiration string) time.Duration {
+	if !hamsterExpirationPattern.MatchString(expiration) {
+		return 0
+	}
+	interval := expiration[1:]
+	timePart := interval[0] == 'T'
+	if timePart {
+		interval = interval[1:]
+	}
+	count, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil {
+		return 0
+	}
+
+	unit := map[byte]time.Duration{'D': 24 * time.Hour, 'W': 7 * 24 * time.Hour, 'M': 30 * 24 * time.Hour, 'Y': 365 * 24 * time.Hour}
+	if timePart {
+		unit = map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	}
+	return time.Duration(count) * unit[interval[len(interval)-1]]
+}
+
 type HamsterUploadResult struct {
 	ID           string `json:"id"`
 	URL          string `json:"url"`
@@ -70,6 +109,11 @@ func NewHamsterService(email, password string) *HamsterService {
 	}
 }
 
+// SetOptions sets the optional upload settings
+func (h *HamsterService) SetOptions(options HamsterOptions) {
+	h.options = options
+}
+
 // extractAuthToken extracts auth_token from JavaScript in the page
 func (h *HamsterService) extractAuthToken(htmlContent string) (string, error) {
 	// Look for PF.obj.config.auth_token = "token_value";
@@ -211,7 +255,7 @@ func (h *HamsterService) Login(ctx context.Context) error {
 	log.Printf("Cookies in session for hamster.is:")
 	keepLoginFound := false
 	for _, cookie := range cookies {
-		log.Printf("Session Cookie: %s = %s", cookie.Name, cookie.Value)
+		log.Printf("Session Cookie: %s", cookie.Name)
 		if cookie.Name == "KEEP_LOGIN" {
 			keepLoginFound = true
 			log.Printf("Found KEEP_LOGIN cookie in session!")
@@ -254,21 +298,7 @@ func (h *HamsterService) uploadToHamster(ctx context.Context, filePath, fileName
 	}
 	defer file.Close()
 
-	// Determine content type
-	contentType := "application/octet-stream"
-	ext := filepath.Ext(fileName)
-	switch ext {
-	case ".jpg", ".jpeg":
-		contentType = "image/jpeg"
-	case ".png":
-		contentType = "image/png"
-	case ".gif":
-		contentType = "image/gif"
-	case ".webp":
-		contentType = "image/webp"
-	case ".bmp":
-		contentType = "image/bmp"
-	}
+	contentType := ImageContentType(fileName)
 
 	timestamp := h.getTimestamp()
 
@@ -276,13 +306,8 @@ func (h *HamsterService) uploadToHamster(ctx context.Context, filePath, fileName
 	writer := multipart.NewWriter(&buffer)
 
 	// Add the image file first
-	part, err := writer.CreateFormFile("source", fileName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %v", err)
-	}
-
-	if _, err := io.Copy(part, file); err != nil {
-		return nil, fmt.Errorf("failed to copy file data: %v", err)
+	if err := writeImagePart(writer, "source", fileName, file); err != nil {
+		return nil, err
 	}
 
 	// Add all required form fields
@@ -295,6 +320,12 @@ func (h *HamsterService) uploadToHamster(ctx context.Context, filePath, fileName
 		"mimetype":   contentType,
 		"checksum":   "",
 	}
+	if h.options.ResizeWidth > 0 {
+		fields["width"] = strconv.Itoa(h.options.ResizeWidth)
+	}
+	if h.options.Expiration != "" {
+		fields["expiration"] = h.options.Expiration
+	}
 
 	for key, value := range fields {
 		if err := writer.WriteField(key, value); err != nil {
@@ -304,10 +335,12 @@ func (h *HamsterService) uploadToHamster(ctx context.Context, filePath, fileName
 
 	writer.Close()
 
-	req, err := http.NewRequest(http.MethodPost, "https://hamster.is/json", &buffer)
+	payload, size := uploadBody(ctx, &buffer)
+	req, err := http.NewRequest(http.MethodPost, "https://hamster.is/json", payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create upload request: %v", err)
 	}
+	req.ContentLength = size
 
 	req.Header = http.Header{
 		"accept":         {"application/json"},
@@ -368,8 +401,8 @@ func (h *HamsterService) uploadToHamster(ctx context.Context, filePath, fileName
 	}
 
 	// Generate BBCode formats
-	result.BBThumb = fmt.Sprintf("[URL=%s][IMG]%s[/IMG][/URL]", result.ViewerURL, result.ThumbnailURL)
-	result.BBBig = fmt.Sprintf("[URL=%s][IMG]%s[/IMG][/URL]", result.ViewerURL, result.URL)
+	result.BBThumb = BBCodeUppercase.LinkedImage(result.ViewerURL, result.ThumbnailURL)
+	result.BBBig = BBCodeUppercase.LinkedImage(result.ViewerURL, result.URL)
 
 	log.Printf("Upload completed. URL: %s, Viewer: %s, Thumbnail: %s",
 		result.URL, result.ViewerURL, result.ThumbnailURL)
@@ -379,8 +412,11 @@ func (h *HamsterService) uploadToHamster(ctx context.Context, filePath, fileName
 
 // UploadImage is the main public method to upload an image
 func (h *HamsterService) UploadImage(ctx context.Context, filePath string) (*HamsterUploadResult, error) {
-	fileName := filepath.Base(filePath)
+	return h.UploadImageAs(ctx, filePath, filepath.Base(filePath))
+}
 
+// UploadImageAs uploads an image under the given file name instead of its on-disk name
+func (h *HamsterService) UploadImageAs(ctx context.Context, filePath, fileName string) (*HamsterUploadResult, error) {
 	// Validate file exists and get basic info
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
@@ -402,3 +438,25 @@ func (h *HamsterService) UploadImage(ctx context.Context, filePath string) (*Ham
 
 	return h.uploadToHamster(ctx, filePath, fileName)
 }
+
+func (h *HamsterService) Name() string { return "hamster" }
+
+func (h *HamsterService) PlaceholderSuffix() string { return "HAM" }
+
+// Init logs in to Hamster
+func (h *HamsterService) Init(ctx context.Context) error {
+	return h.Login(ctx)
+}
+
+// Upload implements ImageUploader
+func (h *HamsterService) Upload(ctx context.Context, filePath, fileName string) (*UploadResult, error) {
+	result, err := h.UploadImageAs(ctx, filePath, fileName)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadResult{
+		Direct:  result.URL,
+		BBThumb: result.BBThumb,
+		BBBig:   result.BBBig,
+	}, nil
+}
@@ -19,8 +19,15 @@ import (
 	"github.com/bogdanfinn/tls-client/profiles"
 )
 
+// Imgbox content types
+const (
+	ImgboxContentFamily = "1"
+	ImgboxContentAdult  = "2"
+)
+
 type ImgboxService struct {
 	imageMiniatureSize int
+	contentType        string
 	csrfToken          string
 	tokenID            string
 	tokenSecret        string
@@ -44,7 +51,7 @@ type ImgboxResponse struct {
 	Files []ImgboxUploadResult `json:"files"`
 }
 
-func NewImgboxService(imageMiniatureSize int) *ImgboxService {
+func NewImgboxService(imageMiniatureSize int, familySafe bool) *ImgboxService {
 	jar := tls_client.NewCookieJar()
 	options := []tls_client.HttpClientOption{
 		tls_client.WithTimeoutSeconds(60),
@@ -59,8 +66,14 @@ func NewImgboxService(imageMiniatureSize int) *ImgboxService {
 		return nil
 	}
 
+	contentType := ImgboxContentAdult
+	if familySafe {
+		contentType = ImgboxContentFamily
+	}
+
 	return &ImgboxService{
 		imageMiniatureSize: imageMiniatureSize,
+		contentType:        contentType,
 		client:             client,
 	}
 }
@@ -229,7 +242,7 @@ func (i *ImgboxService) uploadToImgbox(ctx context.Context, filePath, fileName s
 	fields := map[string]string{
 		"token_id":         i.tokenID,
 		"token_secret":     i.tokenSecret,
-		"content_type":     "2",
+		"content_type":     i.contentType,
 		"thumbnail_size":   strconv.Itoa(i.imageMiniatureSize) + "r",
 		"gallery_id":       "null",
 		"gallery_secret":   "null",
@@ -243,21 +256,18 @@ func (i *ImgboxService) uploadToImgbox(ctx context.Context, filePath, fileName s
 	}
 
 	// Add the file
-	part, err := writer.CreateFormFile("files[]", fileName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %v", err)
-	}
-
-	if _, err := io.Copy(part, file); err != nil {
-		return nil, fmt.Errorf("failed to copy file data: %v", err)
+	if err := writeImagePart(writer, "files[]", fileName, file); err != nil {
+		return nil, err
 	}
 
 	writer.Close()
 
-	req, err := http.NewRequest(http.MethodPost, "https://imgbox.com/upload/process", &buffer)
+	payload, size := uploadBody(ctx, &buffer)
+	req, err := http.NewRequest(http.MethodPost, "https://imgbox.com/upload/process", payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create upload request: %v", err)
 	}
+	req.ContentLength = size
 
 	req.Header = http.Header{
 		"content-type": {writer.FormDataContentType()},
@@ -301,8 +311,8 @@ func (i *ImgboxService) uploadToImgbox(ctx context.Context, filePath, fileName s
 
 	result := &respJSON.Files[0]
 
-	result.BBThumb = fmt.Sprintf("[URL=%s][IMG]%s[/IMG][/URL]", result.URL, result.ThumbnailURL)
-	result.BBBig = fmt.Sprintf("[URL=%s][IMG]%s[/IMG][/URL]", result.URL, result.OriginalURL)
+	result.BBThumb = BBCodeUppercase.LinkedImage(result.URL, result.ThumbnailURL)
+	result.BBBig = BBCodeUppercase.LinkedImage(result.URL, result.OriginalURL)
 
 	log.Printf("Upload completed. URL: %s, Original: %s, Thumbnail: %s",
 		result.URL, result.OriginalURL, result.ThumbnailURL)
@@ -312,8 +322,11 @@ func (i *ImgboxService) uploadToImgbox(ctx context.Context, filePath, fileName s
 
 // UploadImage is the main public method to upload an image
 func (i *ImgboxService) UploadImage(ctx context.Context, filePath string) (*ImgboxUploadResult, error) {
-	fileName := filepath.Base(filePath)
+	return i.UploadImageAs(ctx, filePath, filepath.Base(filePath))
+}
 
+// UploadImageAs uploads an image under the given file name instead of its on-disk name
+func (i *ImgboxService) UploadImageAs(ctx context.Context, filePath, fileName string) (*ImgboxUploadResult, error) {
 	// Validate file exists and get basic info
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
@@ -335,3 +348,25 @@ func (i *ImgboxService) UploadImage(ctx context.Context, filePath string) (*Imgb
 
 	return i.uploadToImgbox(ctx, filePath, fileName)
 }
+
+func (i *ImgboxService) Name() string { return "imgbox" }
+
+func (i *ImgboxService) PlaceholderSuffix() string { return "IB" }
+
+// Init requests fresh upload tokens so a long-lived service never reuses expired ones
+func (i *ImgboxService) Init(ctx context.Context) error {
+	return i.initializeTokens(ctx)
+}
+
+// Upload implements ImageUploader
+func (i *ImgboxService) Upload(ctx context.Context, filePath, fileName string) (*UploadResult, error) {
+	result, err := i.UploadImageAs(ctx, filePath, fileName)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadResult{
+		Direct:  result.OriginalURL,
+		BBThumb: result.BBThumb,
+		BBBig:   result.BBBig,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package img_uploaders
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+)
+
+// imageContentTypes maps image extensions to their MIME type
+var imageContentTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".bmp":  "image/bmp",
+}
+
+// ImageContentType returns the MIME type of an image file name, application/octet-stream
+// when the extension is unknown
+func ImageContentType(fileName string) string {
+	if contentType, ok := imageContentTypes[strings.ToLower(filepath.Ext(fileName))]; ok {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// writeImagePart adds an image as a form file with its actual content type. Hosts sniff
+// the type from the part header, which CreateFormFile always sets to octet-stream.
+func writeImagePart(writer *multipart.Writer, field, fileName string, data io.Reader) error {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(field), escapeQuotes(fileName)))
+	header.Set("Content-Type", ImageContentType(fileName))
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("failed to create form file: %v", err)
+	}
+	if _, err := io.Copy(part, data); err != nil {
+		return fmt.Errorf("failed to copy file data: %v", err)
+	}
+	return nil
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,45 @@
+package img_uploaders
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// ProgressFunc receives the bytes of an upload body sent so far
+type ProgressFunc func(sent, total int64)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose uploads report their body progress to fn
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// progressReader counts the bytes read from an upload body
+type progressReader struct {
+	r      io.Reader
+	sent   int64
+	total  int64
+	report ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.report(p.sent, p.total)
+	}
+	return n, err
+}
+
+// uploadBody wraps an upload body so the progress function of ctx sees the bytes sent. The
+// returned length must be set as the request's ContentLength, a wrapped body has none.
+func uploadBody(ctx context.Context, body *bytes.Buffer) (io.Reader, int64) {
+	size := int64(body.Len())
+	report, ok := ctx.Value(progressKey{}).(ProgressFunc)
+	if !ok || report == nil {
+		return body, size
+	}
+	return &progressReader{r: body, total: size, report: report}, size
+}
@@ -0,0 +1,27 @@
+package img_uploaders
+
+import (
+	"context"
+	"time"
+)
+
+// UploadResult is the host-independent result of a single image upload
+type UploadResult struct {
+	Direct    string    `json:"direct"`
+	BBThumb   string    `json:"bbThumb"`
+	BBBig     string    `json:"bbBig"`
+	AlbumLink string    `json:"albumLink,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"` // When the host deletes the image, zero when it keeps it
+}
+
+// ImageUploader is implemented by every image host
+type ImageUploader interface {
+	// Name identifies the host, e.g. "fastpic"
+	Name() string
+	// PlaceholderSuffix is the template suffix of the host, e.g. "FP" for %SCREENSHOTS_FP%
+	PlaceholderSuffix() string
+	// Init prepares the uploader (login, upload session) before the first upload
+	Init(ctx context.Context) error
+	// Upload uploads an image under the given file name
+	Upload(ctx context.Context, filePath, fileName string) (*UploadResult, error)
+}
@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// launchQueue holds the files the app was asked to open until its window is ready
+type launchQueue struct {
+	mu      sync.Mutex
+	ready   bool
+	pending []string
+}
+
+// LaunchPaths returns the existing files and folders among launch arguments, absolute against
+// dir. Flags are skipped, including the process serial number older macOS versions pass.
+func LaunchPaths(args []string, dir string) []string {
+	var paths []string
+	for _, arg := range args {
+		if arg == "" || strings.HasPrefix(arg, "-") {
+			continue
+		}
+		path := arg
+		if !filepath.IsAbs(path) && dir != "" {
+			path = filepath.Join(dir, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			log.Printf("Ignoring launch argument %s: %v", arg, err)
+			continue
+		}
+		paths = append(paths, filepath.Clean(path))
+	}
+	return paths
+}
+
+// OpenFiles adds files passed on the command line, by a file association or by a second
+// instance. Files opened before the window is ready are added once it is, see WindowReady.
+func (s *SpoilerService) OpenFiles(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	s.launch.mu.Lock()
+	if !s.launch.ready {
+		s.launch.pending = append(s.launch.pending, paths...)
+		s.launch.mu.Unlock()
+		return
+	}
+	s.launch.mu.Unlock()
+
+	log.Printf("Opening %d files", len(paths))
+	if err := s.AddMovies(paths); err != nil {
+		log.Printf("Error adding opened files: %v", err)
+	}
+}
+
+// WindowReady adds the files that were opened before the frontend could show them
+func (s *SpoilerService) WindowReady() {
+	s.launch.mu.Lock()
+	if s.launch.ready {
+		s.launch.mu.Unlock()
+		return
+	}
+	s.launch.ready = true
+	pending := s.launch.pending
+	s.launch.pending = nil
+	s.launch.mu.Unlock()
+
+	s.OpenFiles(pending)
+}
@@ -0,0 +1,284 @@
+package backend
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"spoilr/backend/img_uploaders"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	linkCheckTimeout     = 30 * time.Second
+	linkCheckConcurrency = 8
+)
+
+// Kinds of checked images
+const (
+	LinkContactSheet = "contact_sheet"
+	LinkScreenshot   = "screenshot"
+)
+
+// DeadLink is an uploaded image the host no longer serves
+type DeadLink struct {
+	Host   string `json:"host"`
+	Image  string `json:"image"` // LinkContactSheet or LinkScreenshot
+	Index  int    `json:"index"` // Screenshot position, 0 for the contact sheet
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+// LinkReport summarizes a link check
+type LinkReport struct {
+	Checked     int                   `json:"checked"`
+	Unreachable int                   `json:"unreachable"` // Hosts that did not answer, not counted as dead
+	Dead        map[string][]DeadLink `json:"dead"`        // Per movie ID
+}
+
+// checkedLink is an image link of a movie to check
+type checkedLink struct {
+	movieID string
+	link    DeadLink
+}
+
+// VerifyLinks checks the uploaded images of completed movies, all completed movies when ids
+// is empty. Images the hosts no longer serve are marked on the movies as DeadLinks, which
+// ReuploadDeadLinks uploads again. Hosts that do not answer leave the marks untouched.
+func (s *SpoilerService) VerifyLinks(ids []string) (LinkReport, error) {
+	report := LinkReport{Dead: make(map[string][]DeadLink)}
+	if s.processing {
+		return report, fmt.Errorf("processing already in progress")
+	}
+
+	movies := s.linkCheckMovies(ids)
+	if len(movies) == 0 {
+		return report, fmt.Errorf("no completed movies to check")
+	}
+
+	var links []checkedLink
+	for _, movie := range movies {
+		links = append(links, movieImageLinks(movie)...)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	unreachable := make(map[string]bool)
+	slots := make(chan struct{}, linkCheckConcurrency)
+	for _, link := range links {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			alive, reason, err := checkImageLink(link.link.URL)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checked++
+			if err != nil {
+				log.Printf("Link check of %s failed: %v", link.link.URL, err)
+				report.Unreachable++
+				unreachable[link.movieID] = true
+				return
+			}
+			if !alive {
+				link.link.Reason = reason
+				report.Dead[link.movieID] = append(report.Dead[link.movieID], link.link)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, movie := range movies {
+		dead := report.Dead[movie.ID]
+		slices.SortFunc(dead, func(a, b DeadLink) int {
+			return cmp.Or(strings.Compare(a.Host, b.Host), strings.Compare(a.Image, b.Image), cmp.Compare(a.Index, b.Index))
+		})
+		if len(dead) == 0 && unreachable[movie.ID] {
+			continue
+		}
+		s.updateMovieByID(movie.ID, func(m *Movie) {
+			m.DeadLinks = dead
+		})
+		for _, link := range dead {
+			s.uploadCache.Forget(movie.Fingerprint, link.Host)
+		}
+		s.recordEvent(movie.ID, "links", fmt.Sprintf("Link check found %d dead images", len(dead)), nil)
+	}
+	s.emitState()
+
+	log.Printf("Link check: %d images checked, %d dead, %d unreachable", report.Checked, countDeadLinks(report.Dead), report.Unreachable)
+	return report, nil
+}
+
+// linkCheckMovies returns the completed movies with the given IDs, or all completed movies
+func (s *SpoilerService) linkCheckMovies(ids []string) []Movie {
+	s.moviesMu.Lock()
+	defer s.moviesMu.Unlock()
+
+	var movies []Movie
+	for _, movie := range s.movies {
+		if movie.ProcessingState.IsCompleted() && (len(ids) == 0 || slices.Contains(ids, movie.ID)) {
+			movies = append(movies, movie)
+		}
+	}
+	return movies
+}
+
+// movieImageLinks lists the direct links of every image a movie uploaded
+func movieImageLinks(movie Movie) []checkedLink {
+	var links []checkedLink
+	for _, host := range slices.Sorted(maps.Keys(movie.Uploads)) {
+		uploads := movie.Uploads[host]
+		if uploads == nil {
+			continue
+		}
+		if uploads.ContactSheetDirectURL != "" {
+			links = append(links, checkedLink{movie.ID, DeadLink{Host: host, Image: LinkContactSheet, URL: uploads.ContactSheetDirectURL}})
+		}
+		for i, direct := range uploads.ScreenshotDirectURLs {
+			if direct != "" {
+				links = append(links, checkedLink{movie.ID, DeadLink{Host: host, Image: LinkScreenshot, Index: i, URL: direct}})
+			}
+		}
+	}
+	return links
+}
+
+// checkImageLink asks the host for an image. It reports false with the reason when the host
+// answers without the image, and an error when the host could not be reached.
+func checkImageLink(rawURL string) (bool, string, error) {
+	resp, err := requestImage(http.MethodHead, rawURL)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		// Some hosts only answer GET
+		resp, err = requestImage(http.MethodGet, rawURL)
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	if resp.StatusCode >= 500 {
+		return false, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, resp.Status, nil
+	}
+	// Purged images are often replaced by an HTML page or a redirect to one
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "image/") {
+		return false, "not an image (" + contentType + ")", nil
+	}
+	return true, "", nil
+}
+
+// requestImage sends a request and discards the body, only the response headers are used
+func requestImage(method, rawURL string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), linkCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	return resp, nil
+}
+
+func countDeadLinks(dead map[string][]DeadLink) int {
+	count := 0
+	for _, links := range dead {
+		count += len(links)
+	}
+	return count
+}
+
+// ReuploadDeadLinks uploads the images marked dead by VerifyLinks again, all marked movies
+// when ids is empty. Only the dead images are replaced: movies with a source file regenerate
+// their media, imported movies download the images from hosts that still serve them. Like
+// RefreshUploads, the updated BBCode is sent with the "uploads-refreshed" event.
+func (s *SpoilerService) ReuploadDeadLinks(ids []string) error {
+	if s.processing {
+		return fmt.Errorf("processing already in progress")
+	}
+
+	var targets []string
+	sources := make(map[string]map[string]HostUploads)
+	s.uploadsMu.Lock()
+	for _, movie := range s.linkCheckMovies(ids) {
+		if len(movie.DeadLinks) == 0 {
+			continue
+		}
+		if movie.Imported {
+			hosts := make(map[string]HostUploads)
+			for host, uploads := range movie.Uploads {
+				if uploads != nil {
+					hosts[host] = *uploads
+				}
+			}
+			sources[movie.ID] = hosts
+		}
+		s.transitionMovieState(movie.ID, StatePending)
+		s.updateMovieByID(movie.ID, func(m *Movie) {
+			for _, dead := range m.DeadLinks {
+				clearDeadLink(m.Uploads[dead.Host], dead)
+			}
+			m.DeadLinks = nil
+			m.ProcessingError = ""
+		})
+		s.recordEvent(movie.ID, "processing", fmt.Sprintf("Re-upload of %d dead images requested", len(movie.DeadLinks)), nil)
+		targets = append(targets, movie.ID)
+	}
+	s.uploadsMu.Unlock()
+
+	if len(targets) == 0 {
+		return fmt.Errorf("no dead links to re-upload, run a link check first")
+	}
+
+	var movies []Movie
+	for _, id := range s.orderedMovieIDs(targets) {
+		movie, _ := s.getMovieByID(id)
+		movies = append(movies, movie)
+	}
+
+	// Also marks the run as a refresh, so the upload history does not return the dead links
+	s.refreshSources = sources
+	s.applyPresetGeneration()
+	s.startProcessing(movies, func() {
+		s.refreshSources = nil
+		s.emitUploadsRefreshed(targets)
+	})
+	return nil
+}
+
+// clearDeadLink removes the results of a dead image, so processing uploads it again
+func clearDeadLink(uploads *HostUploads, dead DeadLink) {
+	if uploads == nil {
+		return
+	}
+	if dead.Image == LinkContactSheet {
+		uploads.ContactSheetURL = ""
+		uploads.ContactSheetBigURL = ""
+		uploads.ContactSheetDirectURL = ""
+		uploads.ContactSheetResult = nil
+		return
+	}
+	for _, urls := range []*[]string{&uploads.ScreenshotURLs, &uploads.ScreenshotBigURLs, &uploads.ScreenshotDirectURLs} {
+		if dead.Index < len(*urls) {
+			(*urls)[dead.Index] = ""
+		}
+	}
+	if dead.Index < len(uploads.ScreenshotResults) {
+		uploads.ScreenshotResults[dead.Index] = img_uploaders.UploadResult{}
+	}
+}
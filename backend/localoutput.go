@@ -0,0 +1,143 @@
+package backend
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localOutputFolder returns the folder of a movie inside the local output directory, named
+// after the file. When another movie in the list has a file of the same name, e.g.
+// "Season 01/01.mkv" and "Season 02/01.mkv", the name of the parent folder is prepended, and
+// the movie ID when that is the same too.
+func (s *SpoilerService) localOutputFolder(movie Movie) string {
+	name := movieOutputName(movie)
+	if name == "" {
+		return movie.ID
+	}
+
+	parent := sanitizeFileName(filepath.Base(filepath.Dir(movie.FilePath)))
+	nameTaken, parentTaken := false, false
+	s.moviesMu.Lock()
+	for _, other := range s.movies {
+		if other.ID == movie.ID || movieOutputName(other) != name {
+			continue
+		}
+		nameTaken = true
+		if sanitizeFileName(filepath.Base(filepath.Dir(other.FilePath))) == parent {
+			parentTaken = true
+		}
+	}
+	s.moviesMu.Unlock()
+
+	switch {
+	case !nameTaken:
+		return name
+	case parent != "" && !parentTaken:
+		return parent + " - " + name
+	default:
+		return name + " - " + movie.ID
+	}
+}
+
+// movieOutputName returns the sanitized file name of a movie without its extension
+func movieOutputName(movie Movie) string {
+	return sanitizeFileName(strings.TrimSuffix(movie.FileName, filepath.Ext(movie.FileName)))
+}
+
+// saveLocalOutput copies the generated contact sheet and screenshots of a movie into its
+// folder of the local output directory. Files of an earlier run are replaced.
+func (s *SpoilerService) saveLocalOutput(movie Movie, contactSheetPath string, screenshotPaths []string) {
+	if s.settings.LocalOutputDir == "" {
+		return
+	}
+
+	dir := filepath.Join(s.settings.LocalOutputDir, s.localOutputFolder(movie))
+	err := s.prepareLocalOutputFolder(dir)
+	if err == nil {
+		var contactSheet string
+		var screenshots []string
+		contactSheet, screenshots, err = copyLocalOutput(dir, contactSheetPath, screenshotPaths)
+		s.updateMovieByID(movie.ID, func(m *Movie) {
+			m.LocalContactSheet = contactSheet
+			m.LocalScreenshots = screenshots
+		})
+	}
+
+	s.recordEvent(movie.ID, "output", "Media saved to "+dir, err)
+	if err != nil {
+		s.addMovieError(movie.ID, fmt.Sprintf("Failed to save media to the output folder: %v", err))
+	}
+}
+
+// prepareLocalOutputFolder creates a movie's output folder and removes media left by an
+// earlier run, which may have had more screenshots
+func (s *SpoilerService) prepareLocalOutputFolder(dir string) error {
+	if err := s.guardSourceWrite(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, pattern := range []string{"contact_sheet.*", "screenshot_*"} {
+		stale, _ := filepath.Glob(filepath.Join(dir, pattern))
+		for _, path := range stale {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyLocalOutput copies the media into dir and returns the copies. Screenshots keep their
+// position, skipped or failed ones are empty.
+func copyLocalOutput(dir, contactSheetPath string, screenshotPaths []string) (string, []string, error) {
+	var contactSheet string
+	if contactSheetPath != "" {
+		contactSheet = filepath.Join(dir, "contact_sheet"+filepath.Ext(contactSheetPath))
+		if err := copyFile(contactSheetPath, contactSheet); err != nil {
+			return "", nil, err
+		}
+	}
+
+	screenshots := make([]string, len(screenshotPaths))
+	for i, path := range screenshotPaths {
+		if path == "" {
+			continue
+		}
+		screenshots[i] = filepath.Join(dir, fmt.Sprintf("screenshot_%02d%s", i+1, filepath.Ext(path)))
+		if err := copyFile(path, screenshots[i]); err != nil {
+			return contactSheet, screenshots[:i], err
+		}
+	}
+	return contactSheet, screenshots, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// replaceLocalPlaceholders replaces %CONTACT_SHEET_LOCAL% and %SCREENSHOTS_LOCAL% with the
+// paths of the media saved to the local output directory
+func (s *SpoilerService) replaceLocalPlaceholders(template string, movie Movie) string {
+	template = s.replaceIfNotEmpty(template, "%CONTACT_SHEET_LOCAL%", movie.LocalContactSheet)
+	screenshots := s.filterNonEmptyStrings(movie.LocalScreenshots)
+	return s.replaceScreenshotGroup(template, "%SCREENSHOTS_LOCAL%", "%SCREENSHOTS_LOCAL_SPACED%", screenshots)
+}
@@ -0,0 +1,44 @@
+package backend
+
+import (
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+const maxLogLines = 2000
+
+// LogBuffer keeps the most recent log lines in memory for debug bundles
+type LogBuffer struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+var recentLogs = &LogBuffer{}
+
+// InstallLogBuffer tees the standard logger into the in-memory log buffer
+func InstallLogBuffer() {
+	log.SetOutput(io.MultiWriter(os.Stderr, recentLogs))
+}
+
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		b.lines = append(b.lines, line)
+	}
+	if len(b.lines) > maxLogLines {
+		b.lines = b.lines[len(b.lines)-maxLogLines:]
+	}
+	return len(p), nil
+}
+
+// String returns the buffered log lines
+func (b *LogBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Join(b.lines, "\n")
+}
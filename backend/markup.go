@@ -0,0 +1,284 @@
+package backend
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Output formats a preset renders to. Templates are always written in BBCode, other formats
+// are converted from the rendered BBCode.
+const (
+	OutputFormatBBCode    = "bbcode"
+	OutputFormatDiscourse = "discourse" // Discourse-flavored Markdown
+	OutputFormatIPB       = "ipb"       // Invision Power Board editor HTML
+)
+
+// bbTagPattern matches a tag with an optional argument. A quoted argument runs up to the
+// closing `"]` on the same line, so titles like "[Group] Show [1080p]" keep their brackets.
+var bbTagPattern = regexp.MustCompile(`\[(/?)([a-zA-Z*]+)(?:=("(?:[^"\n]|"[^\]\n])*"|[^\]]*))?\]`)
+
+// bbKnownTags are the tags converted to other formats, others are kept as text
+var bbKnownTags = map[string]bool{
+	"b": true, "i": true, "u": true, "s": true,
+	"url": true, "img": true, "spoiler": true, "quote": true, "code": true,
+	"center": true, "left": true, "right": true, "size": true, "color": true, "font": true,
+	"hr": true, "list": true, "*": true,
+}
+
+// bbNode is a BBCode tag with its content, or a text run when tag is empty
+type bbNode struct {
+	tag      string
+	option   string
+	text     string
+	children []*bbNode
+}
+
+func isValidOutputFormat(format string) bool {
+	switch format {
+	case "", OutputFormatBBCode, OutputFormatDiscourse, OutputFormatIPB:
+		return true
+	}
+	return false
+}
+
+// parseBBCode parses BBCode into a tree. Unknown and unmatched closing tags stay text, an
+// unclosed tag ends with the input, and code blocks are not parsed.
+func parseBBCode(text string) *bbNode {
+	root := &bbNode{}
+	stack := []*bbNode{root}
+	top := func() *bbNode { return stack[len(stack)-1] }
+	appendText := func(value string) {
+		if value == "" {
+			return
+		}
+		children := top().children
+		if n := len(children); n > 0 && children[n-1].tag == "" {
+			children[n-1].text += value
+			return
+		}
+		top().children = append(children, &bbNode{text: value})
+	}
+
+	last := 0
+	for _, match := range bbTagPattern.FindAllStringSubmatchIndex(text, -1) {
+		raw := text[match[0]:match[1]]
+		closing := match[3] > match[2]
+		tag := strings.ToLower(text[match[4]:match[5]])
+		option := ""
+		if match[6] >= 0 {
+			option = strings.Trim(text[match[6]:match[7]], `"`)
+		}
+
+		if !bbKnownTags[tag] || (top().tag == "code" && !(closing && tag == "code")) {
+			continue
+		}
+		appendText(text[last:match[0]])
+		last = match[1]
+
+		if closing {
+			depth := -1
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tag == tag {
+					depth = i
+					break
+				}
+			}
+			if depth < 0 {
+				appendText(raw)
+				continue
+			}
+			stack = stack[:depth]
+			continue
+		}
+
+		if tag == "*" && top().tag == "*" {
+			stack = stack[:len(stack)-1]
+		}
+		node := &bbNode{tag: tag, option: option}
+		top().children = append(top().children, node)
+		if tag != "hr" {
+			stack = append(stack, node)
+		}
+	}
+	appendText(text[last:])
+	return root
+}
+
+// ConvertBBCode renders BBCode in the given output format
+func ConvertBBCode(text, format string) string {
+	var b strings.Builder
+	switch format {
+	case OutputFormatDiscourse:
+		renderMarkdown(&b, parseBBCode(text).children)
+	case OutputFormatIPB:
+		renderIPB(&b, parseBBCode(text).children)
+	default:
+		return text
+	}
+	return b.String()
+}
+
+// plainText returns the text content of nodes, used for link targets and code blocks
+func plainText(nodes []*bbNode) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		b.WriteString(node.text)
+		b.WriteString(plainText(node.children))
+	}
+	return b.String()
+}
+
+func renderMarkdown(b *strings.Builder, nodes []*bbNode) {
+	for _, node := range nodes {
+		switch node.tag {
+		case "":
+			b.WriteString(node.text)
+		case "b":
+			wrapMarkdown(b, "**", node.children)
+		case "i":
+			wrapMarkdown(b, "*", node.children)
+		case "s":
+			wrapMarkdown(b, "~~", node.children)
+		case "u":
+			// Markdown has no underline, Discourse accepts the BBCode tag
+			b.WriteString("[u]")
+			renderMarkdown(b, node.children)
+			b.WriteString("[/u]")
+		case "url":
+			if node.option == "" {
+				b.WriteString(plainText(node.children))
+				continue
+			}
+			b.WriteString("[")
+			renderMarkdown(b, node.children)
+			fmt.Fprintf(b, "](%s)", node.option)
+		case "img":
+			fmt.Fprintf(b, "![](%s)", strings.TrimSpace(plainText(node.children)))
+		case "spoiler":
+			title := node.option
+			if title == "" {
+				title = "Spoiler"
+			}
+			fmt.Fprintf(b, "[details=\"%s\"]\n", strings.ReplaceAll(title, `"`, "'"))
+			renderMarkdown(b, node.children)
+			b.WriteString("\n[/details]")
+		case "quote":
+			b.WriteString("[quote]\n")
+			renderMarkdown(b, node.children)
+			b.WriteString("\n[/quote]")
+		case "code":
+			startLine(b)
+			fmt.Fprintf(b, "```\n%s\n```\n", strings.Trim(plainText(node.children), "\n"))
+		case "hr":
+			b.WriteString("\n---\n")
+		case "*":
+			startLine(b)
+			b.WriteString("- ")
+			renderMarkdown(b, node.children)
+		default:
+			// Alignment, size, color and font have no Markdown equivalent
+			renderMarkdown(b, node.children)
+		}
+	}
+}
+
+// startLine begins a new line unless the output is already at the start of one, as Markdown
+// blocks only start on their own line
+func startLine(b *strings.Builder) {
+	if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
+	}
+}
+
+func wrapMarkdown(b *strings.Builder, marker string, children []*bbNode) {
+	b.WriteString(marker)
+	renderMarkdown(b, children)
+	b.WriteString(marker)
+}
+
+func renderIPB(b *strings.Builder, nodes []*bbNode) {
+	for _, node := range nodes {
+		switch node.tag {
+		case "":
+			b.WriteString(strings.ReplaceAll(html.EscapeString(node.text), "\n", "<br>\n"))
+		case "b":
+			wrapHTML(b, "<strong>", "</strong>", node.children)
+		case "i":
+			wrapHTML(b, "<em>", "</em>", node.children)
+		case "u":
+			wrapHTML(b, "<u>", "</u>", node.children)
+		case "s":
+			wrapHTML(b, "<s>", "</s>", node.children)
+		case "url":
+			href := node.option
+			if href == "" {
+				href = plainText(node.children)
+			}
+			wrapHTML(b, fmt.Sprintf(`<a href="%s" rel="external nofollow">`, html.EscapeString(href)), "</a>", node.children)
+		case "img":
+			fmt.Fprintf(b, `<img class="ipsImage" src="%s" alt="">`, html.EscapeString(strings.TrimSpace(plainText(node.children))))
+		case "spoiler":
+			b.WriteString(`<div class="ipsSpoiler" data-ipsspoiler="">`)
+			if node.option != "" {
+				fmt.Fprintf(b, `<div class="ipsSpoiler_header"><span>%s</span></div>`, html.EscapeString(node.option))
+			}
+			wrapHTML(b, `<div class="ipsSpoiler_contents">`, "</div></div>", node.children)
+		case "quote":
+			wrapHTML(b, `<blockquote class="ipsQuote" data-ipsquote="">`, "</blockquote>", node.children)
+		case "code":
+			fmt.Fprintf(b, `<pre class="ipsCode">%s</pre>`, html.EscapeString(strings.Trim(plainText(node.children), "\n")))
+		case "center", "left", "right":
+			wrapHTML(b, fmt.Sprintf(`<p style="text-align:%s;">`, node.tag), "</p>", node.children)
+		case "size":
+			wrapHTML(b, fmt.Sprintf(`<span style="font-size:%s;">`, html.EscapeString(cssFontSize(node.option))), "</span>", node.children)
+		case "color":
+			wrapHTML(b, fmt.Sprintf(`<span style="color:%s;">`, html.EscapeString(node.option)), "</span>", node.children)
+		case "font":
+			wrapHTML(b, fmt.Sprintf(`<span style="font-family:%s;">`, html.EscapeString(node.option)), "</span>", node.children)
+		case "hr":
+			b.WriteString("<hr>")
+		case "list":
+			wrapHTML(b, "<ul>", "</ul>", node.children)
+		case "*":
+			wrapHTML(b, "<li>", "</li>", node.children)
+		}
+	}
+}
+
+func wrapHTML(b *strings.Builder, open, close string, children []*bbNode) {
+	b.WriteString(open)
+	renderIPB(b, children)
+	b.WriteString(close)
+}
+
+// cssFontSize maps a BBCode size, a pixel or point value or a 1-7 step, to CSS
+func cssFontSize(size string) string {
+	steps := map[string]string{"1": "10px", "2": "13px", "3": "16px", "4": "18px", "5": "24px", "6": "32px", "7": "48px"}
+	if css, ok := steps[size]; ok {
+		return css
+	}
+	if strings.TrimLeft(size, "0123456789") == "" && size != "" {
+		return size + "px"
+	}
+	return size
+}
+
+// outputFormat returns the output format of the current preset
+func (s *SpoilerService) outputFormat() string {
+	if preset, ok := s.currentPreset(); ok && preset.OutputFormat != "" {
+		return preset.OutputFormat
+	}
+	return OutputFormatBBCode
+}
+
+// SetPresetOutputFormat selects whether a preset renders BBCode, Discourse Markdown or IPB HTML
+func (s *SpoilerService) SetPresetOutputFormat(presetID string, format string) error {
+	if !isValidOutputFormat(format) {
+		return fmt.Errorf("unknown output format %q", format)
+	}
+	return s.configManager.updatePreset(presetID, func(p *TemplatePreset) {
+		p.OutputFormat = format
+	})
+}
@@ -0,0 +1,269 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var mediainfoMissingOnce sync.Once
+
+// mediainfoTrackPrefixes maps mediainfo track types to their placeholder prefix
+var mediainfoTrackPrefixes = map[string]string{
+	"General": "MI_GENERAL",
+	"Video":   "MI_VIDEO",
+	"Audio":   "MI_AUDIO",
+}
+
+// AnalyzerCapabilities reports which external media tools are installed
+type AnalyzerCapabilities struct {
+	MediaInfo      bool   `json:"mediainfo"`
+	FFprobe        bool   `json:"ffprobe"`
+	FFmpeg         bool   `json:"ffmpeg"`
+	MTN            bool   `json:"mtn"`
+	MetadataSource string `json:"metadataSource"` // Tool used for %MI_...% fields: "mediainfo", "ffprobe" or empty
+}
+
+// DetectAnalyzers looks up the media tools in the tools directory and PATH
+func DetectAnalyzers() AnalyzerCapabilities {
+	caps := AnalyzerCapabilities{
+		MediaInfo: toolInstalled("mediainfo"),
+		FFprobe:   toolInstalled("ffprobe"),
+		FFmpeg:    toolInstalled("ffmpeg"),
+		MTN:       toolInstalled("mtn"),
+	}
+	switch {
+	case caps.MediaInfo:
+		caps.MetadataSource = "mediainfo"
+	case caps.FFprobe:
+		caps.MetadataSource = "ffprobe"
+	}
+	return caps
+}
+
+// GetAnalyzerCapabilities reports the available media analyzers to the frontend
+func (s *SpoilerService) GetAnalyzerCapabilities() AnalyzerCapabilities {
+	return DetectAnalyzers()
+}
+
+// GetMediaInfoFields returns every General, Video and Audio field as placeholders, e.g.
+// BitDepth of the first video track becomes %MI_VIDEO_BIT_DEPTH%. Further tracks of a type
+// are numbered: %MI_AUDIO_2_FORMAT%. Every field of every track is also kept under its
+// original name, namespaced by track: %General.Encoded_Application%, %Audio2.Format%.
+// mediainfo is used when installed, ffprobe otherwise.
+func GetMediaInfoFields(filePath string) (map[string]string, error) {
+	if !toolInstalled("mediainfo") {
+		mediainfoMissingOnce.Do(func() {
+			log.Printf("mediainfo not found, reading %%MI_...%% fields with ffprobe")
+		})
+		return getFFprobeFields(filePath)
+	}
+
+	output, err := exec.Command(toolPath("mediainfo"), "--Output=JSON", filePath).Output()
+	if err != nil {
+		return nil, fmt.Errorf("mediainfo failed: %v", err)
+	}
+
+	var result struct {
+		Media struct {
+			Track []map[string]any `json:"track"`
+		} `json:"media"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse mediainfo output: %v", err)
+	}
+
+	fields := make(map[string]string)
+	prefixes := newTrackPrefixer()
+	namespaces := newTrackNamespacer()
+	for _, track := range result.Media.Track {
+		trackType, _ := track["@type"].(string)
+		namespace := namespaces.next(trackType)
+		prefix, hasPrefix := prefixes.next(trackType)
+
+		addField := func(key, text string) {
+			fields["%"+namespace+"."+key+"%"] = text
+			if hasPrefix {
+				fields["%"+prefix+"_"+placeholderKey(key)+"%"] = text
+			}
+		}
+		for key, value := range track {
+			if strings.HasPrefix(key, "@") {
+				continue
+			}
+			switch v := value.(type) {
+			case string:
+				if v != "" {
+					addField(key, v)
+				}
+			case map[string]any:
+				// Extra fields mediainfo has no standard name for, like the encoder settings
+				for extraKey, extraValue := range v {
+					if text, ok := extraValue.(string); ok && text != "" {
+						fields["%"+namespace+"."+extraKey+"%"] = text
+					}
+				}
+			}
+		}
+	}
+	return fields, nil
+}
+
+// ffprobeFieldAliases maps ffprobe field names to the differing mediainfo names templates use,
+// so the common %MI_...% placeholders work with either analyzer
+var ffprobeFieldAliases = map[string]string{
+	"format_long_name":     "Format",
+	"codec_name":           "Format",
+	"profile":              "Format_Profile",
+	"size":                 "FileSize",
+	"display_aspect_ratio": "DisplayAspectRatio",
+	"avg_frame_rate":       "FrameRate",
+	"bits_per_raw_sample":  "BitDepth",
+	"color_primaries":      "colour_primaries",
+	"color_transfer":       "transfer_characteristics",
+	"color_range":          "colour_range",
+	"sample_rate":          "SamplingRate",
+}
+
+// getFFprobeFields is the ffprobe fallback of GetMediaInfoFields. Fields are available
+// under their ffprobe names and, where one exists, their mediainfo alias.
+func getFFprobeFields(filePath string) (map[string]string, error) {
+	output, err := exec.Command(toolPath("ffprobe"),
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		filePath,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %v", err)
+	}
+
+	var result struct {
+		Format  map[string]any   `json:"format"`
+		Streams []map[string]any `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+
+	fields := make(map[string]string)
+	addTrack := func(prefix, namespace string, track map[string]any) {
+		for key, value := range track {
+			var text string
+			switch v := value.(type) {
+			case string:
+				text = v
+			case float64:
+				text = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				continue // Tags and dispositions are nested objects
+			}
+			if text == "" {
+				continue
+			}
+			names := []string{key}
+			if alias, ok := ffprobeFieldAliases[key]; ok {
+				names = append(names, alias)
+			}
+			for _, name := range names {
+				fields["%"+namespace+"."+name+"%"] = text
+				if prefix != "" {
+					fields["%"+prefix+"_"+placeholderKey(name)+"%"] = text
+				}
+			}
+		}
+	}
+
+	addTrack(mediainfoTrackPrefixes["General"], "General", result.Format)
+
+	prefixes := newTrackPrefixer()
+	namespaces := newTrackNamespacer()
+	for _, stream := range result.Streams {
+		codecType, _ := stream["codec_type"].(string)
+		trackType := map[string]string{"video": "Video", "audio": "Audio", "subtitle": "Text"}[codecType]
+		prefix, _ := prefixes.next(trackType) // Only namespaced fields for other track types
+		addTrack(prefix, namespaces.next(trackType), stream)
+
+		// ffprobe rarely reports the video bit depth directly, derive it from the pixel format
+		if pixFmt, _ := stream["pix_fmt"].(string); trackType == "Video" && pixFmt != "" {
+			if _, exists := fields["%"+prefix+"_BIT_DEPTH%"]; !exists {
+				fields["%"+prefix+"_BIT_DEPTH%"] = pixelFormatBitDepth(pixFmt)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// pixelFormatBitDepth returns the bit depth of an ffmpeg pixel format like yuv420p10le
+func pixelFormatBitDepth(pixFmt string) string {
+	for _, depth := range []string{"16", "12", "10"} {
+		if strings.Contains(pixFmt, "p"+depth) {
+			return depth
+		}
+	}
+	return "8"
+}
+
+// trackPrefixer numbers repeated tracks of the same type
+type trackPrefixer map[string]int
+
+func newTrackPrefixer() trackPrefixer {
+	return make(trackPrefixer)
+}
+
+// next returns the placeholder prefix of the next track of a type, false for unsupported types
+func (t trackPrefixer) next(trackType string) (string, bool) {
+	prefix, ok := mediainfoTrackPrefixes[trackType]
+	if !ok {
+		return "", false
+	}
+	t[trackType]++
+	if n := t[trackType]; n > 1 {
+		prefix += "_" + strconv.Itoa(n)
+	}
+	return prefix, true
+}
+
+// trackNamespacer names tracks of any type for namespaced fields, numbering repeated ones:
+// Audio, Audio2, Audio3
+type trackNamespacer map[string]int
+
+func newTrackNamespacer() trackNamespacer {
+	return make(trackNamespacer)
+}
+
+func (t trackNamespacer) next(trackType string) string {
+	if trackType == "" {
+		trackType = "Other"
+	}
+	t[trackType]++
+	if n := t[trackType]; n > 1 {
+		return trackType + strconv.Itoa(n)
+	}
+	return trackType
+}
+
+// placeholderKey converts a mediainfo field name like "BitDepth" or "HDR_Format_Compatibility"
+// into placeholder form: BIT_DEPTH, HDR_FORMAT_COMPATIBILITY
+func placeholderKey(name string) string {
+	var key strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			r = '_'
+		} else if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			key.WriteRune('_')
+		}
+		if r == '_' && strings.HasSuffix(key.String(), "_") {
+			continue
+		}
+		key.WriteRune(unicode.ToUpper(r))
+	}
+	return strings.Trim(key.String(), "_")
+}
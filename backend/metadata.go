@@ -0,0 +1,423 @@
+package backend
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	metadataTimeout   = 20 * time.Second
+	tmdbAPIURL        = "https://api.themoviedb.org/3"
+	tmdbPosterBaseURL = "https://image.tmdb.org/t/p/w500"
+	omdbAPIURL        = "https://www.omdbapi.com/"
+)
+
+var errMetadataNotFound = errors.New("no match found")
+
+// MovieMetadata is the title information of a movie looked up online
+type MovieMetadata struct {
+	Source        string `json:"source"` // Provider that matched, e.g. "tmdb"
+	Title         string `json:"title"`
+	OriginalTitle string `json:"originalTitle,omitempty"`
+	Year          string `json:"year,omitempty"`
+	Plot          string `json:"plot,omitempty"`
+	IMDbID        string `json:"imdbId,omitempty"`    // e.g. "tt0133093"
+	TMDbPath      string `json:"tmdbPath,omitempty"`  // e.g. "movie/603" or "tv/1399"
+	PosterURL     string `json:"posterUrl,omitempty"` // Poster on the provider, the uploads are in HostUploads
+}
+
+// params returns the placeholders filled from the metadata
+func (m MovieMetadata) params() map[string]string {
+	params := map[string]string{
+		"%TITLE%":          m.Title,
+		"%ORIGINAL_TITLE%": m.OriginalTitle,
+		"%YEAR%":           m.Year,
+		"%PLOT%":           m.Plot,
+		"%IMDB_ID%":        m.IMDbID,
+		"%POSTER_URL%":     m.PosterURL,
+	}
+	if m.IMDbID != "" {
+		params["%IMDB_URL%"] = "https://www.imdb.com/title/" + m.IMDbID + "/"
+	}
+	if m.TMDbPath != "" {
+		params["%TMDB_URL%"] = "https://www.themoviedb.org/" + m.TMDbPath
+	}
+	return params
+}
+
+// metadataPlaceholders are the placeholders that make processing look up metadata
+var metadataPlaceholders = []string{
+	"%TITLE%", "%ORIGINAL_TITLE%", "%YEAR%", "%PLOT%", "%IMDB_ID%", "%IMDB_URL%", "%TMDB_URL%", "%POSTER_URL%", "%POSTER_",
+}
+
+// metadataProvider looks up a title on an online database
+type metadataProvider interface {
+	Name() string
+	// Lookup returns the best match for the title, errMetadataNotFound when there is none
+	Lookup(ctx context.Context, title, year string) (*MovieMetadata, error)
+}
+
+// metadataProviders returns the providers with an API key, in lookup order
+func (s *SpoilerService) metadataProviders() []metadataProvider {
+	var providers []metadataProvider
+	if s.settings.TMDBAPIKey != "" {
+		providers = append(providers, &tmdbProvider{apiKey: s.settings.TMDBAPIKey, language: s.settings.MetadataLanguage})
+	}
+	if s.settings.OMDbAPIKey != "" {
+		providers = append(providers, &omdbProvider{apiKey: s.settings.OMDbAPIKey})
+	}
+	return providers
+}
+
+// metadataEnabled reports whether any metadata provider is configured
+func (s *SpoilerService) metadataEnabled() bool {
+	return s.settings.TMDBAPIKey != "" || s.settings.OMDbAPIKey != ""
+}
+
+// usesMetadata reports whether the current template uses looked up metadata
+func (s *SpoilerService) usesMetadata() bool {
+	template := s.currentTemplate()
+	if preset, _ := s.currentPreset(); preset.usesGoTemplate() {
+		if strings.Contains(template, "Metadata") || strings.Contains(template, "Poster") {
+			return true
+		}
+		for _, placeholder := range metadataPlaceholders {
+			if strings.Contains(template, `"`+strings.Trim(placeholder, "%")+`"`) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, placeholder := range metadataPlaceholders {
+		if strings.Contains(template, placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidMetadataLanguage accepts languages like "en" and "en-US"
+func isValidMetadataLanguage(language string) bool {
+	return metadataLanguagePattern.MatchString(language)
+}
+
+var metadataLanguagePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// metadataKeysChanged reports whether a metadata API key differs between the settings
+func metadataKeysChanged(current, updated AppSettings) bool {
+	return current.TMDBAPIKey != updated.TMDBAPIKey || current.OMDbAPIKey != updated.OMDbAPIKey
+}
+
+// startMetadataLookup looks up the movie's metadata in the background and uploads its poster
+// to the hosts the template uses it from. The returned function waits for both. Failures are
+// warnings, the spoiler is generated without the metadata.
+func (s *SpoilerService) startMetadataLookup(movie Movie, tempDir string, uploaders []*activeUploader) func() {
+	if !s.metadataEnabled() || !s.usesMetadata() {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if movie.Metadata == nil && !s.lookupMetadata(movie) {
+			return
+		}
+		s.uploadPosters(movie.ID, tempDir, uploaders)
+	}()
+	return func() { <-done }
+}
+
+// lookupMetadata asks the providers in order for the movie's title and keeps the first match
+func (s *SpoilerService) lookupMetadata(movie Movie) bool {
+	release := ParseReleaseName(movie.FileName)
+	title, year := release.Title, release.Year
+	if title == "" {
+		return false
+	}
+
+	var failures []string
+	for _, provider := range s.metadataProviders() {
+		ctx, cancel := context.WithTimeout(s.cancelCtx, metadataTimeout)
+		metadata, err := provider.Lookup(ctx, title, year)
+		cancel()
+		if err != nil {
+			if s.cancelCtx.Err() != nil {
+				return false
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", provider.Name(), err))
+			continue
+		}
+
+		metadata.Source = provider.Name()
+		s.updateMovieByID(movie.ID, func(m *Movie) {
+			m.Metadata = metadata
+			if m.Params == nil {
+				m.Params = make(map[string]string)
+			}
+			for key, value := range metadata.params() {
+				if value != "" {
+					m.Params[key] = value
+				}
+			}
+		})
+		s.recordEvent(movie.ID, "metadata", fmt.Sprintf("Found %q (%s) on %s", metadata.Title, metadata.Year, provider.Name()), nil)
+		return true
+	}
+
+	err := errors.New(strings.Join(failures, "; "))
+	s.recordEvent(movie.ID, "metadata", fmt.Sprintf("Metadata lookup of %q", title), err)
+	s.addMovieWarning(movie.ID, fmt.Sprintf("No metadata found for %q: %v", title, err))
+	log.Printf("Metadata lookup of %s failed: %v", movie.FileName, err)
+	return false
+}
+
+// uploadPosters uploads the looked up poster to the hosts that need it and have none yet
+func (s *SpoilerService) uploadPosters(movieID, tempDir string, uploaders []*activeUploader) {
+	movie, exists := s.getMovieByID(movieID)
+	if !exists || movie.Metadata == nil || movie.Metadata.PosterURL == "" {
+		return
+	}
+
+	var hosts []*activeUploader
+	s.uploadsMu.Lock()
+	for _, uploader := range uploaders {
+		if uploads := movie.Uploads[uploader.Name()]; uploader.poster && (uploads == nil || uploads.PosterURL == "") {
+			hosts = append(hosts, uploader)
+		}
+	}
+	s.uploadsMu.Unlock()
+	if len(hosts) == 0 {
+		return
+	}
+
+	posterPath := filepath.Join(tempDir, movieID+"_poster.jpg")
+	if parsed, err := url.Parse(movie.Metadata.PosterURL); err == nil && path.Ext(parsed.Path) != "" {
+		posterPath = filepath.Join(tempDir, movieID+"_poster"+path.Ext(parsed.Path))
+	}
+	ctx, cancel := context.WithTimeout(s.cancelCtx, metadataTimeout)
+	err := saveImage(ctx, movie.Metadata.PosterURL, posterPath)
+	cancel()
+	if err != nil {
+		s.recordEvent(movieID, "metadata", "Poster download", err)
+		s.addMovieWarning(movieID, fmt.Sprintf("Poster download failed: %v", err))
+		return
+	}
+	defer os.Remove(posterPath)
+
+	fileName := s.uploadFileName(sanitizeFileName(movie.Metadata.Title) + "_poster" + filepath.Ext(posterPath))
+	for _, uploader := range hosts {
+		if !s.acquireUploadSlot(uploader) {
+			return
+		}
+		result, reused, err := s.uploadOnce(uploader, posterPath, fileName, nil)
+		<-uploader.slots
+
+		label := hostLabel(uploader.Name()) + " poster upload"
+		s.queue.recordUpload(uploader.Name(), err)
+		s.recordEvent(movieID, "upload", uploadEventMessage(label, reused), err)
+		if err != nil {
+			s.addMovieWarning(movieID, fmt.Sprintf("%s failed: %v", label, err))
+			continue
+		}
+		s.updateHostUploads(movieID, uploader.Name(), func(h *HostUploads) {
+			h.setPoster(*result, s.bbCodeDialect())
+		})
+	}
+}
+
+// tmdbProvider looks up movies and series on The Movie Database
+type tmdbProvider struct {
+	apiKey   string
+	language string
+}
+
+func (p *tmdbProvider) Name() string { return "tmdb" }
+
+func (p *tmdbProvider) Lookup(ctx context.Context, title, year string) (*MovieMetadata, error) {
+	kind, id, err := p.search(ctx, title, year)
+	if err != nil {
+		return nil, err
+	}
+
+	var details struct {
+		Title         string `json:"title"`
+		Name          string `json:"name"`
+		OriginalTitle string `json:"original_title"`
+		OriginalName  string `json:"original_name"`
+		ReleaseDate   string `json:"release_date"`
+		FirstAirDate  string `json:"first_air_date"`
+		Overview      string `json:"overview"`
+		PosterPath    string `json:"poster_path"`
+		ExternalIDs   struct {
+			IMDbID string `json:"imdb_id"`
+		} `json:"external_ids"`
+	}
+	query := url.Values{"append_to_response": {"external_ids"}}
+	if err := p.get(ctx, "/"+kind+"/"+strconv.Itoa(id), query, &details); err != nil {
+		return nil, err
+	}
+
+	metadata := &MovieMetadata{
+		Title:         cmp.Or(details.Title, details.Name),
+		OriginalTitle: cmp.Or(details.OriginalTitle, details.OriginalName),
+		Year:          releaseYear(cmp.Or(details.ReleaseDate, details.FirstAirDate)),
+		Plot:          details.Overview,
+		IMDbID:        details.ExternalIDs.IMDbID,
+		TMDbPath:      kind + "/" + strconv.Itoa(id),
+	}
+	if details.PosterPath != "" {
+		metadata.PosterURL = tmdbPosterBaseURL + details.PosterPath
+	}
+	return metadata, nil
+}
+
+// search returns the kind ("movie" or "tv") and ID of the best match, preferring movies
+func (p *tmdbProvider) search(ctx context.Context, title, year string) (string, int, error) {
+	for _, kind := range []string{"movie", "tv"} {
+		query := url.Values{"query": {title}}
+		if year != "" {
+			if kind == "movie" {
+				query.Set("year", year)
+			} else {
+				query.Set("first_air_date_year", year)
+			}
+		}
+
+		var results struct {
+			Results []struct {
+				ID int `json:"id"`
+			} `json:"results"`
+		}
+		if err := p.get(ctx, "/search/"+kind, query, &results); err != nil {
+			return "", 0, err
+		}
+		if len(results.Results) > 0 {
+			return kind, results.Results[0].ID, nil
+		}
+	}
+	return "", 0, errMetadataNotFound
+}
+
+// get calls the TMDB API. Read access tokens are sent as bearer tokens, API keys as a parameter.
+func (p *tmdbProvider) get(ctx context.Context, endpoint string, query url.Values, out any) error {
+	if p.language != "" {
+		query.Set("language", p.language)
+	}
+	bearer := strings.HasPrefix(p.apiKey, "eyJ")
+	if !bearer {
+		query.Set("api_key", p.apiKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tmdbAPIURL+endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	if bearer {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	return fetchMetadataJSON(req, out)
+}
+
+// omdbProvider looks up IMDb titles through the OMDb API
+type omdbProvider struct {
+	apiKey string
+}
+
+func (p *omdbProvider) Name() string { return "omdb" }
+
+func (p *omdbProvider) Lookup(ctx context.Context, title, year string) (*MovieMetadata, error) {
+	query := url.Values{"apikey": {p.apiKey}, "t": {title}, "plot": {"short"}}
+	if year != "" {
+		query.Set("y", year)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, omdbAPIURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	var result struct {
+		Response string `json:"Response"`
+		Error    string `json:"Error"`
+		Title    string `json:"Title"`
+		Year     string `json:"Year"`
+		Plot     string `json:"Plot"`
+		Poster   string `json:"Poster"`
+		IMDbID   string `json:"imdbID"`
+	}
+	if err := fetchMetadataJSON(req, &result); err != nil {
+		return nil, err
+	}
+	if result.Response != "True" {
+		if strings.Contains(strings.ToLower(result.Error), "not found") {
+			return nil, errMetadataNotFound
+		}
+		return nil, fmt.Errorf("OMDb error: %s", result.Error)
+	}
+
+	metadata := &MovieMetadata{
+		Title:     result.Title,
+		Year:      releaseYear(result.Year),
+		Plot:      omdbValue(result.Plot),
+		IMDbID:    result.IMDbID,
+		PosterURL: omdbValue(result.Poster),
+	}
+	return metadata, nil
+}
+
+// omdbValue returns an OMDb field, which uses "N/A" for missing values
+func omdbValue(value string) string {
+	if value == "N/A" {
+		return ""
+	}
+	return value
+}
+
+// fetchMetadataJSON sends a metadata API request and decodes the JSON response into out
+func fetchMetadataJSON(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The URL holds the API key, report only the cause
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("invalid API key")
+	case resp.StatusCode == http.StatusNotFound:
+		return errMetadataNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %v", err)
+	}
+	return nil
+}
+
+// releaseYear returns the year of a date like "1999-03-30" or a range like "2010–2013"
+func releaseYear(date string) string {
+	if len(date) >= 4 && releaseYearPattern.MatchString(date[:4]) {
+		return date[:4]
+	}
+	return ""
+}
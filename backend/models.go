@@ -5,6 +5,20 @@ type TemplatePreset struct {
 	ID       string `json:"id" koanf:"id"`
 	Name     string `json:"name" koanf:"name"`
 	Template string `json:"template" koanf:"template"`
+	// Template syntax, TemplateModePlaceholders when empty
+	TemplateMode string `json:"templateMode,omitempty" koanf:"template_mode"`
+	// Markup the rendered BBCode is converted to, OutputFormatBBCode when empty
+	OutputFormat string `json:"outputFormat,omitempty" koanf:"output_format"`
+	// BBCode dialect of the upload placeholders, the global setting when empty
+	BBCodeDialect string `json:"bbCodeDialect,omitempty" koanf:"bbcode_dialect"`
+	// Collapse runs of blank lines left behind by empty placeholders
+	CollapseBlankLines bool `json:"collapseBlankLines,omitempty" koanf:"collapse_blank_lines"`
+	// Per-preset overrides, nil uses the global setting
+	ImgboxFamilySafe *bool      `json:"imgboxFamilySafe,omitempty" koanf:"imgbox_family_safe"`
+	Watermark        *Watermark `json:"watermark,omitempty" koanf:"watermark"`
+	MtnArgs          *string    `json:"mtnArgs,omitempty" koanf:"mtn_args"`
+	ScreenshotCount  *int       `json:"screenshotCount,omitempty" koanf:"screenshot_count"`
+	SheetWidth       *int       `json:"sheetWidth,omitempty" koanf:"sheet_width"` // Contact sheet width, replaces -w of the MTN arguments
 }
 
 // Movie represents a media file with its metadata
@@ -15,7 +29,7 @@ type Movie struct {
 	FileSize          string  `json:"fileSize"`
 	FileSizeBytes     int64   `json:"fileSizeBytes"`
 	DurationFormatted string  `json:"duration"`
-	Duration          float64 `json:"videDduration"` // for screenshot generation
+	DurationSeconds   float64 `json:"durationSeconds"` // for screenshot generation, 0 if unknown
 	Width             string  `json:"width"`
 	Height            string  `json:"height"`
 	BitRate           string  `json:"bitRate"`
@@ -24,29 +38,42 @@ type Movie struct {
 	VideoCodec        string  `json:"videoCodec"`
 	AudioCodec        string  `json:"audioCodec"`
 
-	// Fastpic URLs
-	ContactSheetURL    string   `json:"contactSheetUrl"`    // MTN-generated contact sheet (small)
-	ContactSheetBigURL string   `json:"contactSheetBigUrl"` // MTN-generated contact sheet (big)
-	ScreenshotURLs     []string `json:"screenshotUrls"`     // Individual screenshots (small)
-	ScreenshotBigURLs  []string `json:"screenshotBigUrls"`  // Individual screenshots (big)
-	ScreenshotAlbum    string   `json:"screenshotAlbum"`    // Album link
-
-	// Imgbox Results - Renamed from Thumbnail* to ContactSheet*
-	ContactSheetURLIB    string   `json:"contactSheetUrlIb"`    // MTN-generated contact sheet (small)
-	ContactSheetBigURLIB string   `json:"contactSheetBigUrlIb"` // MTN-generated contact sheet (big)
-	ScreenshotURLsIB     []string `json:"screenshotUrlsIb"`     // Individual screenshots (small)
-	ScreenshotBigURLsIB  []string `json:"screenshotBigUrlsIb"`  // Individual screenshots (big)
-
-	// Hamster Results
-	ContactSheetURLHam    string   `json:"contactSheetUrlHam"`    // MTN-generated contact sheet (small)
-	ContactSheetBigURLHam string   `json:"contactSheetBigUrlHam"` // MTN-generated contact sheet (big)
-	ScreenshotURLsHam     []string `json:"screenshotUrlsHam"`     // Individual screenshots (small)
-	ScreenshotBigURLsHam  []string `json:"screenshotBigUrlsHam"`  // Individual screenshots (big)
+	// Numeric values of the display strings above for templates and rules, 0 if unknown
+	BitRateKbps      float64 `json:"bitRateKbps"`
+	VideoBitRateKbps float64 `json:"videoBitRateKbps"`
+	AudioBitRateKbps float64 `json:"audioBitRateKbps"`
+	FPS              float64 `json:"fps"`
 
+	// Upload results per image host, keyed by uploader name
+	Uploads map[string]*HostUploads `json:"uploads"`
+
+	GroupID         string            `json:"groupId,omitempty"` // Manual group the movie belongs to
+	Priority        int               `json:"priority"`          // User-defined priority, higher runs first in priority order
 	Params          map[string]string `json:"params"`
 	ProcessingState ProcessingState   `json:"processingState"`           // State constants defined below
 	ProcessingError string            `json:"processingError,omitempty"` // Error details if processing fails
-	Errors          []string          `json:"errors,omitempty"`          // Individual errors that occurred during processing
+	Errors          []string          `json:"errors,omitempty"`          // Media that failed to generate, upload or save
+	Warnings        []string          `json:"warnings,omitempty"`        // Advisories that leave the output complete
+
+	Segments          []string           `json:"segments,omitempty"`          // Parts of a split movie in order, FilePath is the first
+	ExternalSubtitles []ExternalSubtitle `json:"externalSubtitles,omitempty"` // Sidecar subtitle files
+	AudioTracks       []MediaTrack       `json:"audioTracks,omitempty"`       // Every audio stream, AudioCodec and the bitrates describe one
+	SubtitleTracks    []MediaTrack       `json:"subtitleTracks,omitempty"`    // Embedded subtitle streams
+	HDRFormat         string             `json:"hdrFormat,omitempty"`         // e.g. "Dolby Vision Profile 8.1, HDR10", empty for SDR, see detectHDRFormat
+	Fingerprint       string             `json:"fingerprint,omitempty"`       // Size and partial content hash, see fileFingerprint
+	PreviousRun       *PreviousRun       `json:"previousRun,omitempty"`       // Set when the file was processed before
+	Imported          bool               `json:"imported,omitempty"`          // Rebuilt from posted BBCode, there is no source file
+	FrameHashes       []string           `json:"frameHashes,omitempty"`       // Hex difference hash per screenshot, empty for flat frames
+	LocalContactSheet string             `json:"localContactSheet,omitempty"` // Copy in the local output directory
+	LocalScreenshots  []string           `json:"localScreenshots,omitempty"`  // Copies in the local output directory by position
+	DeadLinks         []DeadLink         `json:"deadLinks,omitempty"`         // Images the hosts no longer serve, see VerifyLinks
+	Metadata          *MovieMetadata     `json:"metadata,omitempty"`          // Title, plot and poster looked up online, see lookupMetadata
+	NFOPath           string             `json:"nfoPath,omitempty"`           // Release .nfo file next to the video, see findNFO
+	NFO               string             `json:"nfo,omitempty"`               // Its text decoded from CP437 and cleaned up
+	Notes             string             `json:"notes,omitempty"`             // Free-form note of the user, see SetMovieNote
+	Checked           []string           `json:"checked,omitempty"`           // Checked items of the configured checklist
+	Torrent           *TorrentInfo       `json:"torrent,omitempty"`           // Dropped .torrent the file belongs to
+	CancelInfo        *CancelInfo        `json:"cancelInfo,omitempty"`        // How far the last run got before it was cancelled
 }
 
 // Processing state constants
@@ -60,13 +87,88 @@ const (
 	StateWaitingForUploadSlot     ProcessingState = "waiting_for_upload_slot"
 	StateUploadingScreenshots     ProcessingState = "uploading_screenshots"
 	StateCompleted                ProcessingState = "completed"
+	StateCompletedWithWarnings    ProcessingState = "completed_with_warnings" // Finished, but some media failed or a warning was raised
 	StateError                    ProcessingState = "error"
 )
 
+// AllProcessingStates lists every valid processing state
+var AllProcessingStates = []ProcessingState{
+	StatePending,
+	StateAnalyzingMedia,
+	StateWaitingForScreenshotSlot,
+	StateGeneratingScreenshots,
+	StateWaitingForUploadSlot,
+	StateUploadingScreenshots,
+	StateCompleted,
+	StateCompletedWithWarnings,
+	StateError,
+}
+
+// processingTransitions lists the allowed forward transitions for each state.
+// Resetting to pending and failing with an error are allowed from any state.
+var processingTransitions = map[ProcessingState][]ProcessingState{
+	StatePending:                  {StateAnalyzingMedia, StateWaitingForScreenshotSlot, StateCompleted}, // Completed when the previous run's results are imported
+	StateAnalyzingMedia:           {},
+	StateWaitingForScreenshotSlot: {StateGeneratingScreenshots, StateWaitingForUploadSlot, StateCompleted, StateCompletedWithWarnings}, // Completed when an earlier run left nothing to do
+	StateGeneratingScreenshots:    {StateWaitingForUploadSlot, StateUploadingScreenshots, StateCompleted, StateCompletedWithWarnings},  // Pipelined mode uploads while generating, or has nothing to upload with local output only
+	StateWaitingForUploadSlot:     {StateUploadingScreenshots, StateCompleted, StateCompletedWithWarnings},
+	StateUploadingScreenshots:     {StateCompleted, StateCompletedWithWarnings},
+	StateCompleted:                {},
+	StateCompletedWithWarnings:    {},
+	StateError:                    {},
+}
+
+// IsValid reports whether the state is one of the known processing states
+func (p ProcessingState) IsValid() bool {
+	_, ok := processingTransitions[p]
+	return ok
+}
+
+// IsCompleted reports whether processing finished, with or without warnings
+func (p ProcessingState) IsCompleted() bool {
+	return p == StateCompleted || p == StateCompletedWithWarnings
+}
+
+// IsFinished reports whether processing completed or failed
+func (p ProcessingState) IsFinished() bool {
+	return p.IsCompleted() || p == StateError
+}
+
+// CanTransitionTo reports whether a movie may move from this state to next
+func (p ProcessingState) CanTransitionTo(next ProcessingState) bool {
+	if !p.IsValid() || !next.IsValid() {
+		return false
+	}
+	if p == next || next == StatePending || next == StateError {
+		return true
+	}
+	for _, allowed := range processingTransitions[p] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 // AppState represents the current application state
 type AppState struct {
-	Processing bool    `json:"processing"`
-	Movies     []Movie `json:"movies"`
+	Processing      bool         `json:"processing"`
+	Movies          []Movie      `json:"movies"`
+	Groups          []MovieGroup `json:"groups"`
+	ConfigLocked    bool         `json:"configLocked"`    // Encrypted host logins wait for the passphrase, see UnlockConfig
+	ConfigEncrypted bool         `json:"configEncrypted"` // Host logins are saved encrypted
+	Summary         StateSummary `json:"summary"`
+}
+
+// StateSummary counts the movies per outcome and their issues
+type StateSummary struct {
+	Total                 int `json:"total"`
+	Completed             int `json:"completed"`
+	CompletedWithWarnings int `json:"completedWithWarnings"`
+	Failed                int `json:"failed"`
+	Cancelled             int `json:"cancelled"` // Left pending by a cancelled run, see CancelInfo
+	Warnings              int `json:"warnings"`  // Over all movies
+	Errors                int `json:"errors"`    // Over all movies, without the failures of failed movies
 }
 
 // MediaInfo represents extracted media information
@@ -74,20 +176,92 @@ type MediaInfo struct {
 	General map[string]string `json:"general"`
 	Video   map[string]string `json:"video"`
 	Audio   map[string]string `json:"audio"`
+
+	AudioTracks    []MediaTrack `json:"audioTracks,omitempty"` // Every audio stream in file order
+	SubtitleTracks []MediaTrack `json:"subtitleTracks,omitempty"`
 }
 
 // AppSettings represents application settings
 type AppSettings struct {
-	ScreenshotCount          int    `json:"screenshotCount"`
-	FastpicSID               string `json:"fastpicSid"`
-	ScreenshotQuality        int    `json:"screenshotQuality"`
-	MaxConcurrentScreenshots int    `json:"maxConcurrentScreenshots"` // Max parallel screenshot generation
-	MaxConcurrentUploads     int    `json:"maxConcurrentUploads"`     // Max parallel uploads
-	MtnArgs                  string `json:"mtnArgs"`                  // MTN command line arguments
-	ImageMiniatureSize       int    `json:"imageMiniatureSize"`
+	ScreenshotCount            int    `json:"screenshotCount"`
+	FastpicSID                 string `json:"fastpicSid"`
+	ScreenshotQuality          int    `json:"screenshotQuality"`
+	MaxConcurrentScreenshots   int    `json:"maxConcurrentScreenshots"`   // Max parallel screenshot extraction (ffmpeg)
+	MaxConcurrentContactSheets int    `json:"maxConcurrentContactSheets"` // Max parallel contact sheet generation (mtn), limited apart from screenshots as it is much heavier
+	MaxConcurrentUploads       int    `json:"maxConcurrentUploads"`       // Max parallel uploads per host without a HostUploadLimits entry
+	MtnArgs                    string `json:"mtnArgs"`                    // MTN command line arguments
+	ImageMiniatureSize         int    `json:"imageMiniatureSize"`
+	// Fastpic upload options
+	FastpicDeleteAfterDays int      `json:"fastpicDeleteAfterDays"` // 0 keeps images forever
+	FastpicOrigResize      int      `json:"fastpicOrigResize"`      // Server-side resize width, 0 disables
+	FastpicOptimization    bool     `json:"fastpicOptimization"`
+	FastpicBaseURL         string   `json:"fastpicBaseUrl"` // Preferred fastpic domain
+	FastpicMirrors         []string `json:"fastpicMirrors"` // Fallback domains probed when the preferred one is down
+	// Per-host thumbnail sizes, 0 means use ImageMiniatureSize
+	FastpicMiniatureSize      int            `json:"fastpicMiniatureSize"`
+	ImgboxMiniatureSize       int            `json:"imgboxMiniatureSize"`
+	AnonymizeUploads          bool           `json:"anonymizeUploads"`          // Upload images under random file names
+	RenamePattern             string         `json:"renamePattern"`             // Pattern for renaming source files, e.g. "%BASE_NAME% [%WIDTH%p]"
+	ReadOnlySources           bool           `json:"readOnlySources"`           // Never write anything into source directories
+	GroupHeaderTemplate       string         `json:"groupHeaderTemplate"`       // Heading rendered before each group, supports %GROUP_NAME%, %GROUP_COUNT% and %GROUP_SIZE%
+	NestGroupSpoilers         bool           `json:"nestGroupSpoilers"`         // Wrap each group in an outer collection spoiler
+	CollectionSpoilerTemplate string         `json:"collectionSpoilerTemplate"` // Outer spoiler template, %GROUP_CONTENT% marks the movie spoilers
+	OutputLineEnding          string         `json:"outputLineEnding"`          // "lf" or "crlf"
+	OutputBOM                 bool           `json:"outputBom"`                 // Prepend a UTF-8 BOM to exported files
+	SpoilerTitleMaxLength     int            `json:"spoilerTitleMaxLength"`     // Max characters in spoiler titles, 0 for no limit
+	PipelinedUploads          bool           `json:"pipelinedUploads"`          // Upload each image as soon as it is generated
+	ProcessingOrder           string         `json:"processingOrder"`           // "list", "smallest", "shortest" or "priority"
+	ImgboxFamilySafe          bool           `json:"imgboxFamilySafe"`          // Mark imgbox uploads as family safe instead of adult, presets may override
+	CatboxUserHash            string         `json:"catboxUserHash"`            // Optional Catbox account hash
+	CatboxTemporary           bool           `json:"catboxTemporary"`           // Upload to Litterbox, files expire after LitterboxExpiry
+	LitterboxExpiry           string         `json:"litterboxExpiry"`           // "1h", "12h", "24h" or "72h"
+	BatchHeaderTemplate       string         `json:"batchHeaderTemplate"`       // Rendered before all spoilers, supports %FILE_COUNT%, %TOTAL_SIZE% and %TOTAL_DURATION%
+	BatchFooterTemplate       string         `json:"batchFooterTemplate"`       // Rendered after all spoilers, same placeholders as the header
+	ComputeChecksums          bool           `json:"computeChecksums"`          // Compute %CRC32%, %MD5%, %SHA1% and %ED2K% of each file while processing
+	MaxTempUsageMB            int            `json:"maxTempUsageMb"`            // Throttles media generation once generated files use this much temp space, 0 disables
+	ScreenshotMode            string         `json:"screenshotMode"`            // "uniform" spreads screenshots evenly, "smart" avoids black frames, intro and credits
+	ScreenshotJitterSeconds   int            `json:"screenshotJitterSeconds"`   // Moves each screenshot randomly by up to this many seconds, 0 disables
+	ScreenshotStartOffset     string         `json:"screenshotStartOffset"`     // Skipped start of the video for screenshots, "5%" or seconds like "90"
+	ScreenshotEndOffset       string         `json:"screenshotEndOffset"`       // Skipped end of the video for screenshots, "5%" or seconds like "300"
+	BBCodeDialect             string         `json:"bbCodeDialect"`             // "uppercase", "lowercase", "quoted" or "thumb"
+	WatermarkText             string         `json:"watermarkText"`             // Text stamped on screenshots and contact sheets, empty for none
+	WatermarkImage            string         `json:"watermarkImage"`            // PNG overlaid on screenshots and contact sheets, empty for none
+	WatermarkPosition         string         `json:"watermarkPosition"`         // "top-left", "top-right", "bottom-left", "bottom-right" or "center"
+	WatermarkOpacity          int            `json:"watermarkOpacity"`          // Watermark opacity in percent
+	ScreenshotFormat          string         `json:"screenshotFormat"`          // "jpeg", "png" or "webp"
+	WebPQuality               int            `json:"webpQuality"`               // Lossy WebP quality, 0-100
+	WebPLossless              bool           `json:"webpLossless"`              // Encode WebP screenshots losslessly
+	HostUploadLimits          map[string]int `json:"hostUploadLimits"`          // Parallel uploads per host name, hosts not listed use MaxConcurrentUploads
+	ResultFooterEnabled       bool           `json:"resultFooterEnabled"`       // Append the attribution footer to the result
+	ResultFooterTemplate      string         `json:"resultFooterTemplate"`      // Footer line after all spoilers, %APP_VERSION% is the app version
+	DisableSimilarityCheck    bool           `json:"disableSimilarityCheck"`    // Skip the warning about movies with nearly identical screenshots
+	LocalOutputDir            string         `json:"localOutputDir"`            // Folder generated screenshots and contact sheets are kept in, one subfolder per movie; empty deletes them after upload
+	DisableUploadCache        bool           `json:"disableUploadCache"`        // Always generate and upload again instead of reusing the cached uploads of a video
+	TMDBAPIKey                string         `json:"tmdbApiKey"`                // TMDB API key for metadata lookups
+	OMDbAPIKey                string         `json:"omdbApiKey"`                // OMDb API key for IMDb metadata lookups
+	MetadataLanguage          string         `json:"metadataLanguage"`          // Language of looked up titles and plots, e.g. ru-RU
+	DegradeOnUploadFailures   bool           `json:"degradeOnUploadFailures"`   // Lower the screenshot count and quality for the rest of a batch once a host keeps rejecting uploads
+	DegradeAfterFailures      int            `json:"degradeAfterFailures"`      // Size or quota rejections of a host in a row that trigger the degradation
+	DegradedScreenshotCount   int            `json:"degradedScreenshotCount"`   // Screenshot count once degraded, never raises the configured count
+	DegradedQuality           int            `json:"degradedQuality"`           // Screenshot quality (1-31, lower is better) once degraded, never improves the configured quality
+	ChecklistItems            []string       `json:"checklistItems"`            // Per-movie checklist to track posting, see SetMovieChecked
+	AddTorrentVideos          bool           `json:"addTorrentVideos"`          // Add the local video files of dropped .torrent files
+	GroupByFolder             bool           `json:"groupByFolder"`             // Head ungrouped movies from different folders with their folder, like a group
 	// Hamster settings
-	HamsterEmail    string `json:"hamsterEmail"`    // Hamster.is email
-	HamsterPassword string `json:"hamsterPassword"` // Hamster.is password
+	HamsterEmail       string `json:"hamsterEmail"`       // Hamster.is email
+	HamsterPassword    string `json:"hamsterPassword"`    // Hamster.is password
+	HamsterResizeWidth int    `json:"hamsterResizeWidth"` // Server-side resize width, 0 uploads originals
+	HamsterExpiration  string `json:"hamsterExpiration"`  // Auto-delete interval such as "P1M", empty keeps images
+}
+
+// WindowState holds the persisted main window geometry and UI preferences
+type WindowState struct {
+	X         int    `json:"x" koanf:"x"`
+	Y         int    `json:"y" koanf:"y"`
+	Width     int    `json:"width" koanf:"width"`
+	Height    int    `json:"height" koanf:"height"`
+	Maximized bool   `json:"maximized" koanf:"maximized"`
+	Theme     string `json:"theme" koanf:"theme"` // "light", "dark" or "system"
 }
 
 // TemplateData represents data for template processing
@@ -0,0 +1,231 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	maxMovieHistoryEntries = 2000
+	fingerprintChunkSize   = 1 << 20 // Bytes hashed from the start and the end of a file
+)
+
+// MovieRecord is a movie that was processed before, keyed by its fingerprint
+type MovieRecord struct {
+	FilePath    string                 `json:"filePath"`
+	FileName    string                 `json:"fileName"`
+	Uploads     map[string]HostUploads `json:"uploads"`
+	ProcessedAt time.Time              `json:"processedAt"`
+}
+
+// PreviousRun flags a newly added movie that was already processed before
+type PreviousRun struct {
+	FilePath    string    `json:"filePath"`
+	ProcessedAt time.Time `json:"processedAt"`
+	MatchedBy   string    `json:"matchedBy"` // "path" or "hash"
+}
+
+// MovieHistory persists the upload results of processed movies in the config directory
+type MovieHistory struct {
+	mu     sync.Mutex
+	path   string
+	Movies map[string]MovieRecord `json:"movies"`
+}
+
+func NewMovieHistory() *MovieHistory {
+	history := &MovieHistory{
+		path:   filepath.Join(getConfigDir(), "movie_history.json"),
+		Movies: make(map[string]MovieRecord),
+	}
+	history.load()
+	return history
+}
+
+func (h *MovieHistory) load() {
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		log.Printf("Failed to parse movie history: %v", err)
+	}
+	if h.Movies == nil {
+		h.Movies = make(map[string]MovieRecord)
+	}
+}
+
+// save writes the history through a temp file so a crash never leaves it truncated
+func (h *MovieHistory) save() error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create movie history directory: %v", err)
+	}
+
+	tmpPath := h.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, h.path)
+}
+
+// Lookup finds a previous run of a file by fingerprint, or by path when the content changed
+// its fingerprint but the file was described before under the same path
+func (h *MovieHistory) Lookup(fingerprint, filePath string) (MovieRecord, string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if record, exists := h.Movies[fingerprint]; exists && fingerprint != "" {
+		if record.FilePath == filePath {
+			return record, "path", true
+		}
+		return record, "hash", true
+	}
+	for _, record := range h.Movies {
+		if record.FilePath == filePath {
+			return record, "path", true
+		}
+	}
+	return MovieRecord{}, "", false
+}
+
+// Record stores the results of a processed movie and persists the history
+func (h *MovieHistory) Record(fingerprint string, record MovieRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.Movies[fingerprint] = record
+	h.prune()
+
+	if err := h.save(); err != nil {
+		log.Printf("Failed to save movie history: %v", err)
+	}
+}
+
+// prune drops the oldest records once the history grows past its limit
+func (h *MovieHistory) prune() {
+	if len(h.Movies) <= maxMovieHistoryEntries {
+		return
+	}
+
+	keys := make([]string, 0, len(h.Movies))
+	for key := range h.Movies {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return h.Movies[keys[i]].ProcessedAt.Before(h.Movies[keys[j]].ProcessedAt)
+	})
+	for _, key := range keys[:len(keys)-maxMovieHistoryEntries] {
+		delete(h.Movies, key)
+	}
+}
+
+// fileFingerprint identifies a video by its size and the hash of its first and last
+// megabyte, cheap enough to compute for every added file
+func fileFingerprint(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	if _, err := io.CopyN(hash, file, fingerprintChunkSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	if info.Size() > 2*fingerprintChunkSize {
+		if _, err := file.Seek(-fingerprintChunkSize, io.SeekEnd); err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(hash, file); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%d:%s", info.Size(), hex.EncodeToString(hash.Sum(nil))), nil
+}
+
+// recordMovieHistory remembers the upload results of a completed movie
+func (s *SpoilerService) recordMovieHistory(movieID string) {
+	s.uploadsMu.Lock()
+	movie, exists := s.getMovieByID(movieID)
+	uploads := make(map[string]HostUploads)
+	if exists {
+		for host, result := range movie.Uploads {
+			if result != nil && result.hasResults() {
+				uploads[host] = *result
+			}
+		}
+	}
+	s.uploadsMu.Unlock()
+
+	if !exists || movie.Fingerprint == "" || len(uploads) == 0 {
+		return
+	}
+
+	s.movieHistory.Record(movie.Fingerprint, MovieRecord{
+		FilePath:    movie.FilePath,
+		FileName:    movie.FileName,
+		Uploads:     uploads,
+		ProcessedAt: time.Now(),
+	})
+}
+
+// ImportPreviousResults completes the given movies with the upload results of their previous
+// run instead of processing them again
+func (s *SpoilerService) ImportPreviousResults(movieIDs []string) error {
+	if s.processing {
+		return fmt.Errorf("processing already in progress")
+	}
+
+	for _, id := range movieIDs {
+		movie, exists := s.getMovieByID(id)
+		if !exists {
+			return fmt.Errorf("movie with ID %s not found", id)
+		}
+
+		record, _, found := s.movieHistory.Lookup(movie.Fingerprint, movie.FilePath)
+		if !found {
+			return fmt.Errorf("no previous results for %s", movie.FileName)
+		}
+
+		// The imported results replace whatever the movie went through, like a new run
+		s.uploadsMu.Lock()
+		s.transitionMovieState(id, StatePending)
+		if !s.transitionMovieState(id, StateCompleted) {
+			s.uploadsMu.Unlock()
+			return fmt.Errorf("cannot import previous results for %s while it is %s", movie.FileName, movie.ProcessingState)
+		}
+		s.updateMovieByID(id, func(m *Movie) {
+			m.Uploads = make(map[string]*HostUploads, len(record.Uploads))
+			for host, result := range record.Uploads {
+				m.Uploads[host] = &result
+			}
+			m.ProcessingError = ""
+			m.Errors = nil
+			m.Warnings = nil
+			m.DeadLinks = nil
+			m.PreviousRun = nil
+		})
+		s.uploadsMu.Unlock()
+		s.recordEvent(id, "processing", "Imported results of the previous run", nil)
+	}
+
+	s.emitState()
+	return nil
+}
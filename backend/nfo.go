@@ -0,0 +1,117 @@
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// maxNFOSize skips larger .nfo files, which are not release notes
+const maxNFOSize = 256 << 10
+
+// findNFO returns the .nfo file of a video: the one named after it, or the only one in a folder
+// holding a single video, as in a release folder. Empty when there is none.
+func findNFO(videoPath string) string {
+	dir := filepath.Dir(videoPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	base := strings.TrimSuffix(filepath.Base(videoPath), filepath.Ext(videoPath))
+	var nfos []string
+	videos := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		switch {
+		case strings.EqualFold(ext, ".nfo"):
+			if strings.EqualFold(strings.TrimSuffix(name, ext), base) {
+				return filepath.Join(dir, name)
+			}
+			nfos = append(nfos, name)
+		case releaseExtensionPattern.MatchString(ext):
+			videos++
+		}
+	}
+
+	if len(nfos) == 1 && videos == 1 {
+		return filepath.Join(dir, nfos[0])
+	}
+	return ""
+}
+
+// readNFO reads an .nfo file for posting. NFOs are usually CP437 so their ASCII art uses the
+// DOS box drawing characters, files that are valid UTF-8 are kept as they are.
+func readNFO(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > maxNFOSize {
+		return "", fmt.Errorf("%s is larger than %d KB", filepath.Base(path), maxNFOSize>>10)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		if data, err = charmap.CodePage437.NewDecoder().Bytes(data); err != nil {
+			return "", fmt.Errorf("failed to decode %s: %v", filepath.Base(path), err)
+		}
+	}
+	return cleanNFO(string(data)), nil
+}
+
+// cleanNFO normalizes line endings, drops control characters such as the DOS end of file
+// marker and removes trailing spaces and the blank lines around the text. Indentation is
+// kept, it is part of the art.
+func cleanNFO(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\n' && r != '\t' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// nfoSpoiler renders %NFO_SPOILER%, the NFO in a code block so the art keeps its monospace layout
+func nfoSpoiler(nfo string) string {
+	if nfo == "" {
+		return ""
+	}
+	// A closing tag inside the NFO would end the code block early
+	nfo = strings.ReplaceAll(nfo, "[/code]", "[/ code]")
+	return "[spoiler=\"NFO\"][code]" + nfo + "[/code][/spoiler]"
+}
+
+// replaceNFOPlaceholders replaces %NFO% and %NFO_SPOILER%. It runs after the other
+// placeholders, as NFO art often contains percent signs.
+func replaceNFOPlaceholders(template string, movie Movie) string {
+	template = strings.ReplaceAll(template, "%NFO_SPOILER%", nfoSpoiler(movie.NFO))
+	return strings.ReplaceAll(template, "%NFO%", movie.NFO)
+}
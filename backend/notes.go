@@ -0,0 +1,102 @@
+package backend
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxChecklistItems      = 20
+	maxChecklistItemLength = 40
+)
+
+// validateChecklistItems rejects checklist items that are empty, too long, or share a placeholder
+func validateChecklistItems(items []string) error {
+	if len(items) > maxChecklistItems {
+		return fmt.Errorf("the checklist can have at most %d items", maxChecklistItems)
+	}
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		name := strings.TrimSpace(item)
+		if name == "" || len([]rune(name)) > maxChecklistItemLength {
+			return fmt.Errorf("checklist items must have 1 to %d characters", maxChecklistItemLength)
+		}
+		placeholder := checklistPlaceholder(name)
+		if seen[placeholder] {
+			return fmt.Errorf("checklist item %q is listed twice", name)
+		}
+		seen[placeholder] = true
+	}
+	return nil
+}
+
+// checklistPlaceholder returns the placeholder of a checklist item, e.g. %CHECK_NFO_WRITTEN%
+func checklistPlaceholder(item string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToUpper(strings.TrimSpace(item)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return "%CHECK_" + strings.TrimSuffix(b.String(), "_") + "%"
+}
+
+// SetMovieNote sets the free-form note of a movie, e.g. "proper release", available as %NOTE%
+func (s *SpoilerService) SetMovieNote(id, text string) error {
+	if !s.updateMovieByID(id, func(m *Movie) { m.Notes = strings.TrimSpace(text) }) {
+		return fmt.Errorf("movie with ID %s not found", id)
+	}
+	s.emitState()
+	return nil
+}
+
+// SetMovieChecked checks or unchecks an item of the configured checklist for a movie
+func (s *SpoilerService) SetMovieChecked(id, item string, checked bool) error {
+	if !slices.Contains(s.settings.ChecklistItems, item) {
+		return fmt.Errorf("%q is not a checklist item", item)
+	}
+
+	found := s.updateMovieByID(id, func(m *Movie) {
+		m.Checked = slices.DeleteFunc(m.Checked, func(name string) bool { return name == item })
+		if checked {
+			m.Checked = append(m.Checked, item)
+		}
+	})
+	if !found {
+		return fmt.Errorf("movie with ID %s not found", id)
+	}
+	s.emitState()
+	return nil
+}
+
+// withNoteParams adds %NOTE% (also %NOTES%), %CHECKLIST% and a %CHECK_<ITEM>% per checklist
+// item to the movie's parameters. Checked items render as "✓", unchecked ones like missing parameters.
+func (s *SpoilerService) withNoteParams(movie Movie) Movie {
+	params := make(map[string]string, len(movie.Params)+len(s.settings.ChecklistItems)+3)
+	for key, value := range movie.Params {
+		params[key] = value
+	}
+	params["%NOTE%"] = movie.Notes
+	params["%NOTES%"] = movie.Notes
+
+	var checklist []string
+	for _, item := range s.settings.ChecklistItems {
+		if slices.Contains(movie.Checked, item) {
+			params[checklistPlaceholder(item)] = "✓"
+			checklist = append(checklist, "☑ "+item)
+		} else {
+			checklist = append(checklist, "☐ "+item)
+		}
+	}
+	params["%CHECKLIST%"] = strings.Join(checklist, "\n")
+
+	movie.Params = params
+	return movie
+}
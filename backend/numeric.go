@@ -0,0 +1,101 @@
+package backend
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberPattern finds the first number of a value, with any digit grouping and decimal separator
+var numberPattern = regexp.MustCompile(`\d[\d\s\x{00A0}\x{202F}'.,]*`)
+
+// bitRateUnits converts bit rate units to kbps, longest first so "kbps" is not read as "bps"
+var bitRateUnits = []struct {
+	unit   string
+	factor float64
+}{
+	{"gbit/s", 1e6}, {"gb/s", 1e6}, {"gbps", 1e6},
+	{"mbit/s", 1e3}, {"mb/s", 1e3}, {"mbps", 1e3},
+	{"kbit/s", 1}, {"kb/s", 1}, {"kbps", 1},
+	{"bit/s", 1e-3}, {"b/s", 1e-3}, {"bps", 1e-3},
+}
+
+// parseLocaleNumber reads a number written with any locale's separators, like "4 500",
+// "4.500,5" or "23,976". A single separator followed by exactly three digits is digit
+// grouping when groups is true and a decimal separator otherwise.
+func parseLocaleNumber(value string, groups bool) (float64, bool) {
+	match := numberPattern.FindString(value)
+	if match == "" {
+		return 0, false
+	}
+	digits := strings.NewReplacer(" ", "", "\t", "", "\u00a0", "", "\u202f", "", "'", "").Replace(strings.TrimRight(match, " \t\u00a0\u202f'.,"))
+
+	lastDot, lastComma := strings.LastIndex(digits, "."), strings.LastIndex(digits, ",")
+	decimal := max(lastDot, lastComma)
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		// Both are used, the last one separates the decimals
+	case decimal < 0:
+	case strings.Count(digits, digits[decimal:decimal+1]) > 1:
+		decimal = -1 // Repeated separator, e.g. "1.234.567"
+	case groups && len(digits)-decimal-1 == 3:
+		decimal = -1
+	}
+
+	var normalized strings.Builder
+	for i, r := range digits {
+		switch {
+		case i == decimal:
+			normalized.WriteByte('.')
+		case r >= '0' && r <= '9':
+			normalized.WriteRune(r)
+		}
+	}
+	number, err := strconv.ParseFloat(normalized.String(), 64)
+	return number, err == nil
+}
+
+// ParseBitRateKbps reads a bit rate like "4 500 kb/s", "4,5 Mbps" or a plain number of bits
+// per second as reported by ffprobe
+func ParseBitRateKbps(value string) (float64, bool) {
+	number, ok := parseLocaleNumber(value, true)
+	if !ok || number <= 0 {
+		return 0, false
+	}
+	lower := strings.ToLower(strings.Join(strings.Fields(value), ""))
+	for _, unit := range bitRateUnits {
+		if strings.Contains(lower, unit.unit) {
+			return number * unit.factor, true
+		}
+	}
+	return number / 1000, true
+}
+
+// ParseFPS reads a frame rate like "23.976", "23,976 fps" or "24000/1001"
+func ParseFPS(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if numerator, denominator, found := strings.Cut(value, "/"); found && !strings.ContainsAny(denominator, " (") {
+		if fps := parseFrameRate(strings.TrimSpace(numerator) + "/" + strings.TrimSpace(denominator)); fps > 0 {
+			return fps, true
+		}
+	}
+	fps, ok := parseLocaleNumber(value, false)
+	return fps, ok && fps > 0
+}
+
+// normalizeMediaNumbers fills the numeric media fields that are still unset from the display
+// strings, e.g. for imported movies and sessions saved before the fields existed
+func normalizeMediaNumbers(movie *Movie) {
+	if movie.BitRateKbps == 0 {
+		movie.BitRateKbps, _ = ParseBitRateKbps(movie.BitRate)
+	}
+	if movie.VideoBitRateKbps == 0 {
+		movie.VideoBitRateKbps, _ = ParseBitRateKbps(movie.VideoBitRate)
+	}
+	if movie.AudioBitRateKbps == 0 {
+		movie.AudioBitRateKbps, _ = ParseBitRateKbps(movie.AudioBitRate)
+	}
+	if movie.FPS == 0 {
+		movie.FPS, _ = ParseFPS(movie.Params["%VIDEO_FPS%"])
+	}
+}
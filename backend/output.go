@@ -0,0 +1,108 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Line ending styles for generated output
+const (
+	LineEndingLF   = "lf"
+	LineEndingCRLF = "crlf"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// spoilerTitlePattern matches a spoiler title, quoted up to the closing `"]` so bracketed
+// release names stay whole, or unquoted up to the first `]`
+var spoilerTitlePattern = regexp.MustCompile(`\[spoiler=(?:"((?:[^"\n]|"[^\]\n])*)"|([^"\]\n][^\]\n]*))\]`)
+
+var blankLinesPattern = regexp.MustCompile(`\n([ \t]*\r?\n){2,}`)
+
+// collapseBlankLines reduces every run of blank or whitespace-only lines to a single blank line
+func collapseBlankLines(text string) string {
+	return blankLinesPattern.ReplaceAllString(text, "\n\n")
+}
+
+// limitSpoilerTitles truncates every spoiler title to the configured maximum length
+func (s *SpoilerService) limitSpoilerTitles(text string) string {
+	if s.settings.SpoilerTitleMaxLength <= 0 {
+		return text
+	}
+
+	return LimitSpoilerTitles(text, s.settings.SpoilerTitleMaxLength)
+}
+
+// LimitSpoilerTitles truncates every spoiler title in text to maxLength characters
+func LimitSpoilerTitles(text string, maxLength int) string {
+	return spoilerTitlePattern.ReplaceAllStringFunc(text, func(tag string) string {
+		parts := spoilerTitlePattern.FindStringSubmatch(tag)
+		if strings.HasPrefix(tag, `[spoiler="`) {
+			return `[spoiler="` + TruncateGraphemes(parts[1], maxLength) + `"]`
+		}
+		return "[spoiler=" + TruncateGraphemes(parts[2], maxLength) + "]"
+	})
+}
+
+// applyLineEndings normalizes all line breaks to the configured style
+func (s *SpoilerService) applyLineEndings(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if s.settings.OutputLineEnding == LineEndingCRLF {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	return text
+}
+
+// ExportResult writes the generated result to a file using the configured line endings and BOM setting
+func (s *SpoilerService) ExportResult(path string) error {
+	if path == "" {
+		return fmt.Errorf("export path cannot be empty")
+	}
+
+	data := []byte(s.GenerateResult())
+	if s.settings.OutputBOM {
+		data = append(append([]byte{}, utf8BOM...), data...)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write result file: %v", err)
+	}
+	return nil
+}
+
+// renderBatchTemplate renders the batch header or footer with totals over the rendered movies
+func (s *SpoilerService) renderBatchTemplate(template string, movies []Movie) string {
+	if template == "" || len(movies) == 0 {
+		return ""
+	}
+
+	var totalSize int64
+	var totalDuration float64
+	for _, movie := range movies {
+		totalSize += movie.FileSizeBytes
+		totalDuration += movie.DurationSeconds
+	}
+
+	return replacePlaceholders(template, map[string]string{
+		"%FILE_COUNT%":     strconv.Itoa(len(movies)),
+		"%TOTAL_SIZE%":     FormatFileSize(totalSize),
+		"%TOTAL_DURATION%": FormatDuration(time.Duration(totalDuration * float64(time.Second))),
+	})
+}
+
+// DefaultResultFooterTemplate is the attribution line appended to the result when enabled
+const DefaultResultFooterTemplate = "[size=1]Generated with spoilr v%APP_VERSION%[/size]"
+
+// renderResultFooter renders the attribution footer, empty when disabled
+func (s *SpoilerService) renderResultFooter() string {
+	if !s.settings.ResultFooterEnabled || strings.TrimSpace(s.settings.ResultFooterTemplate) == "" {
+		return ""
+	}
+	return replacePlaceholders(s.settings.ResultFooterTemplate, map[string]string{
+		"%APP_VERSION%": AppVersion,
+	})
+}
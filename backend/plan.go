@@ -0,0 +1,74 @@
+package backend
+
+// MoviePlan lists what processing will do for a single movie
+type MoviePlan struct {
+	MovieID      string   `json:"movieId"`
+	FileName     string   `json:"fileName"`
+	ContactSheet bool     `json:"contactSheet"`
+	Screenshots  int      `json:"screenshots"`
+	Hosts        []string `json:"hosts"`
+	Warnings     []string `json:"warnings,omitempty"`
+}
+
+// ProcessingPlan previews what StartProcessing will generate and upload
+type ProcessingPlan struct {
+	Movies   []MoviePlan `json:"movies"` // Pending movies in the order they will be started
+	Hosts    []string    `json:"hosts"`
+	Warnings []string    `json:"warnings,omitempty"`
+}
+
+// GetProcessingPlan returns, per pending movie, which artifacts will be generated and which
+// hosts will be used with the current template and settings
+func (s *SpoilerService) GetProcessingPlan() ProcessingPlan {
+	requirements := s.getUploaderRequirements()
+	plan := ProcessingPlan{
+		Movies: make([]MoviePlan, 0),
+		Hosts:  requiredHosts(requirements),
+	}
+
+	if len(plan.Hosts) == 0 {
+		plan.Warnings = append(plan.Warnings, "The current template does not use any image placeholders, nothing will be uploaded")
+	}
+	if _, needed := requirements.Host("hamster"); needed && (s.settings.HamsterEmail == "" || s.settings.HamsterPassword == "") {
+		plan.Warnings = append(plan.Warnings, "Hamster credentials are missing, Hamster uploads will fail")
+	}
+	if s.usesMetadata() && !s.metadataEnabled() {
+		plan.Warnings = append(plan.Warnings, "The template uses movie metadata, but no TMDB or OMDb API key is set")
+	}
+
+	for _, movie := range s.prioritizeMovies(s.getPendingMovies()) {
+		moviePlan := MoviePlan{
+			MovieID:      movie.ID,
+			FileName:     movie.FileName,
+			ContactSheet: requirements.NeedsContactSheet(),
+			Hosts:        plan.Hosts,
+		}
+
+		if moviePlan.ContactSheet && movie.DurationSeconds <= 0 {
+			moviePlan.ContactSheet = false
+			moviePlan.Warnings = append(moviePlan.Warnings, "Video duration is unknown, the contact sheet will be skipped")
+		}
+
+		if requirements.NeedsScreenshots() {
+			if movie.DurationSeconds > 0 {
+				moviePlan.Screenshots = s.settings.ScreenshotCount
+			} else {
+				moviePlan.Screenshots = min(s.settings.ScreenshotCount, 1)
+				moviePlan.Warnings = append(moviePlan.Warnings, "Video duration is unknown, only a single screenshot at 0s will be taken")
+			}
+		}
+
+		plan.Movies = append(plan.Movies, moviePlan)
+	}
+
+	return plan
+}
+
+// requiredHosts lists the image hosts the requirements will upload to
+func requiredHosts(requirements UploaderRequirements) []string {
+	hosts := make([]string, 0, len(requirements.Hosts))
+	for _, host := range requirements.Hosts {
+		hosts = append(hosts, host.Name)
+	}
+	return hosts
+}
@@ -0,0 +1,144 @@
+package backend
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	minSheetWidth = 320
+	maxSheetWidth = 7680
+)
+
+// validatePresetGeneration checks the generation parameters a preset overrides
+func validatePresetGeneration(preset TemplatePreset) error {
+	if preset.MtnArgs != nil && strings.TrimSpace(*preset.MtnArgs) == "" {
+		return fmt.Errorf("MTN arguments cannot be empty")
+	}
+	if count := preset.ScreenshotCount; count != nil && (*count < 0 || *count > 20) {
+		return fmt.Errorf("screenshot count must be between 0 and 20")
+	}
+	if width := preset.SheetWidth; width != nil && (*width < minSheetWidth || *width > maxSheetWidth) {
+		return fmt.Errorf("contact sheet width must be between %d and %d", minSheetWidth, maxSheetWidth)
+	}
+	return nil
+}
+
+// presetGeneration returns the settings with the generation parameters of the preset applied
+func presetGeneration(settings AppSettings, preset TemplatePreset) AppSettings {
+	if preset.MtnArgs != nil {
+		settings.MtnArgs = *preset.MtnArgs
+	}
+	if preset.ScreenshotCount != nil {
+		settings.ScreenshotCount = *preset.ScreenshotCount
+	}
+	if preset.SheetWidth != nil {
+		args := setMtnOption(splitMtnArgs(settings.MtnArgs), "-w", strconv.Itoa(*preset.SheetWidth))
+		settings.MtnArgs = joinMtnArgs(args)
+	}
+	return settings
+}
+
+// applyPresetGeneration switches to the generation parameters of the current preset for a run.
+// The configured settings are staged like a settings change during processing, so they are
+// restored when the run finishes. Run options applied afterwards take precedence.
+func (s *SpoilerService) applyPresetGeneration() {
+	preset, ok := s.currentPreset()
+	if !ok || (preset.MtnArgs == nil && preset.ScreenshotCount == nil && preset.SheetWidth == nil) {
+		return
+	}
+
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+	if s.pendingSettings == nil {
+		configured := s.settings
+		s.pendingSettings = &configured
+	}
+	s.settings = presetGeneration(s.settings, preset)
+}
+
+// splitMtnArgs splits MTN arguments on spaces, keeping quoted arguments together
+func splitMtnArgs(raw string) []string {
+	args := []string{}
+	current := ""
+	inQuotes := false
+
+	for _, char := range raw {
+		switch char {
+		case '"':
+			inQuotes = !inQuotes
+		case ' ':
+			if !inQuotes {
+				if current != "" {
+					args = append(args, current)
+					current = ""
+				}
+			} else {
+				current += string(char)
+			}
+		default:
+			current += string(char)
+		}
+	}
+	if current != "" {
+		args = append(args, current)
+	}
+
+	return args
+}
+
+// joinMtnArgs is the inverse of splitMtnArgs
+func joinMtnArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.Contains(arg, " ") {
+			arg = `"` + arg + `"`
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// setMtnOption replaces the value of an MTN option, or appends the option when it is missing
+func setMtnOption(args []string, option, value string) []string {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == option {
+			updated := append([]string(nil), args...)
+			updated[i+1] = value
+			return updated
+		}
+	}
+	return append(args, option, value)
+}
+
+// SetPresetMtnArgs overrides the MTN arguments for a preset, nil restores the global setting
+func (s *SpoilerService) SetPresetMtnArgs(presetID string, mtnArgs *string) error {
+	if err := validatePresetGeneration(TemplatePreset{MtnArgs: mtnArgs}); err != nil {
+		return err
+	}
+	return s.configManager.updatePreset(presetID, func(p *TemplatePreset) {
+		p.MtnArgs = mtnArgs
+	})
+}
+
+// SetPresetScreenshotCount overrides the screenshot count for a preset, nil restores the global setting
+func (s *SpoilerService) SetPresetScreenshotCount(presetID string, count *int) error {
+	if err := validatePresetGeneration(TemplatePreset{ScreenshotCount: count}); err != nil {
+		return err
+	}
+	return s.configManager.updatePreset(presetID, func(p *TemplatePreset) {
+		p.ScreenshotCount = count
+	})
+}
+
+// SetPresetSheetWidth overrides the contact sheet width for a preset, nil keeps the width of
+// the MTN arguments
+func (s *SpoilerService) SetPresetSheetWidth(presetID string, width *int) error {
+	if err := validatePresetGeneration(TemplatePreset{SheetWidth: width}); err != nil {
+		return err
+	}
+	return s.configManager.updatePreset(presetID, func(p *TemplatePreset) {
+		p.SheetWidth = width
+	})
+}
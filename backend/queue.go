@@ -0,0 +1,308 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"slices"
+	"spoilr/backend/img_uploaders"
+	"sync"
+	"time"
+)
+
+// Queue job kinds
+const (
+	QueueJobScreenshot   = "screenshot"
+	QueueJobContactSheet = "contact_sheet"
+	QueueJobUpload       = "upload"
+)
+
+// uploadProgressInterval throttles the upload-progress events
+const uploadProgressInterval = 250 * time.Millisecond
+
+// Queue job statuses
+const (
+	QueueStatusWaiting = "waiting" // Waiting for a slot
+	QueueStatusPaused  = "paused"  // Upload held back until uploads are resumed
+	QueueStatusRunning = "running"
+)
+
+// QueueJob is a single screenshot, contact sheet or upload that is waiting for or holding a slot
+type QueueJob struct {
+	MovieID  string    `json:"movieId"`
+	FileName string    `json:"fileName"`
+	Kind     string    `json:"kind"`
+	Host     string    `json:"host,omitempty"`  // Uploads only
+	Index    int       `json:"index,omitempty"` // 1-based screenshot number, 0 for contact sheets
+	Status   string    `json:"status"`
+	Since    time.Time `json:"since"`           // When the job entered its current stage
+	Sent     int64     `json:"sent,omitempty"`  // Upload bytes sent so far
+	Total    int64     `json:"total,omitempty"` // Upload body size
+}
+
+// SlotUsage reports the usage of a concurrency limit
+type SlotUsage struct {
+	Capacity int `json:"capacity"`
+	Running  int `json:"running"`
+	Waiting  int `json:"waiting"` // Including paused uploads
+}
+
+// HostQueue reports the uploads of a single host in the current run
+type HostQueue struct {
+	Name      string `json:"name"`
+	Capacity  int    `json:"capacity"` // Parallel uploads allowed to the host
+	Waiting   int    `json:"waiting"`
+	Uploading int    `json:"uploading"`
+	Completed int    `json:"completed"`
+	Failed    int    `json:"failed"`
+}
+
+// QueueSnapshot is the state of the processing pipeline
+type QueueSnapshot struct {
+	Processing        bool        `json:"processing"`
+	UploadsPaused     bool        `json:"uploadsPaused"`
+	ScreenshotSlots   SlotUsage   `json:"screenshotSlots"`
+	ContactSheetSlots SlotUsage   `json:"contactSheetSlots"`
+	UploadSlots       SlotUsage   `json:"uploadSlots"` // Totals over the hosts of the run
+	Hosts             []HostQueue `json:"hosts"`
+	Jobs              []QueueJob  `json:"jobs"` // Oldest first
+}
+
+// queueTracker keeps the jobs of the current run
+type queueTracker struct {
+	mu     sync.Mutex
+	jobs   map[int]*QueueJob
+	nextID int
+	hosts  map[string]*HostQueue
+	order  []string // Host order of the snapshot
+
+	paused  bool
+	resumed chan struct{} // Closed when uploads are resumed
+}
+
+func newQueueTracker() *queueTracker {
+	return &queueTracker{
+		jobs:    make(map[int]*QueueJob),
+		hosts:   make(map[string]*HostQueue),
+		resumed: make(chan struct{}),
+	}
+}
+
+// reset starts a new run with the given hosts
+func (q *queueTracker) reset(uploaders []*activeUploader) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.hosts = make(map[string]*HostQueue)
+	q.order = nil
+	for _, uploader := range uploaders {
+		q.hosts[uploader.Name()] = &HostQueue{Name: uploader.Name(), Capacity: cap(uploader.slots)}
+		q.order = append(q.order, uploader.Name())
+	}
+}
+
+// add registers a job waiting for its slot and returns its handle
+func (q *queueTracker) add(movie Movie, kind, host string, index int) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	id := q.nextID
+	q.nextID++
+	q.jobs[id] = &QueueJob{
+		MovieID:  movie.ID,
+		FileName: movie.FileName,
+		Kind:     kind,
+		Host:     host,
+		Index:    index,
+		Status:   QueueStatusWaiting,
+		Since:    time.Now(),
+	}
+	return id
+}
+
+// start marks a job as holding its slot
+func (q *queueTracker) start(id int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, exists := q.jobs[id]; exists {
+		job.Status = QueueStatusRunning
+		job.Since = time.Now()
+	}
+}
+
+// progress records the bytes an upload sent so far
+func (q *queueTracker) progress(id int, sent, total int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, exists := q.jobs[id]; exists {
+		job.Sent, job.Total = sent, total
+	}
+}
+
+// setPaused holds back uploads that have not started yet, or releases them
+func (q *queueTracker) setPaused(paused bool) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.paused == paused {
+		return false
+	}
+	q.paused = paused
+	if paused {
+		q.resumed = make(chan struct{})
+	} else {
+		close(q.resumed)
+	}
+	return true
+}
+
+func (q *queueTracker) isPaused() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.paused
+}
+
+// waitResumed blocks while uploads are paused
+func (q *queueTracker) waitResumed(ctx context.Context) error {
+	q.mu.Lock()
+	paused, resumed := q.paused, q.resumed
+	q.mu.Unlock()
+
+	if !paused {
+		return nil
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// done removes a finished or cancelled job
+func (q *queueTracker) done(id int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.jobs, id)
+}
+
+// recordUpload counts a finished upload of a host
+func (q *queueTracker) recordUpload(host string, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats, exists := q.hosts[host]
+	if !exists {
+		return
+	}
+	if err != nil {
+		stats.Failed++
+	} else {
+		stats.Completed++
+	}
+}
+
+func (q *queueTracker) snapshot() QueueSnapshot {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	snapshot := QueueSnapshot{UploadsPaused: q.paused, Jobs: make([]QueueJob, 0, len(q.jobs))}
+	hosts := make(map[string]*HostQueue, len(q.hosts))
+	for _, name := range q.order {
+		host := *q.hosts[name]
+		hosts[name] = &host
+	}
+
+	for _, job := range q.jobs {
+		entry := *job
+		if entry.Kind == QueueJobUpload && entry.Status == QueueStatusWaiting && q.paused {
+			entry.Status = QueueStatusPaused
+		}
+		snapshot.Jobs = append(snapshot.Jobs, entry)
+
+		slots := &snapshot.ScreenshotSlots
+		switch job.Kind {
+		case QueueJobUpload:
+			slots = &snapshot.UploadSlots
+		case QueueJobContactSheet:
+			slots = &snapshot.ContactSheetSlots
+		}
+		host := hosts[job.Host]
+		if job.Status == QueueStatusRunning {
+			slots.Running++
+			if host != nil {
+				host.Uploading++
+			}
+		} else {
+			slots.Waiting++
+			if host != nil {
+				host.Waiting++
+			}
+		}
+	}
+	slices.SortFunc(snapshot.Jobs, func(a, b QueueJob) int {
+		return a.Since.Compare(b.Since)
+	})
+
+	for _, name := range q.order {
+		snapshot.Hosts = append(snapshot.Hosts, *hosts[name])
+		snapshot.UploadSlots.Capacity += hosts[name].Capacity
+	}
+	return snapshot
+}
+
+// GetQueueSnapshot reports the jobs waiting for and holding screenshot and upload slots, so
+// the frontend can render the processing pipeline
+func (s *SpoilerService) GetQueueSnapshot() QueueSnapshot {
+	snapshot := s.queue.snapshot()
+	snapshot.Processing = s.processing
+	snapshot.ScreenshotSlots.Capacity = cap(s.screenshotSemaphore)
+	snapshot.ContactSheetSlots.Capacity = cap(s.contactSheetSemaphore)
+	return snapshot
+}
+
+// PauseUploads holds back every upload that has not started, for example on a metered
+// connection. Uploads in flight finish, generation continues and the generated images stay
+// in the temp directory until uploads are resumed or processing is cancelled.
+func (s *SpoilerService) PauseUploads() {
+	if s.queue.setPaused(true) {
+		log.Println("Uploads paused")
+		s.emitUploadsPaused(true)
+	}
+}
+
+// ResumeUploads releases the uploads held back by PauseUploads
+func (s *SpoilerService) ResumeUploads() {
+	if s.queue.setPaused(false) {
+		log.Println("Uploads resumed")
+		s.emitUploadsPaused(false)
+	}
+}
+
+// uploadProgress returns the progress reporter of an upload job. Every report updates the
+// queue snapshot, upload-progress events are throttled except for the final one.
+func (s *SpoilerService) uploadProgress(job int, movieID, host string, index int) img_uploaders.ProgressFunc {
+	var lastSent time.Time
+	return func(sent, total int64) {
+		s.queue.progress(job, sent, total)
+		if s.app == nil || (sent < total && time.Since(lastSent) < uploadProgressInterval) {
+			return
+		}
+		lastSent = time.Now()
+		s.app.Event.Emit("upload-progress", map[string]any{
+			"movieId":  movieID,
+			"host":     host,
+			"index":    index, // 1-based screenshot number, 0 for the contact sheet
+			"sent":     sent,
+			"total":    total,
+			"progress": float64(sent) / float64(max(total, 1)),
+		})
+	}
+}
+
+func (s *SpoilerService) emitUploadsPaused(paused bool) {
+	if s.app != nil {
+		s.app.Event.Emit("uploads-paused", paused)
+	}
+}
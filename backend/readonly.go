@@ -0,0 +1,50 @@
+package backend
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// guardSourceWrite refuses writes into any source directory when read-only source mode is enabled
+func (s *SpoilerService) guardSourceWrite(path string) error {
+	if !s.settings.ReadOnlySources {
+		return nil
+	}
+
+	target, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path %s: %v", path, err)
+	}
+
+	for _, dir := range s.sourceDirectories() {
+		if isWithinDir(target, dir) {
+			return fmt.Errorf("read-only source mode: refusing to write %s inside source directory %s", target, dir)
+		}
+	}
+	return nil
+}
+
+// sourceDirectories returns the unique directories containing the current source files
+func (s *SpoilerService) sourceDirectories() []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, movie := range s.movies {
+		dir, err := filepath.Abs(filepath.Dir(movie.FilePath))
+		if err != nil || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// isWithinDir reports whether path equals dir or is located below it
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
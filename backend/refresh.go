@@ -0,0 +1,228 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// refreshDownloadTimeout bounds the download of a single image re-uploaded by a refresh
+const refreshDownloadTimeout = 2 * time.Minute
+
+// RefreshUploads uploads the images of completed movies again, for hosts that purge old
+// uploads. Movies whose source file is still there are regenerated, imported movies and movies
+// whose source file is gone re-upload the images downloaded from their current links. The
+// upload history is bypassed so every link is new, and the updated BBCode is generated as usual
+// once the refresh finishes. The old links are kept until a new upload replaces them.
+func (s *SpoilerService) RefreshUploads(ids []string) error {
+	if s.processing {
+		return fmt.Errorf("processing already in progress")
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("no movies to refresh")
+	}
+
+	sources := make(map[string]map[string]HostUploads)
+	for _, id := range ids {
+		movie, exists := s.getMovieByID(id)
+		if !exists {
+			return fmt.Errorf("movie with ID %s not found", id)
+		}
+		if !movie.ProcessingState.IsCompleted() {
+			return fmt.Errorf("%s has not been completed", movie.FileName)
+		}
+		hosts := make(map[string]HostUploads)
+		for host, uploads := range movie.Uploads {
+			if uploads != nil {
+				hosts[host] = *uploads
+			}
+		}
+		sources[id] = hosts
+	}
+
+	var movies []Movie
+	refreshAll := make(map[string]bool, len(ids))
+	for _, id := range s.orderedMovieIDs(ids) {
+		s.transitionMovieState(id, StatePending)
+		s.updateMovieByID(id, func(m *Movie) {
+			m.ProcessingError = ""
+			m.DeadLinks = nil
+		})
+		refreshAll[id] = true
+		s.recordEvent(id, "processing", "Upload refresh requested", nil)
+		movie, _ := s.getMovieByID(id)
+		movies = append(movies, movie)
+	}
+
+	s.refreshSources = sources
+	s.refreshAll = refreshAll
+	s.applyPresetGeneration()
+	s.startProcessing(movies, func() {
+		s.refreshSources = nil
+		s.refreshAll = nil
+		s.emitUploadsRefreshed(ids)
+	})
+	return nil
+}
+
+// emitUploadsRefreshed sends the updated BBCode of the refreshed movies, ready to replace the
+// spoilers of the old post
+func (s *SpoilerService) emitUploadsRefreshed(ids []string) {
+	if s.app == nil {
+		return
+	}
+	var blocks []string
+	for _, id := range s.orderedMovieIDs(ids) {
+		if movie, exists := s.getMovieByID(id); exists && movie.ProcessingState.IsCompleted() {
+			blocks = append(blocks, s.GenerateResultForMovie(id))
+		}
+	}
+	s.app.Event.Emit("uploads-refreshed", map[string]any{
+		"movieIds": ids,
+		"result":   strings.Join(blocks, "\n"),
+	})
+}
+
+// refreshing reports whether the current run is an upload refresh
+func (s *SpoilerService) refreshing() bool {
+	return s.refreshSources != nil
+}
+
+// imagesFromLinks reports whether a movie's images are downloaded from its current links
+// instead of generated: imported movies, and refreshed movies whose source file is gone
+func (s *SpoilerService) imagesFromLinks(movie Movie) bool {
+	if movie.Imported {
+		return true
+	}
+	if _, exists := s.refreshSources[movie.ID]; !exists {
+		return false
+	}
+	_, err := os.Stat(movie.FilePath)
+	return err != nil
+}
+
+// downloadRefreshSources downloads the images a movie had before the refresh, as it has no
+// source file to generate them from. Each image is taken from the first host that
+// still serves it.
+func (s *SpoilerService) downloadRefreshSources(movie Movie, tempDir string) (string, []string, error) {
+	hosts := s.refreshSources[movie.ID]
+	if len(hosts) == 0 {
+		return "", nil, fmt.Errorf("no uploaded images to refresh")
+	}
+
+	var contactSheetURLs []string
+	var screenshotURLs [][]string
+	for _, host := range slices.Sorted(maps.Keys(hosts)) {
+		uploads := hosts[host]
+		if uploads.ContactSheetDirectURL != "" {
+			contactSheetURLs = append(contactSheetURLs, uploads.ContactSheetDirectURL)
+		}
+		for i, direct := range uploads.ScreenshotDirectURLs {
+			for len(screenshotURLs) <= i {
+				screenshotURLs = append(screenshotURLs, nil)
+			}
+			if direct != "" {
+				screenshotURLs[i] = append(screenshotURLs[i], direct)
+			}
+		}
+	}
+
+	contactSheetPath := ""
+	if len(contactSheetURLs) > 0 {
+		var err error
+		contactSheetPath, err = s.downloadFirstAvailable(movie.ID, contactSheetURLs, filepath.Join(tempDir, "contact_sheet"))
+		s.recordEvent(movie.ID, "contact_sheet", "Contact sheet downloaded", err)
+		if err != nil {
+			s.addMovieError(movie.ID, fmt.Sprintf("Contact sheet download failed: %v", err))
+		}
+	}
+
+	screenshotPaths := make([]string, len(screenshotURLs))
+	for i, urls := range screenshotURLs {
+		if len(urls) == 0 {
+			continue
+		}
+		screenshotPath, err := s.downloadFirstAvailable(movie.ID, urls, filepath.Join(tempDir, fmt.Sprintf("screenshot_%03d", i+1)))
+		s.recordEvent(movie.ID, "screenshot", fmt.Sprintf("Screenshot %d downloaded", i+1), err)
+		if err != nil {
+			s.addMovieError(movie.ID, fmt.Sprintf("Screenshot %d download failed: %v", i+1, err))
+			continue
+		}
+		screenshotPaths[i] = screenshotPath
+	}
+
+	if s.cancelCtx.Err() != nil {
+		return "", nil, fmt.Errorf("refresh cancelled: %v", s.cancelCtx.Err())
+	}
+	return contactSheetPath, screenshotPaths, nil
+}
+
+// downloadFirstAvailable downloads the first of the URLs that answers, to basePath with the
+// URL's extension
+func (s *SpoilerService) downloadFirstAvailable(movieID string, urls []string, basePath string) (string, error) {
+	var lastErr error
+	for _, rawURL := range urls {
+		filePath := basePath + ".jpg"
+		if parsed, err := url.Parse(rawURL); err == nil && path.Ext(parsed.Path) != "" {
+			filePath = basePath + path.Ext(parsed.Path)
+		}
+		if err := s.downloadImage(movieID, rawURL, filePath); err != nil {
+			lastErr = err
+			continue
+		}
+		return filePath, nil
+	}
+	return "", lastErr
+}
+
+// downloadImage saves an uploaded image, failing on purged images that hosts answer with an
+// error page
+func (s *SpoilerService) downloadImage(movieID, rawURL, filePath string) error {
+	ctx, cancel := context.WithTimeout(s.cancelCtx, refreshDownloadTimeout)
+	defer cancel()
+
+	if err := saveImage(ctx, rawURL, filePath); err != nil {
+		return err
+	}
+	s.artifacts.add(movieID, filePath)
+	return nil
+}
+
+// saveImage downloads an image to filePath
+func saveImage(ctx context.Context, rawURL, filePath string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %v", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: unexpected status %s", rawURL, resp.Status)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("failed to download %s: not an image (%s)", rawURL, contentType)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		os.Remove(filePath)
+		return fmt.Errorf("failed to download %s: %v", rawURL, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,222 @@
+package backend
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ReleaseInfo is a scene or P2P release name decomposed into its parts. Season and episode
+// are zero-padded to two digits, a double episode is written like "01-02".
+type ReleaseInfo struct {
+	Title      string `json:"title"`
+	Year       string `json:"year,omitempty"`
+	Season     string `json:"season,omitempty"`
+	Episode    string `json:"episode,omitempty"`
+	Resolution string `json:"resolution,omitempty"` // e.g. "1080p"
+	Source     string `json:"source,omitempty"`     // e.g. "BluRay" or "WEB-DL"
+	Group      string `json:"group,omitempty"`
+}
+
+var (
+	releaseExtensionPattern  = regexp.MustCompile(`(?i)^\.(mkv|mp4|m4v|avi|mov|wmv|webm|flv|ts|m2ts|mts|mpg|mpeg|vob|iso)$`)
+	releaseYearPattern       = regexp.MustCompile(`^(19|20)\d{2}$`)
+	releaseEpisodePattern    = regexp.MustCompile(`(?i)^s(\d{1,2})(?:e(\d{1,3})(?:-?e(\d{1,3}))?)?$`)
+	releaseCrossPattern      = regexp.MustCompile(`(?i)^(\d{1,2})x(\d{2,3})$`)
+	releaseResolutionPattern = regexp.MustCompile(`(?i)^(\d{3,4})[pi]$`)
+	releaseDimensionsPattern = regexp.MustCompile(`(?i)^\d{3,4}x(\d{3,4})$`)
+	releaseTagPattern        = regexp.MustCompile(`(?i)^(x26[45]|h\.?26[45]|hevc|avc|xvid|divx|av1|vp9|10bit|8bit|hdr10?\+?|hdr|dv|dovi|sdr|dts(-?hd)?|truehd|atmos|aac\d?|ac3|eac3|dd\+?(5\.1|2\.0)?|ddp?\d?|flac|opus|proper|repack|rerip|internal|limited|extended|unrated|remastered|uncut|imax|multi|dual|amzn|nf|dsnp|hmax|atvp|hulu|itunes|complete)$`)
+	releaseGroupPattern      = regexp.MustCompile(`-([A-Za-z0-9]+)(?:\[[^\]]*\])?$`)
+	releaseLeadingPattern    = regexp.MustCompile(`^\[([^\]]+)\]\s*`)
+)
+
+// releaseSources maps the source tags of release names to their usual spelling
+var releaseSources = map[string]string{
+	"bluray":   "BluRay",
+	"blu-ray":  "BluRay",
+	"bdrip":    "BDRip",
+	"brrip":    "BRRip",
+	"bdremux":  "BDRemux",
+	"remux":    "Remux",
+	"web-dl":   "WEB-DL",
+	"webdl":    "WEB-DL",
+	"webrip":   "WEBRip",
+	"web-rip":  "WEBRip",
+	"web":      "WEB",
+	"hdtv":     "HDTV",
+	"hdtvrip":  "HDTVRip",
+	"pdtv":     "PDTV",
+	"hdrip":    "HDRip",
+	"dvdrip":   "DVDRip",
+	"dvd-rip":  "DVDRip",
+	"dvd":      "DVD",
+	"dvd5":     "DVD5",
+	"dvd9":     "DVD9",
+	"dvdscr":   "DVDScr",
+	"satrip":   "SATRip",
+	"vhsrip":   "VHSRip",
+	"camrip":   "CAMRip",
+	"cam":      "CAM",
+	"ts":       "TS",
+	"telesync": "TS",
+}
+
+// ParseReleaseName decomposes a release name like "Show.Name.S01E02.1080p.WEB-DL.x264-GROUP.mkv"
+// or "[Group] Show Name - 05 [720p].mkv". Parts that are not found stay empty. The title ends
+// at the first release tag, the last year before it is the year, so titles containing a year
+// keep it.
+func ParseReleaseName(fileName string) ReleaseInfo {
+	name := strings.TrimSpace(fileName)
+	if releaseExtensionPattern.MatchString(filepath.Ext(name)) {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	var info ReleaseInfo
+	if match := releaseLeadingPattern.FindStringSubmatch(name); match != nil {
+		info.Group = strings.TrimSpace(match[1])
+		name = name[len(match[0]):]
+	}
+
+	fields := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '.' || r == '_' || r == ' ' || r == '[' || r == ']' || r == '(' || r == ')'
+	})
+
+	end := len(fields)
+	tagged := false
+	for i, field := range fields {
+		// Anime style "Title - 05"
+		if field == "-" && i+1 < len(fields) && info.Season == "" && info.Episode == "" {
+			if episode, err := strconv.Atoi(fields[i+1]); err == nil && len(fields[i+1]) <= 3 {
+				info.Episode = fmt.Sprintf("%02d", episode)
+				end = min(end, i)
+				continue
+			}
+		}
+		if parseReleaseTag(&info, field) {
+			tagged = true
+			end = min(end, i)
+		}
+	}
+	fields = fields[:end]
+
+	for i := len(fields) - 1; i > 0; i-- {
+		if releaseYearPattern.MatchString(fields[i]) {
+			info.Year = fields[i]
+			fields = fields[:i]
+			break
+		}
+	}
+	info.Title = strings.Trim(strings.Join(fields, " "), " -")
+
+	// Only names with release tags end in a group, "Spider-Man" does not
+	if info.Group == "" && tagged {
+		if match := releaseGroupPattern.FindStringSubmatchIndex(name); match != nil {
+			group := name[match[2]:match[3]]
+			before := strings.ToLower(name[:match[0]])
+			if last := strings.LastIndexAny(before, "._ [("); last >= 0 {
+				before = before[last+1:]
+			}
+			if _, source := releaseSources[before+"-"+strings.ToLower(group)]; !source {
+				info.Group = group
+			}
+		}
+	}
+	return info
+}
+
+// parseReleaseTag fills the part of the release a field stands for. It reports whether the
+// field is a release tag, which ends the title.
+func parseReleaseTag(info *ReleaseInfo, field string) bool {
+	lower := strings.ToLower(field)
+	if source, exists := releaseSources[lower]; exists {
+		if info.Source == "" {
+			info.Source = source
+		}
+		return true
+	}
+	// The group follows the last tag, e.g. "x264-GROUP"
+	if tag, _, found := strings.Cut(lower, "-"); found {
+		if releaseTagPattern.MatchString(tag) || releaseResolutionPattern.MatchString(tag) {
+			parseReleaseTag(info, field[:len(tag)])
+			return true
+		}
+		if source, exists := releaseSources[tag]; exists {
+			if info.Source == "" {
+				info.Source = source
+			}
+			return true
+		}
+	}
+
+	if match := releaseEpisodePattern.FindStringSubmatch(field); match != nil {
+		if info.Season == "" {
+			info.Season = padReleaseNumber(match[1])
+			if match[2] != "" {
+				info.Episode = padReleaseNumber(match[2])
+			}
+			if match[3] != "" {
+				info.Episode += "-" + padReleaseNumber(match[3])
+			}
+		}
+		return true
+	}
+	if match := releaseCrossPattern.FindStringSubmatch(field); match != nil {
+		if info.Season == "" {
+			info.Season = padReleaseNumber(match[1])
+			info.Episode = padReleaseNumber(match[2])
+		}
+		return true
+	}
+
+	resolution := ""
+	switch {
+	case lower == "4k" || lower == "uhd" || lower == "2160p":
+		resolution = "2160p"
+	case releaseResolutionPattern.MatchString(lower):
+		resolution = lower
+	case releaseDimensionsPattern.MatchString(lower):
+		resolution = releaseDimensionsPattern.FindStringSubmatch(lower)[1] + "p"
+	}
+	if resolution != "" {
+		if info.Resolution == "" {
+			info.Resolution = resolution
+		}
+		return true
+	}
+
+	return releaseTagPattern.MatchString(field)
+}
+
+func padReleaseNumber(number string) string {
+	value, _ := strconv.Atoi(number)
+	return fmt.Sprintf("%02d", value)
+}
+
+// params returns the %REL_*% placeholders of the release
+func (r ReleaseInfo) params() map[string]string {
+	return map[string]string{
+		"%REL_TITLE%":      r.Title,
+		"%REL_YEAR%":       r.Year,
+		"%REL_SEASON%":     r.Season,
+		"%REL_EPISODE%":    r.Episode,
+		"%REL_RESOLUTION%": r.Resolution,
+		"%REL_SOURCE%":     r.Source,
+		"%REL_GROUP%":      r.Group,
+	}
+}
+
+// setReleaseParams parses the movie's file name into the %REL_*% placeholders
+func setReleaseParams(movie *Movie) {
+	if movie.Params == nil {
+		movie.Params = make(map[string]string)
+	}
+	for key, value := range ParseReleaseName(movie.FileName).params() {
+		if value == "" {
+			delete(movie.Params, key)
+			continue
+		}
+		movie.Params[key] = value
+	}
+}
@@ -0,0 +1,114 @@
+package backend
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RenamePreview describes how a single source file would be renamed
+type RenamePreview struct {
+	MovieID string `json:"movieId"`
+	OldName string `json:"oldName"`
+	NewName string `json:"newName"`
+	Error   string `json:"error,omitempty"`
+}
+
+var invalidFileNameChars = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+
+// PreviewRename shows the file names the configured rename pattern would produce
+func (s *SpoilerService) PreviewRename() []RenamePreview {
+	previews := make([]RenamePreview, 0, len(s.movies))
+	for _, movie := range s.movies {
+		if movie.ProcessingState == StateAnalyzingMedia {
+			continue
+		}
+		previews = append(previews, s.buildRenamePreview(movie))
+	}
+	return previews
+}
+
+// RenameMovieFiles renames source files on disk according to the rename pattern
+func (s *SpoilerService) RenameMovieFiles() ([]RenamePreview, error) {
+	if s.processing {
+		return nil, fmt.Errorf("cannot rename files while processing is in progress")
+	}
+	if s.settings.ReadOnlySources {
+		return nil, fmt.Errorf("cannot rename files while read-only source mode is enabled")
+	}
+	if strings.TrimSpace(s.settings.RenamePattern) == "" {
+		return nil, fmt.Errorf("rename pattern is empty")
+	}
+
+	results := s.PreviewRename()
+	for i, preview := range results {
+		if preview.Error != "" || preview.OldName == preview.NewName {
+			continue
+		}
+
+		movie, exists := s.getMovieByID(preview.MovieID)
+		if !exists {
+			continue
+		}
+
+		newPath := filepath.Join(filepath.Dir(movie.FilePath), preview.NewName)
+		if _, err := os.Stat(newPath); err == nil {
+			results[i].Error = "target file already exists"
+			continue
+		}
+
+		if err := os.Rename(movie.FilePath, newPath); err != nil {
+			results[i].Error = err.Error()
+			log.Printf("Failed to rename %s: %v", movie.FileName, err)
+			continue
+		}
+
+		s.updateMovieByID(movie.ID, func(m *Movie) {
+			m.FilePath = newPath
+			m.FileName = preview.NewName
+			setReleaseParams(m)
+		})
+		log.Printf("Renamed %s -> %s", preview.OldName, preview.NewName)
+	}
+
+	s.emitState()
+	return results, nil
+}
+
+// buildRenamePreview renders the rename pattern for a movie and validates the result
+func (s *SpoilerService) buildRenamePreview(movie Movie) RenamePreview {
+	preview := RenamePreview{
+		MovieID: movie.ID,
+		OldName: movie.FileName,
+		NewName: movie.FileName,
+	}
+
+	pattern := strings.TrimSpace(s.settings.RenamePattern)
+	if pattern == "" {
+		return preview
+	}
+
+	ext := filepath.Ext(movie.FileName)
+	name := strings.ReplaceAll(pattern, "%BASE_NAME%", strings.TrimSuffix(movie.FileName, ext))
+	name = s.replaceBasicPlaceholders(name, movie)
+	name = s.replaceParameterPlaceholders(name, movie)
+	name = sanitizeFileName(name)
+
+	if name == "" {
+		preview.Error = "pattern produced an empty file name"
+		return preview
+	}
+
+	preview.NewName = name + ext
+	return preview
+}
+
+// sanitizeFileName strips characters that are not allowed in file names on any platform
+func sanitizeFileName(name string) string {
+	name = invalidFileNameChars.ReplaceAllString(name, "")
+	name = strings.Join(strings.Fields(name), " ")
+	return strings.Trim(name, " .")
+}
@@ -0,0 +1,96 @@
+package backend
+
+import "fmt"
+
+// RetryMovie re-runs only the failed stages of a single movie. Screenshots and uploads that
+// already succeeded are kept, the rest of the list is left untouched.
+func (s *SpoilerService) RetryMovie(id string) error {
+	if s.processing {
+		return fmt.Errorf("processing already in progress")
+	}
+
+	movie, exists := s.getMovieByID(id)
+	if !exists {
+		return fmt.Errorf("movie with ID %s not found", id)
+	}
+	if movie.Imported {
+		return fmt.Errorf("%s was imported from BBCode and has no source file", movie.FileName)
+	}
+	if !movie.ProcessingState.IsFinished() {
+		return fmt.Errorf("%s has not been processed yet", movie.FileName)
+	}
+
+	s.transitionMovieState(id, StatePending)
+	s.updateMovieByID(id, func(m *Movie) {
+		m.ProcessingError = ""
+	})
+	movie, _ = s.getMovieByID(id)
+
+	s.recordEvent(id, "processing", "Retry requested", nil)
+	s.applyPresetGeneration()
+	s.startProcessing([]Movie{movie}, func() {})
+	return nil
+}
+
+// missingUploads narrows the uploaders to the work a movie still lacks, so results kept from
+// an earlier run are not uploaded again
+func (s *SpoilerService) missingUploads(movieID string, uploaders []*activeUploader) []*activeUploader {
+	s.uploadsMu.Lock()
+	defer s.uploadsMu.Unlock()
+
+	movie, exists := s.getMovieByID(movieID)
+	if !exists || s.refreshAll[movieID] {
+		return uploaders
+	}
+
+	var missing []*activeUploader
+	for _, uploader := range uploaders {
+		remaining := *uploader
+		if uploads := movie.Uploads[uploader.Name()]; uploads != nil {
+			remaining.contactSheet = uploader.contactSheet && uploads.ContactSheetURL == ""
+			remaining.screenshots = uploader.screenshots && !s.screenshotsComplete(movieID, uploads.ScreenshotURLs)
+		}
+		if remaining.contactSheet || remaining.screenshots {
+			missing = append(missing, &remaining)
+		}
+	}
+	return missing
+}
+
+// screenshotsComplete reports whether every screenshot of a movie has an upload result
+func (s *SpoilerService) screenshotsComplete(movieID string, urls []string) bool {
+	count := s.movieScreenshotLimits(movieID).count
+	if len(urls) < count {
+		return false
+	}
+	for _, url := range urls[:count] {
+		if url == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// hasScreenshotUpload reports whether the screenshot at index is already uploaded to the host
+func (s *SpoilerService) hasScreenshotUpload(movieID, host string, index int) bool {
+	s.uploadsMu.Lock()
+	defer s.uploadsMu.Unlock()
+
+	movie, exists := s.getMovieByID(movieID)
+	if !exists || s.refreshAll[movieID] {
+		return false
+	}
+	uploads := movie.Uploads[host]
+	return uploads != nil && index < len(uploads.ScreenshotURLs) && uploads.ScreenshotURLs[index] != ""
+}
+
+// screenshotUploaded reports whether every screenshot host already has the screenshot at
+// index, in which case it does not need to be generated again
+func (s *SpoilerService) screenshotUploaded(movieID string, index int, uploaders []*activeUploader) bool {
+	for _, uploader := range uploaders {
+		if uploader.screenshots && !s.hasScreenshotUpload(movieID, uploader.Name(), index) {
+			return false
+		}
+	}
+	return true
+}
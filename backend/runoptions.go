@@ -0,0 +1,63 @@
+package backend
+
+import "fmt"
+
+// ProcessingOptions overrides settings for a single processing run without saving them.
+// Nil fields keep the configured value.
+type ProcessingOptions struct {
+	ScreenshotCount   *int     `json:"screenshotCount,omitempty"`
+	ScreenshotQuality *int     `json:"screenshotQuality,omitempty"`
+	Hosts             []string `json:"hosts,omitempty"` // Hosts allowed to upload, all when empty
+}
+
+// validateProcessingOptions checks the run overrides without applying them
+func (s *SpoilerService) validateProcessingOptions(options *ProcessingOptions) error {
+	if options == nil {
+		return nil
+	}
+	if count := options.ScreenshotCount; count != nil && (*count < 0 || *count > 20) {
+		return fmt.Errorf("screenshot count must be between 0 and 20")
+	}
+	if quality := options.ScreenshotQuality; quality != nil && (*quality < 1 || *quality > 31) {
+		return fmt.Errorf("screenshot quality must be between 1 and 31")
+	}
+	_, err := s.parseHostFilter(options.Hosts)
+	return err
+}
+
+// applyProcessingOptions validates and applies the run overrides. The configured settings are
+// staged like a settings change during processing, so they are restored when the run
+// finishes. The returned function restores the host filter.
+func (s *SpoilerService) applyProcessingOptions(options *ProcessingOptions) (func(), error) {
+	if err := s.validateProcessingOptions(options); err != nil {
+		return nil, err
+	}
+	if options == nil {
+		return func() {}, nil
+	}
+
+	savedFilter := s.hostFilter
+	if len(options.Hosts) > 0 {
+		if err := s.setHostFilter(options.Hosts); err != nil {
+			return nil, err
+		}
+	}
+
+	s.settingsMu.Lock()
+	if s.pendingSettings == nil {
+		configured := s.settings
+		s.pendingSettings = &configured
+	}
+	s.settingsMu.Unlock()
+
+	if options.ScreenshotCount != nil {
+		s.settings.ScreenshotCount = *options.ScreenshotCount
+	}
+	if options.ScreenshotQuality != nil {
+		s.settings.ScreenshotQuality = *options.ScreenshotQuality
+	}
+
+	return func() {
+		s.hostFilter = savedFilter
+	}, nil
+}
@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Processing orders for pending movies
+const (
+	ProcessingOrderList     = "list"     // Order of the movie list
+	ProcessingOrderSmallest = "smallest" // Smallest files first
+	ProcessingOrderShortest = "shortest" // Shortest durations first
+	ProcessingOrderPriority = "priority" // Highest user-defined priority first
+)
+
+func isValidProcessingOrder(order string) bool {
+	switch order {
+	case ProcessingOrderList, ProcessingOrderSmallest, ProcessingOrderShortest, ProcessingOrderPriority:
+		return true
+	}
+	return false
+}
+
+// SetMoviePriority sets the user-defined priority used by the "priority" processing order
+func (s *SpoilerService) SetMoviePriority(id string, priority int) error {
+	if !s.updateMovieByID(id, func(m *Movie) { m.Priority = priority }) {
+		return fmt.Errorf("movie with ID %s not found", id)
+	}
+	s.emitState()
+	return nil
+}
+
+// prioritizeMovies returns the movies in the order they should be started.
+// Ties keep their list order.
+func (s *SpoilerService) prioritizeMovies(movies []Movie) []Movie {
+	ordered := append([]Movie{}, movies...)
+
+	switch s.settings.ProcessingOrder {
+	case ProcessingOrderSmallest:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].FileSizeBytes < ordered[j].FileSizeBytes
+		})
+	case ProcessingOrderShortest:
+		// Movies with unknown duration go last
+		sort.SliceStable(ordered, func(i, j int) bool {
+			a, b := ordered[i].DurationSeconds, ordered[j].DurationSeconds
+			if a <= 0 || b <= 0 {
+				return a > 0 && b <= 0
+			}
+			return a < b
+		})
+	case ProcessingOrderPriority:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].Priority > ordered[j].Priority
+		})
+	}
+
+	return ordered
+}
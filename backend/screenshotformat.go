@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Screenshot image formats
+const (
+	ScreenshotFormatJPEG = "jpeg" // Quality set by ScreenshotQuality, the default
+	ScreenshotFormatPNG  = "png"  // Lossless, required by some trackers
+	ScreenshotFormatWebP = "webp" // Lossy by WebPQuality, or lossless
+)
+
+func isValidScreenshotFormat(format string) bool {
+	return format == ScreenshotFormatJPEG || format == ScreenshotFormatPNG || format == ScreenshotFormatWebP
+}
+
+// screenshotExtension returns the file extension of the configured screenshot format
+func (s *SpoilerService) screenshotExtension() string {
+	switch s.settings.ScreenshotFormat {
+	case ScreenshotFormatPNG:
+		return ".png"
+	case ScreenshotFormatWebP:
+		return ".webp"
+	default:
+		return ".jpg"
+	}
+}
+
+// imageEncodeArgs returns the ffmpeg encoder arguments for an image, chosen by its extension
+// so re-encoded contact sheets stay JPEG whatever the screenshot format
+func (s *SpoilerService) imageEncodeArgs(path string, limits screenshotLimits) []string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return []string{"-c:v", "png", "-pix_fmt", "rgb24"}
+	case ".webp":
+		if s.settings.WebPLossless {
+			return []string{"-c:v", "libwebp", "-lossless", "1"}
+		}
+		return []string{"-c:v", "libwebp", "-lossless", "0", "-quality", fmt.Sprintf("%d", limits.webPQuality)}
+	default:
+		return []string{"-q:v", fmt.Sprintf("%d", limits.quality)}
+	}
+}
@@ -0,0 +1,107 @@
+package backend
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Split multi-part files: "movie.mkv.001", "movie.mkv.002" and DVD title sets
+// "VTS_01_1.VOB", "VTS_01_2.VOB". VTS_xx_0.VOB is the menu and stays a movie of its own.
+var (
+	numberedPartPattern = regexp.MustCompile(`^(.+)\.(\d{3})$`)
+	vobPartPattern      = regexp.MustCompile(`(?i)^(VTS_\d{2})_([1-9]\d?)\.VOB$`)
+)
+
+// segmentPart identifies a file as part of a split movie
+type segmentPart struct {
+	key    string // Full path of the logical movie, shared by all its parts
+	name   string // File name of the logical movie
+	number int
+}
+
+func parseSegmentPart(path string) (segmentPart, bool) {
+	dir, base := filepath.Split(path)
+	if parts := numberedPartPattern.FindStringSubmatch(base); parts != nil {
+		number, _ := strconv.Atoi(parts[2])
+		return segmentPart{key: filepath.Join(dir, parts[1]), name: parts[1], number: number}, true
+	}
+	if parts := vobPartPattern.FindStringSubmatch(base); parts != nil {
+		number, _ := strconv.Atoi(parts[2])
+		name := strings.ToUpper(parts[1]) + filepath.Ext(base)
+		return segmentPart{key: filepath.Join(dir, name), name: name, number: number}, true
+	}
+	return segmentPart{}, false
+}
+
+// segmentGroup is a logical movie made of one or more files
+type segmentGroup struct {
+	name  string // Empty for a single file
+	paths []string
+}
+
+// groupSegments joins the parts of split files into one group per logical movie, in the
+// order their first part appears. A lone part stays a regular single-file movie.
+func groupSegments(paths []string) []segmentGroup {
+	var groups []segmentGroup
+	byKey := make(map[string]int)
+	numbers := make(map[string][]int)
+
+	for _, path := range paths {
+		part, ok := parseSegmentPart(path)
+		if !ok {
+			groups = append(groups, segmentGroup{paths: []string{path}})
+			continue
+		}
+
+		index, exists := byKey[part.key]
+		if !exists {
+			index = len(groups)
+			byKey[part.key] = index
+			groups = append(groups, segmentGroup{name: part.name})
+		}
+
+		// Keep the parts ordered by their number
+		at := len(numbers[part.key])
+		for i, number := range numbers[part.key] {
+			if part.number < number {
+				at = i
+				break
+			}
+		}
+		numbers[part.key] = slices.Insert(numbers[part.key], at, part.number)
+		groups[index].paths = slices.Insert(groups[index].paths, at, path)
+	}
+
+	for i := range groups {
+		if len(groups[i].paths) == 1 {
+			groups[i].name = ""
+		}
+	}
+	return groups
+}
+
+// mediaInput is what ffmpeg and ffprobe read for the movie. Split movies are joined with the
+// concat protocol, so timestamps and the duration span all segments.
+func (m Movie) mediaInput() string {
+	if len(m.Segments) > 1 {
+		return "concat:" + strings.Join(m.Segments, "|")
+	}
+	return m.FilePath
+}
+
+// segmentsDuration sums the durations of a split movie's segments, 0 when a segment cannot
+// be probed on its own, e.g. a byte-split container whose header is only in the first part
+func segmentsDuration(segments []string) float64 {
+	total := 0.0
+	for _, segment := range segments {
+		duration, err := ProbeDuration(segment)
+		if err != nil || duration <= 0 {
+			return 0
+		}
+		total += duration
+	}
+	return total
+}
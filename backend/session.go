@@ -0,0 +1,169 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// sessionSaveDelay batches bursts of state changes into a single write
+const sessionSaveDelay = 2 * time.Second
+
+// Session is the movie list saved between restarts
+type Session struct {
+	Movies  []Movie      `json:"movies"`
+	Groups  []MovieGroup `json:"groups"`
+	SavedAt time.Time    `json:"savedAt"`
+}
+
+// SessionStore persists the movie list in the config directory so a crash or restart
+// does not lose a batch
+type SessionStore struct {
+	mu       sync.Mutex // Guards the timer
+	writeMu  sync.Mutex // Serializes writes, never held together with mu
+	path     string
+	timer    *time.Timer
+	snapshot func() ([]byte, error) // Encodes the current session
+}
+
+func NewSessionStore(snapshot func() ([]byte, error)) *SessionStore {
+	return &SessionStore{
+		path:     filepath.Join(getConfigDir(), "session.json"),
+		snapshot: snapshot,
+	}
+}
+
+// Load reads the saved session
+func (st *SessionStore) Load() (Session, bool) {
+	var session Session
+	data, err := os.ReadFile(st.path)
+	if err != nil {
+		return session, false
+	}
+	if err := json.Unmarshal(data, &session); err != nil {
+		log.Printf("Failed to parse saved session: %v", err)
+		return session, false
+	}
+	return session, true
+}
+
+// Schedule saves the session once no further change arrives within sessionSaveDelay
+func (st *SessionStore) Schedule() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.timer != nil {
+		st.timer.Stop()
+	}
+	st.timer = time.AfterFunc(sessionSaveDelay, func() {
+		if err := st.Flush(); err != nil {
+			log.Printf("Failed to save session: %v", err)
+		}
+	})
+}
+
+// Flush writes the current session immediately, through a temp file so a crash never
+// leaves it truncated
+func (st *SessionStore) Flush() error {
+	st.mu.Lock()
+	if st.timer != nil {
+		st.timer.Stop()
+		st.timer = nil
+	}
+	st.mu.Unlock()
+
+	st.writeMu.Lock()
+	defer st.writeMu.Unlock()
+
+	data, err := st.snapshot()
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(st.path), 0755); err != nil {
+		return fmt.Errorf("failed to create session directory: %v", err)
+	}
+
+	tmpPath := st.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, st.path)
+}
+
+// RestoreSession loads the movie list saved by the previous run and keeps it saved from now
+// on. Movies that were interrupted mid-processing are reset to pending with their upload
+// results kept, so a later run only redoes what is missing.
+func (s *SpoilerService) RestoreSession() {
+	s.session = NewSessionStore(s.sessionSnapshot)
+
+	session, ok := s.session.Load()
+	if !ok {
+		return
+	}
+
+	movies := make([]Movie, 0, len(session.Movies))
+	for _, movie := range session.Movies {
+		if _, err := os.Stat(movie.FilePath); err != nil {
+			log.Printf("Dropping %s from the restored session: %v", movie.FileName, err)
+			continue
+		}
+		if movie.ProcessingState == StateAnalyzingMedia {
+			log.Printf("Dropping %s from the restored session: media analysis did not finish", movie.FileName)
+			continue
+		}
+		if !movie.ProcessingState.IsFinished() {
+			movie.ProcessingState = StatePending
+		}
+		if movie.Uploads == nil {
+			movie.Uploads = make(map[string]*HostUploads)
+		}
+		if movie.Params == nil {
+			movie.Params = make(map[string]string)
+		}
+		normalizeMediaNumbers(&movie)
+		setReleaseParams(&movie)
+		movies = append(movies, movie)
+	}
+
+	s.movies = movies
+	if session.Groups != nil {
+		s.groups = session.Groups
+	}
+	log.Printf("Restored %d movies from the previous session", len(movies))
+}
+
+// ServiceShutdown stops the config watcher and writes pending session changes before the app exits
+func (s *SpoilerService) ServiceShutdown() error {
+	if s.stopConfigWatch != nil {
+		s.stopConfigWatch()
+	}
+	if s.session == nil {
+		return nil
+	}
+	return s.session.Flush()
+}
+
+// sessionSnapshot encodes the movie list, guarded against concurrent upload result updates
+func (s *SpoilerService) sessionSnapshot() ([]byte, error) {
+	s.uploadsMu.Lock()
+	defer s.uploadsMu.Unlock()
+	s.moviesMu.Lock()
+	defer s.moviesMu.Unlock()
+
+	return json.Marshal(Session{
+		Movies:  s.movies,
+		Groups:  s.groups,
+		SavedAt: time.Now(),
+	})
+}
+
+// scheduleSessionSave persists the movie list shortly after a change, when sessions are enabled
+func (s *SpoilerService) scheduleSessionSave() {
+	if s.session != nil {
+		s.session.Schedule()
+	}
+}
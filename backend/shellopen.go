@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// openWithDefaultApp opens a file or folder with the handler the OS associates with it
+func openWithDefaultApp(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	case "darwin":
+		cmd = exec.Command("open", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %v", filepath.Base(path), err)
+	}
+	// Reap the launcher in the background, the opened app outlives it
+	go cmd.Wait()
+	return nil
+}
+
+// OpenResultInEditor writes the rendered result to a temp file and opens it in the default
+// text editor. Returns the path of the written file.
+func (s *SpoilerService) OpenResultInEditor() (string, error) {
+	file, err := os.CreateTemp("", "spoilr_result_*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %v", err)
+	}
+	path := file.Name()
+	file.Close()
+
+	if err := s.ExportResult(path); err != nil {
+		return "", err
+	}
+	if err := openWithDefaultApp(path); err != nil {
+		return path, err
+	}
+	return path, nil
+}
+
+// revealInFileManager opens the folder containing path, selecting the file where the OS supports it
+func revealInFileManager(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("explorer", "/select,", path)
+	case "darwin":
+		cmd = exec.Command("open", "-R", path)
+	default:
+		cmd = exec.Command("xdg-open", filepath.Dir(path))
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open the folder of %s: %v", filepath.Base(path), err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// movieFilePath returns the path of a movie that still exists on disk
+func (s *SpoilerService) movieFilePath(movieID string) (string, error) {
+	movie, exists := s.getMovieByID(movieID)
+	if !exists {
+		return "", fmt.Errorf("movie with ID %s not found", movieID)
+	}
+	if _, err := os.Stat(movie.FilePath); err != nil {
+		return "", fmt.Errorf("file not found: %v", err)
+	}
+	return movie.FilePath, nil
+}
+
+// RevealInFileManager shows the movie file in the OS file manager
+func (s *SpoilerService) RevealInFileManager(movieID string) error {
+	path, err := s.movieFilePath(movieID)
+	if err != nil {
+		return err
+	}
+	return revealInFileManager(path)
+}
+
+// PlayFile opens the movie file in the default video player
+func (s *SpoilerService) PlayFile(movieID string) error {
+	path, err := s.movieFilePath(movieID)
+	if err != nil {
+		return err
+	}
+	return openWithDefaultApp(path)
+}
@@ -0,0 +1,149 @@
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math/bits"
+	"os/exec"
+	"strconv"
+)
+
+const (
+	frameHashMaxDistance = 10  // Differing bits of two frames that still count as the same picture
+	frameHashMinContrast = 8   // Gray level spread below which a frame is flat and not hashed
+	similarFramesRatio   = 0.8 // Share of the smaller screenshot set that must match
+	similarFramesMin     = 3   // Matching frames needed before movies are compared at all
+)
+
+// frameHash computes the difference hash of an image: ffmpeg scales it to 9x8 gray pixels and
+// each bit tells whether a pixel is darker than its right neighbour. Flat frames, e.g. black
+// fades, return false as every such frame hashes alike.
+func (s *SpoilerService) frameHash(path string) (uint64, bool, error) {
+	cmd := exec.CommandContext(s.cancelCtx, toolPath("ffmpeg"),
+		"-hide_banner", "-loglevel", "error",
+		"-i", path,
+		"-vf", "scale=9:8:flags=area,format=gray",
+		"-frames:v", "1",
+		"-f", "rawvideo", "-",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	pixels, err := cmd.Output()
+	if err != nil {
+		return 0, false, fmt.Errorf("ffmpeg command failed: %v\nOutput: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	if len(pixels) < 72 {
+		return 0, false, fmt.Errorf("unexpected frame size %d", len(pixels))
+	}
+
+	lowest, highest := pixels[0], pixels[0]
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			left, right := pixels[y*9+x], pixels[y*9+x+1]
+			if left < right {
+				hash |= 1 << (y*8 + x)
+			}
+			lowest, highest = min(lowest, left, right), max(highest, left, right)
+		}
+	}
+	return hash, int(highest)-int(lowest) >= frameHashMinContrast, nil
+}
+
+// hashScreenshot records the frame hash of a generated screenshot, before the watermark makes
+// every screenshot share a corner
+func (s *SpoilerService) hashScreenshot(movie Movie, path string, index int) {
+	if s.settings.DisableSimilarityCheck {
+		return
+	}
+	hash, ok, err := s.frameHash(path)
+	if err != nil {
+		log.Printf("Failed to hash screenshot %d of %s: %v", index+1, movie.FileName, err)
+		return
+	}
+
+	s.updateMovieByID(movie.ID, func(m *Movie) {
+		s.ensureScreenshotSliceSize(&m.FrameHashes, index)
+		if ok {
+			m.FrameHashes[index] = strconv.FormatUint(hash, 16)
+		}
+	})
+}
+
+// matchingFrames counts the frames of a that have a near-identical frame in b
+func matchingFrames(a, b []uint64) int {
+	matches := 0
+	for _, hashA := range a {
+		for _, hashB := range b {
+			if bits.OnesCount64(hashA^hashB) <= frameHashMaxDistance {
+				matches++
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// parseFrameHashes returns the hashes of the non-flat screenshots
+func parseFrameHashes(hashes []string) []uint64 {
+	var parsed []uint64
+	for _, hash := range hashes {
+		if value, err := strconv.ParseUint(hash, 16, 64); err == nil && hash != "" {
+			parsed = append(parsed, value)
+		}
+	}
+	return parsed
+}
+
+// moviesSimilar reports whether most screenshots of the smaller set reappear in the other one
+func moviesSimilar(a, b []uint64) bool {
+	smaller, larger := a, b
+	if len(b) < len(a) {
+		smaller, larger = b, a
+	}
+	if len(smaller) < similarFramesMin {
+		return false
+	}
+	matches := matchingFrames(smaller, larger)
+	return matches >= similarFramesMin && float64(matches) >= similarFramesRatio*float64(len(smaller))
+}
+
+// checkSimilarMovies warns when a finished movie's screenshots nearly match those of another
+// movie in the list, which usually means the same file or another cut was dropped twice
+func (s *SpoilerService) checkSimilarMovies(movieID string) {
+	if s.settings.DisableSimilarityCheck {
+		return
+	}
+	movie, exists := s.getMovieByID(movieID)
+	if !exists {
+		return
+	}
+	hashes := parseFrameHashes(movie.FrameHashes)
+	if len(hashes) < similarFramesMin {
+		return
+	}
+
+	s.moviesMu.Lock()
+	others := make([]Movie, 0, len(s.movies))
+	for _, other := range s.movies {
+		if other.ID != movieID && len(other.FrameHashes) > 0 {
+			others = append(others, other)
+		}
+	}
+	s.moviesMu.Unlock()
+
+	for _, other := range others {
+		if !moviesSimilar(hashes, parseFrameHashes(other.FrameHashes)) {
+			continue
+		}
+		log.Printf("%s and %s have nearly identical screenshots", movie.FileName, other.FileName)
+		s.addMovieWarning(movieID, fmt.Sprintf("Screenshots nearly identical to %s, check that the right files were added", other.FileName))
+		if s.app != nil {
+			s.app.Event.Emit("similar-movies", map[string]any{
+				"movieId":   movieID,
+				"similarId": other.ID,
+			})
+		}
+	}
+}
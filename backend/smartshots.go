@@ -0,0 +1,179 @@
+package backend
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math/rand/v2"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Screenshot timestamp modes
+const (
+	ScreenshotModeUniform = "uniform" // Evenly spaced over the whole video, the default
+	ScreenshotModeSmart   = "smart"   // Skips intro and credits, avoids black frames and scene transitions
+)
+
+const (
+	// Smart mode spreads the screenshots over this part of the video, skipping intro and credits
+	smartStartFraction = 0.03
+	smartEndFraction   = 0.92
+
+	smartMaxWindow     = 10.0 // Seconds analyzed after each target timestamp
+	smartSceneSettle   = 0.5  // Seconds after a scene change, past the transition
+	smartCandidateStep = 0.5  // Spacing of fallback candidates inside the window
+)
+
+var (
+	blackFramePattern = regexp.MustCompile(`\[Parsed_blackframe_\d+[^\]]*\].*? t:([\d.]+)`)
+	sceneFramePattern = regexp.MustCompile(`\[Parsed_showinfo_\d+[^\]]*\].*? pts_time:([\d.]+)`)
+)
+
+// isValidScreenshotMode reports whether mode is a known screenshot timestamp mode
+func isValidScreenshotMode(mode string) bool {
+	return mode == ScreenshotModeUniform || mode == ScreenshotModeSmart
+}
+
+// parseScreenshotOffset parses an offset like "5%" or "90" / "90s" into seconds of a video
+// with the given duration. An empty offset is not set.
+func parseScreenshotOffset(value string, duration float64) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		p, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil || p < 0 || p >= 100 {
+			return 0, false
+		}
+		return duration * p / 100, true
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "s")), 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return seconds, true
+}
+
+// isValidScreenshotOffset reports whether value is empty or a valid percent or seconds offset
+func isValidScreenshotOffset(value string) bool {
+	_, ok := parseScreenshotOffset(value, 1)
+	return ok || strings.TrimSpace(value) == ""
+}
+
+// screenshotRange returns the part of the video screenshots are taken from. The configured
+// start and end offsets apply in both modes; without them smart mode skips intro and credits
+// on its own. Offsets that leave nothing of a short video are ignored.
+func (s *SpoilerService) screenshotRange(duration float64) (float64, float64) {
+	start, end := 0.0, duration
+	if s.settings.ScreenshotMode == ScreenshotModeSmart {
+		start, end = duration*smartStartFraction, duration*smartEndFraction
+	}
+	if offset, ok := parseScreenshotOffset(s.settings.ScreenshotStartOffset, duration); ok {
+		start = offset
+	}
+	if offset, ok := parseScreenshotOffset(s.settings.ScreenshotEndOffset, duration); ok {
+		end = duration - offset
+	}
+	if end <= start {
+		return 0, duration
+	}
+	return start, end
+}
+
+// spreadTimestamps spreads count screenshots evenly between start and end
+func spreadTimestamps(start, end float64, count int) []float64 {
+	interval := (end - start) / float64(count+1)
+	timestamps := make([]float64, count)
+	for i := range timestamps {
+		timestamps[i] = start + interval*float64(i+1)
+	}
+	return timestamps
+}
+
+// refineTimestamp analyzes the seconds after a target timestamp with ffmpeg's scene change and
+// blackframe filters and returns a moment just after a scene change that is not black. The
+// target is returned unchanged when the analysis fails or finds nothing better.
+func (s *SpoilerService) refineTimestamp(movie Movie, timestamp float64) float64 {
+	count := max(s.movieScreenshotLimits(movie.ID).count, 1)
+	start, end := s.screenshotRange(movie.DurationSeconds)
+	window := min((end-start)/float64(count+1)*0.8, smartMaxWindow)
+	if window <= smartCandidateStep {
+		return timestamp
+	}
+
+	cmd := exec.CommandContext(s.cancelCtx, toolPath("ffmpeg"),
+		"-hide_banner",
+		"-ss", fmt.Sprintf("%.2f", timestamp),
+		"-t", fmt.Sprintf("%.2f", window),
+		"-i", movie.mediaInput(),
+		"-an", "-sn",
+		"-vf", "fps=4,blackframe=amount=95:threshold=32,select='gt(scene,0.3)',showinfo",
+		"-f", "null", "-",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Scene analysis failed for %s at %.2fs: %v", movie.FileName, timestamp, err)
+		return timestamp
+	}
+
+	blackTimes := parseFilterTimes(blackFramePattern, string(output))
+	isBlack := func(offset float64) bool {
+		for _, black := range blackTimes {
+			if black > offset-0.3 && black < offset+0.3 {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Prefer a fresh shot right after a scene change, then any moment that is not black
+	var candidates []float64
+	for _, scene := range parseFilterTimes(sceneFramePattern, string(output)) {
+		candidates = append(candidates, scene+smartSceneSettle)
+	}
+	for offset := 0.0; offset < window; offset += smartCandidateStep {
+		candidates = append(candidates, offset)
+	}
+
+	for _, offset := range candidates {
+		if offset < window && !isBlack(offset) {
+			return timestamp + offset
+		}
+	}
+	return timestamp
+}
+
+// parseFilterTimes extracts the frame times an ffmpeg filter logged
+func parseFilterTimes(pattern *regexp.Regexp, output string) []float64 {
+	var times []float64
+	for _, match := range pattern.FindAllStringSubmatch(output, -1) {
+		if t, err := strconv.ParseFloat(match[1], 64); err == nil {
+			times = append(times, t)
+		}
+	}
+	return times
+}
+
+// jitterTimestamps moves every timestamp randomly by up to ±jitter seconds, so re-posts of a
+// file don't produce byte-identical screenshots. The generator is seeded per movie, so
+// concurrent movies don't share state and a movie keeps its timestamps within a session.
+func jitterTimestamps(timestamps []float64, movie Movie, jitter float64) []float64 {
+	if jitter <= 0 {
+		return timestamps
+	}
+
+	seed := fnv.New64a()
+	seed.Write([]byte(movie.ID))
+	rng := rand.New(rand.NewPCG(seed.Sum64(), uint64(len(timestamps))))
+
+	jittered := make([]float64, len(timestamps))
+	for i, timestamp := range timestamps {
+		offset := (rng.Float64()*2 - 1) * jitter
+		jittered[i] = min(max(timestamp+offset, 0), max(movie.DurationSeconds-1, 0))
+	}
+	return jittered
+}
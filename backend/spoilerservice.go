@@ -9,39 +9,53 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
-	"spoilr/backend/img_uploaders"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
 type SpoilerService struct {
-	app                 *application.App
-	movies              []Movie
-	settings            AppSettings
-	processing          bool
-	cancelCtx           context.Context
-	cancelFn            context.CancelFunc
-	screenshotSemaphore chan struct{} // Limits concurrent screenshot generation
-	uploadSemaphore     chan struct{} // Limits concurrent uploads
-	configManager       *ConfigService
-}
-
-// UploaderRequirements tracks what uploaders are needed based on template
-type UploaderRequirements struct {
-	NeedsFastpic bool
-	NeedsImgbox  bool
-	NeedsHamster bool
-
-	FastpicContactSheet bool
-	FastpicScreenshots  bool
-	ImgboxContactSheet  bool
-	ImgboxScreenshots   bool
-	HamsterContactSheet bool
-	HamsterScreenshots  bool
+	app                   *application.App
+	movies                []Movie
+	groups                []MovieGroup
+	dropContext           DropContext // Where the next dropped files should go
+	dropMu                sync.Mutex  // Guards dropContext, set and taken from frontend calls
+	settings              AppSettings
+	pendingSettings       *AppSettings // Settings saved during processing, applied once the run finishes
+	settingsMu            sync.Mutex   // Guards pendingSettings and the end of a run
+	processing            bool
+	cancelCtx             context.Context
+	cancelFn              context.CancelFunc
+	cancelled             atomic.Bool   // Set by CancelProcessing, failures after it are not errors
+	runMovieIDs           []string      // Movies of the current run
+	screenshotSemaphore   chan struct{} // Limits concurrent screenshot extraction
+	contactSheetSemaphore chan struct{} // Limits concurrent contact sheet generation, apart from screenshots
+	configManager         *ConfigService
+	stats                 *StatsStore
+	artifacts             *artifactTracker // Size of generated media and upload buffers
+	queue                 *queueTracker    // Jobs waiting for and holding slots, see GetQueueSnapshot
+	uploadHistory         *UploadHistory   // Completed uploads keyed by content hash
+	uploadCache           *UploadCache     // Upload results keyed by video fingerprint and host
+	degradation           *degradePolicy   // Upload rejections that lower the settings of the rest of a run
+	movieHistory          *MovieHistory    // Results of processed movies keyed by file fingerprint
+	uploaders             []*hostUploader
+	uploadsMu             sync.Mutex // Guards the per-host upload results of movies
+	moviesMu              sync.Mutex // Guards movie updates against the list growing while movies are processed
+	timelines             *movieTimelines
+	session               *SessionStore                     // Saves the movie list between restarts, nil in CLI mode
+	presetOverride        string                            // Preset ID used instead of the saved current preset, set per CLI run
+	hostFilter            []string                          // Hosts allowed to upload in this run, all when empty
+	refreshSources        map[string]map[string]HostUploads // Previous uploads of the movies of an upload refresh, nil otherwise
+	refreshAll            map[string]bool                   // Movies RefreshUploads uploads every image of again, despite their links
+	retainedMedia         map[string]retainedMedia          // Kept images of movies re-running only their uploads, see RetryUploads
+	stopConfigWatch       func()                            // Stops the config hot-reload, nil when not watching
+	launch                launchQueue                       // Files opened before the window was ready, see OpenFiles
 }
 
 func NewSpoilerService() *SpoilerService {
@@ -49,29 +63,101 @@ func NewSpoilerService() *SpoilerService {
 	config := configManager.GetConfig()
 
 	service := &SpoilerService{
-		movies: make([]Movie, 0),
-		settings: AppSettings{
-			ScreenshotCount:          config.ScreenshotCount,
-			FastpicSID:               config.FastpicSID,
-			ScreenshotQuality:        config.ScreenshotQuality,
-			MaxConcurrentScreenshots: config.MaxConcurrentScreenshots,
-			MaxConcurrentUploads:     config.MaxConcurrentUploads,
-			MtnArgs:                  config.MtnArgs,
-			ImageMiniatureSize:       config.ImageMiniatureSize,
-			HamsterEmail:             config.HamsterEmail,
-			HamsterPassword:          config.HamsterPassword,
-		},
+		movies:        make([]Movie, 0),
+		groups:        make([]MovieGroup, 0),
+		settings:      settingsFromConfig(config),
 		processing:    false,
 		configManager: configManager,
+		stats:         NewStatsStore(),
+		artifacts:     newArtifactTracker(),
+		queue:         newQueueTracker(),
+		uploadHistory: NewUploadHistory(),
+		uploadCache:   NewUploadCache(),
+		degradation:   newDegradePolicy(),
+		movieHistory:  NewMovieHistory(),
+		timelines:     newMovieTimelines(),
 	}
 
 	service.initSemaphores()
+	service.buildUploaders()
 	return service
 }
 
+// settingsFromConfig maps the saved config to the app settings
+func settingsFromConfig(config SpoilerConfig) AppSettings {
+	return AppSettings{
+		ScreenshotCount:            config.ScreenshotCount,
+		FastpicSID:                 config.FastpicSID,
+		FastpicDeleteAfterDays:     config.FastpicDeleteAfterDays,
+		FastpicOrigResize:          config.FastpicOrigResize,
+		FastpicOptimization:        config.FastpicOptimization,
+		FastpicBaseURL:             config.FastpicBaseURL,
+		FastpicMirrors:             config.FastpicMirrors,
+		ScreenshotQuality:          config.ScreenshotQuality,
+		MaxConcurrentScreenshots:   config.MaxConcurrentScreenshots,
+		MaxConcurrentContactSheets: config.MaxConcurrentContactSheets,
+		MaxConcurrentUploads:       config.MaxConcurrentUploads,
+		MtnArgs:                    config.MtnArgs,
+		ImageMiniatureSize:         config.ImageMiniatureSize,
+		FastpicMiniatureSize:       config.FastpicMiniatureSize,
+		ImgboxMiniatureSize:        config.ImgboxMiniatureSize,
+		AnonymizeUploads:           config.AnonymizeUploads,
+		RenamePattern:              config.RenamePattern,
+		ReadOnlySources:            config.ReadOnlySources,
+		GroupHeaderTemplate:        config.GroupHeaderTemplate,
+		NestGroupSpoilers:          config.NestGroupSpoilers,
+		CollectionSpoilerTemplate:  config.CollectionSpoilerTemplate,
+		OutputLineEnding:           config.OutputLineEnding,
+		OutputBOM:                  config.OutputBOM,
+		SpoilerTitleMaxLength:      config.SpoilerTitleMaxLength,
+		PipelinedUploads:           config.PipelinedUploads,
+		ProcessingOrder:            config.ProcessingOrder,
+		ImgboxFamilySafe:           config.ImgboxFamilySafe,
+		HamsterResizeWidth:         config.HamsterResizeWidth,
+		HamsterExpiration:          config.HamsterExpiration,
+		CatboxUserHash:             config.CatboxUserHash,
+		CatboxTemporary:            config.CatboxTemporary,
+		LitterboxExpiry:            config.LitterboxExpiry,
+		BatchHeaderTemplate:        config.BatchHeaderTemplate,
+		BatchFooterTemplate:        config.BatchFooterTemplate,
+		ComputeChecksums:           config.ComputeChecksums,
+		MaxTempUsageMB:             config.MaxTempUsageMB,
+		ScreenshotMode:             config.ScreenshotMode,
+		ScreenshotJitterSeconds:    config.ScreenshotJitterSeconds,
+		ScreenshotStartOffset:      config.ScreenshotStartOffset,
+		ScreenshotEndOffset:        config.ScreenshotEndOffset,
+		BBCodeDialect:              config.BBCodeDialect,
+		WatermarkText:              config.WatermarkText,
+		WatermarkImage:             config.WatermarkImage,
+		WatermarkPosition:          config.WatermarkPosition,
+		WatermarkOpacity:           config.WatermarkOpacity,
+		ScreenshotFormat:           config.ScreenshotFormat,
+		WebPQuality:                config.WebPQuality,
+		WebPLossless:               config.WebPLossless,
+		HostUploadLimits:           config.HostUploadLimits,
+		ResultFooterEnabled:        config.ResultFooterEnabled,
+		ResultFooterTemplate:       config.ResultFooterTemplate,
+		DisableSimilarityCheck:     config.DisableSimilarityCheck,
+		LocalOutputDir:             config.LocalOutputDir,
+		DisableUploadCache:         config.DisableUploadCache,
+		TMDBAPIKey:                 config.TMDBAPIKey,
+		OMDbAPIKey:                 config.OMDbAPIKey,
+		MetadataLanguage:           config.MetadataLanguage,
+		DegradeOnUploadFailures:    config.DegradeOnUploadFailures,
+		DegradeAfterFailures:       config.DegradeAfterFailures,
+		DegradedScreenshotCount:    config.DegradedScreenshotCount,
+		DegradedQuality:            config.DegradedQuality,
+		ChecklistItems:             config.ChecklistItems,
+		AddTorrentVideos:           config.AddTorrentVideos,
+		GroupByFolder:              config.GroupByFolder,
+		HamsterEmail:               config.HamsterEmail,
+		HamsterPassword:            config.HamsterPassword,
+	}
+}
+
 func (s *SpoilerService) initSemaphores() {
 	s.screenshotSemaphore = make(chan struct{}, s.settings.MaxConcurrentScreenshots)
-	s.uploadSemaphore = make(chan struct{}, s.settings.MaxConcurrentUploads)
+	s.contactSheetSemaphore = make(chan struct{}, s.settings.MaxConcurrentContactSheets)
 }
 
 func (s *SpoilerService) SetApp(app *application.App) {
@@ -80,12 +166,38 @@ func (s *SpoilerService) SetApp(app *application.App) {
 
 func (s *SpoilerService) GetState() AppState {
 	return AppState{
-		Processing: s.processing,
-		Movies:     s.movies,
+		Processing:      s.processing,
+		Movies:          s.movies,
+		Groups:          s.groups,
+		ConfigLocked:    configLocked(),
+		ConfigEncrypted: configEncrypted(),
+		Summary:         summarizeMovies(s.movies),
 	}
 }
 
+// summarizeMovies counts the movies per outcome and their issues
+func summarizeMovies(movies []Movie) StateSummary {
+	summary := StateSummary{Total: len(movies)}
+	for _, movie := range movies {
+		switch movie.ProcessingState {
+		case StateCompleted:
+			summary.Completed++
+		case StateCompletedWithWarnings:
+			summary.CompletedWithWarnings++
+		case StateError:
+			summary.Failed++
+		}
+		if movie.CancelInfo != nil && movie.ProcessingState == StatePending {
+			summary.Cancelled++
+		}
+		summary.Warnings += len(movie.Warnings)
+		summary.Errors += len(movie.Errors)
+	}
+	return summary
+}
+
 func (s *SpoilerService) emitState() {
+	s.scheduleSessionSave()
 	if s.app != nil {
 		state := s.GetState()
 		s.app.Event.Emit("state", state)
@@ -93,74 +205,13 @@ func (s *SpoilerService) emitState() {
 }
 
 func (s *SpoilerService) GetDefaultTemplate() string {
-	return `[spoiler="%FILE_NAME% | %FILE_SIZE%"]
-File: %FILE_NAME%
-Size: %FILE_SIZE%
-Duration: %DURATION%
-Video: %VIDEO_CODEC% / %VIDEO_FPS% FPS / %WIDTH%x%HEIGHT% / %VIDEO_BIT_RATE%
-Audio: %AUDIO_CODEC% / %AUDIO_SAMPLE_RATE% / %AUDIO_CHANNELS% / %AUDIO_BIT_RATE%
-
-%CONTACT_SHEET_FP%
-
-%SCREENSHOTS_FP%
-[/spoiler]`
-}
-
-// getUploaderRequirements analyzes template to determine which uploaders are needed
-func (s *SpoilerService) getUploaderRequirements() UploaderRequirements {
-	req := UploaderRequirements{}
-
-	// Get current template from config
-	template := s.configManager.GetCurrentTemplate()
-
-	// Check what types of content are needed first
-	needsContactSheet := strings.Contains(template, "CONTACT_SHEET")
-	needsScreenshots := strings.Contains(template, "SCREENSHOTS")
-
-	// Early return if no image content is needed
-	if !needsContactSheet && !needsScreenshots {
-		return req
-	}
-
-	// Check for fastpic hosting suffix
-	if strings.Contains(template, "_FP_") || strings.Contains(template, "_FP%") {
-		req.NeedsFastpic = true
-		if needsContactSheet {
-			req.FastpicContactSheet = true
-		}
-		if needsScreenshots {
-			req.FastpicScreenshots = true
-		}
-	}
-
-	// Check for imgbox hosting suffix
-	if strings.Contains(template, "_IB_") || strings.Contains(template, "_IB%") {
-		req.NeedsImgbox = true
-		if needsContactSheet {
-			req.ImgboxContactSheet = true
-		}
-		if needsScreenshots {
-			req.ImgboxScreenshots = true
-		}
-	}
-
-	// Check for hamster hosting suffix
-	if strings.Contains(template, "_HAM_") || strings.Contains(template, "_HAM%") {
-		req.NeedsHamster = true
-		if needsContactSheet {
-			req.HamsterContactSheet = true
-		}
-		if needsScreenshots {
-			req.HamsterScreenshots = true
-		}
-	}
-
-	fmt.Printf("%+v\n", req)
-
-	return req
+	return getDefaultTemplate()
 }
 
 func (s *SpoilerService) updateMovieByID(id string, updateFn func(*Movie)) bool {
+	s.moviesMu.Lock()
+	defer s.moviesMu.Unlock()
+
 	for i := range s.movies {
 		if s.movies[i].ID == id {
 			updateFn(&s.movies[i])
@@ -171,6 +222,9 @@ func (s *SpoilerService) updateMovieByID(id string, updateFn func(*Movie)) bool
 }
 
 func (s *SpoilerService) getMovieByID(id string) (Movie, bool) {
+	s.moviesMu.Lock()
+	defer s.moviesMu.Unlock()
+
 	for _, movie := range s.movies {
 		if movie.ID == id {
 			return movie, true
@@ -180,46 +234,79 @@ func (s *SpoilerService) getMovieByID(id string) (Movie, bool) {
 }
 
 func (s *SpoilerService) AddMovies(filePaths []string) error {
+	_, _, err := s.addMovies(filePaths)
+	return err
+}
+
+// AnalysisFailure is a file that could not be analyzed and was not added
+type AnalysisFailure struct {
+	FilePath string `json:"filePath"`
+	Error    string `json:"error"`
+}
+
+// addMovies analyzes and adds the given files, returning the IDs of the added video files
+// and the files whose analysis failed. Files that are not videos are skipped silently.
+func (s *SpoilerService) addMovies(filePaths []string) ([]string, []AnalysisFailure, error) {
 	// First: expand all file paths without filtering
 	expandedPaths, err := s.GetExpandedFilePaths(filePaths)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	expandedPaths, torrents, failures := s.splitTorrents(expandedPaths)
 
 	if len(expandedPaths) == 0 {
-		return nil
+		return nil, failures, nil
 	}
 
-	// Emit all files as movies with analyzing state
+	// Emit all files as movies with analyzing state, split files become a single movie
 	var movieIDs []string
-	for _, path := range expandedPaths {
-		fileInfo, err := os.Stat(path)
-		if err != nil {
+	for _, group := range groupSegments(expandedPaths) {
+		var size int64
+		var segments []string
+		for _, path := range group.paths {
+			fileInfo, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			size += fileInfo.Size()
+			segments = append(segments, path)
+		}
+		if len(segments) == 0 {
 			continue
 		}
 
+		path := segments[0]
 		movie := Movie{
-			ID:                uuid.New().String(),
-			FileName:          filepath.Base(path),
-			FilePath:          path,
-			FileSize:          FormatFileSize(fileInfo.Size()),
-			FileSizeBytes:     fileInfo.Size(),
-			Params:            make(map[string]string),
-			ScreenshotURLs:    make([]string, 0),
-			ScreenshotURLsIB:  make([]string, 0),
-			ScreenshotURLsHam: make([]string, 0),
-			ProcessingState:   StateAnalyzingMedia,
+			ID:              uuid.New().String(),
+			FileName:        filepath.Base(path),
+			FilePath:        path,
+			FileSize:        FormatFileSize(size),
+			FileSizeBytes:   size,
+			Params:          make(map[string]string),
+			Uploads:         make(map[string]*HostUploads),
+			ProcessingState: StateAnalyzingMedia,
+		}
+		if len(segments) > 1 {
+			movie.FileName = group.name
+			movie.Segments = segments
+			log.Printf("Joined %d parts into %s", len(segments), group.name)
 		}
+		setTorrentParams(&movie, torrents)
 
+		s.moviesMu.Lock()
 		s.movies = append(s.movies, movie)
+		s.moviesMu.Unlock()
 		movieIDs = append(movieIDs, movie.ID)
 	}
 	s.emitState()
+	failures = append(failures, s.unmatchedTorrents(torrents, movieIDs)...)
 
 	// Second: check each file and remove non-video files
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var validMovieIDs []string
+	var previouslyProcessed []string
+	batcher := s.newAnalysisBatcher(len(movieIDs))
 
 	for _, movieID := range movieIDs {
 		wg.Add(1)
@@ -228,48 +315,137 @@ func (s *SpoilerService) AddMovies(filePaths []string) error {
 
 			movie, exists := s.getMovieByID(id)
 			if !exists {
+				batcher.removed(id)
 				return
 			}
 
-			mediaInfo, isVideo, err := GetVideoMediaInfo(movie.FilePath)
+			s.recordEvent(id, "analysis", "Media analysis started", nil)
+			mediaInfo, isVideo, err := GetVideoMediaInfo(movie.mediaInput())
+
+			var fields map[string]string
+			var fingerprint string
+			var previousRun *PreviousRun
+			var segmentsDur float64
+			var subtitles []ExternalSubtitle
+			var nfoPath, nfo string
+			var hdrFormat string
+			if isVideo && err == nil {
+				subtitles = findExternalSubtitles(movie.FilePath, movie.FileName)
+				if nfoPath = findNFO(movie.FilePath); nfoPath != "" {
+					var nfoErr error
+					if nfo, nfoErr = readNFO(nfoPath); nfoErr != nil {
+						log.Printf("Failed to read NFO of %s: %v", movie.FileName, nfoErr)
+						nfoPath = ""
+					}
+				}
+				if len(movie.Segments) > 1 {
+					segmentsDur = segmentsDuration(movie.Segments)
+				}
+
+				var fieldsErr error
+				if fields, fieldsErr = GetMediaInfoFields(movie.FilePath); fieldsErr != nil {
+					log.Printf("Failed to read mediainfo fields of %s: %v", movie.FileName, fieldsErr)
+				}
+				hdrFormat = detectHDRFormat(movie.FilePath, mediaInfo, fields)
+				// The analysis already read the container and stream durations
+				if segmentsDur <= 0 && parseDurationSeconds(mediaInfo) <= 0 {
+					log.Printf("Could not determine duration of %s", movie.FileName)
+				}
+
+				var fingerprintErr error
+				if fingerprint, fingerprintErr = fileFingerprint(movie.FilePath); fingerprintErr != nil {
+					log.Printf("Failed to fingerprint %s: %v", movie.FileName, fingerprintErr)
+				}
+				if record, matchedBy, found := s.movieHistory.Lookup(fingerprint, movie.FilePath); found {
+					previousRun = &PreviousRun{FilePath: record.FilePath, ProcessedAt: record.ProcessedAt, MatchedBy: matchedBy}
+					log.Printf("%s was already processed on %s (matched by %s)", movie.FileName, record.ProcessedAt.Format(time.DateTime), matchedBy)
+				}
+			}
 
 			mu.Lock()
 			defer mu.Unlock()
 
 			if !isVideo || err != nil {
 				// Remove non-video file
+				s.moviesMu.Lock()
 				for i, m := range s.movies {
 					if m.ID == id {
 						s.movies = append(s.movies[:i], s.movies[i+1:]...)
 						break
 					}
 				}
+				s.moviesMu.Unlock()
 				if err != nil {
 					log.Printf("Failed to analyze media %s: %v", movie.FileName, err)
+					failures = append(failures, AnalysisFailure{FilePath: movie.FilePath, Error: err.Error()})
 				} else {
 					log.Printf("Skipped non-video file: %s", movie.FileName)
 				}
+				s.timelines.remove(id)
+				batcher.removed(id)
 			} else {
 				// Update video file with media info
 				s.updateMovieByID(id, func(m *Movie) {
 					ExtractMediaInfo(m, mediaInfo)
+					setReleaseParams(m)
+					for key, value := range fields {
+						m.Params[key] = value
+					}
+					m.Fingerprint = fingerprint
+					m.PreviousRun = previousRun
+					m.ExternalSubtitles = subtitles
+					m.NFOPath = nfoPath
+					m.NFO = nfo
+					m.HDRFormat = hdrFormat
+					if segmentsDur > 0 {
+						m.DurationSeconds = segmentsDur
+						m.DurationFormatted = FormatDuration(time.Duration(segmentsDur * float64(time.Second)))
+					}
 					m.ProcessingState = StatePending
 				})
+				s.recordEvent(id, "analysis", "Media analysis finished", nil)
 				validMovieIDs = append(validMovieIDs, id)
+				if previousRun != nil {
+					previouslyProcessed = append(previouslyProcessed, id)
+				}
+				batcher.added(id)
 			}
 		}(movieID)
 	}
 
 	wg.Wait()
+	batcher.stop()
 
 	// Emit final state with only video files
 	s.emitState()
 
+	// Let the frontend offer importing the previous results instead of reprocessing
+	if len(previouslyProcessed) > 0 && s.app != nil {
+		s.app.Event.Emit("previously-processed", s.orderedMovieIDs(previouslyProcessed))
+	}
+
 	log.Printf("Added %d video files out of %d total files", len(validMovieIDs), len(expandedPaths))
-	return nil
+	return s.orderedMovieIDs(validMovieIDs), failures, nil
+}
+
+// orderedMovieIDs returns the given IDs in list order
+func (s *SpoilerService) orderedMovieIDs(ids []string) []string {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	ordered := make([]string, 0, len(ids))
+	for _, movie := range s.movies {
+		if wanted[movie.ID] {
+			ordered = append(ordered, movie.ID)
+		}
+	}
+	return ordered
 }
 
 func (s *SpoilerService) RemoveMovie(id string) {
+	s.timelines.remove(id)
 	for i, movie := range s.movies {
 		if movie.ID == id {
 			s.movies = append(s.movies[:i], s.movies[i+1:]...)
@@ -281,10 +457,19 @@ func (s *SpoilerService) RemoveMovie(id string) {
 
 func (s *SpoilerService) ClearMovies() {
 	s.movies = make([]Movie, 0)
+	s.groups = make([]MovieGroup, 0)
+	s.timelines.clear()
 	s.emitState()
 }
 
+// StartProcessing processes the pending movies with the configured settings
 func (s *SpoilerService) StartProcessing() error {
+	return s.StartProcessingWithOptions(nil)
+}
+
+// StartProcessingWithOptions processes the pending movies. Options override settings for
+// this run only and may be nil.
+func (s *SpoilerService) StartProcessingWithOptions(options *ProcessingOptions) error {
 	if s.processing {
 		return fmt.Errorf("processing already in progress")
 	}
@@ -294,31 +479,62 @@ func (s *SpoilerService) StartProcessing() error {
 		return fmt.Errorf("no pending movies to process")
 	}
 
+	// Validated before the preset stages the configured settings, a rejected run changes nothing
+	if err := s.validateProcessingOptions(options); err != nil {
+		return err
+	}
+	s.applyPresetGeneration()
+	restore, err := s.applyProcessingOptions(options)
+	if err != nil {
+		s.settingsMu.Lock()
+		s.applyPendingSettings()
+		s.settingsMu.Unlock()
+		return err
+	}
+
+	if s.app != nil {
+		s.app.Event.Emit("processing-estimate", s.EstimateProcessingTime())
+	}
+
+	s.startProcessing(pendingMovies, restore)
+	return nil
+}
+
+// startProcessing processes the given movies in the background and calls done when finished
+func (s *SpoilerService) startProcessing(movies []Movie, done func()) {
 	s.processing = true
+	s.cancelled.Store(false)
+	s.runMovieIDs = nil
+	for _, movie := range movies {
+		s.runMovieIDs = append(s.runMovieIDs, movie.ID)
+	}
 	s.cancelCtx, s.cancelFn = context.WithCancel(context.Background())
 	s.emitState()
 
 	go func() {
 		defer func() {
+			done()
+			s.settingsMu.Lock()
 			s.processing = false
+			s.applyPendingSettings()
+			s.settingsMu.Unlock()
 			// Reset any movies that are still in processing states back to pending
 			for i := range s.movies {
-				if s.movies[i].ProcessingState != StateCompleted && s.movies[i].ProcessingState != StateError {
+				if !s.movies[i].ProcessingState.IsFinished() {
 					s.movies[i].ProcessingState = StatePending
 					s.movies[i].ProcessingError = ""
 				}
 			}
 			s.emitState()
+			s.emitBatchSummary()
 			log.Println("Processing completed")
 		}()
 
-		err := s.processAllMoviesConcurrently()
+		err := s.processAllMoviesConcurrently(movies)
 		if err != nil {
 			log.Printf("Processing error: %v", err)
 		}
 	}()
-
-	return nil
 }
 
 func (s *SpoilerService) addMovieError(id string, errorMsg string) {
@@ -330,6 +546,13 @@ func (s *SpoilerService) addMovieError(id string, errorMsg string) {
 	})
 }
 
+// addMovieWarning records an issue that leaves the movie's output complete
+func (s *SpoilerService) addMovieWarning(id string, warning string) {
+	s.updateMovieByID(id, func(m *Movie) {
+		m.Warnings = append(m.Warnings, warning)
+	})
+}
+
 func (s *SpoilerService) ResetMovieStatuses() {
 	for i := range s.movies {
 		// Reset processing state to pending for all movies that have been analyzed
@@ -339,30 +562,20 @@ func (s *SpoilerService) ResetMovieStatuses() {
 		// Clear any processing errors and individual errors
 		s.movies[i].ProcessingError = ""
 		s.movies[i].Errors = make([]string, 0) // Clear individual errors
+		s.movies[i].Warnings = nil
 
-		// Clear processing results - Fastpic fields
-		s.movies[i].ContactSheetURL = ""
-		s.movies[i].ContactSheetBigURL = ""
-		s.movies[i].ScreenshotURLs = make([]string, 0)
-		s.movies[i].ScreenshotBigURLs = make([]string, 0)
-		s.movies[i].ScreenshotAlbum = ""
-
-		// Clear imgbox results
-		s.movies[i].ContactSheetURLIB = ""
-		s.movies[i].ContactSheetBigURLIB = ""
-		s.movies[i].ScreenshotURLsIB = make([]string, 0)
-		s.movies[i].ScreenshotBigURLsIB = make([]string, 0)
-
-		// Clear hamster results
-		s.movies[i].ContactSheetURLHam = ""
-		s.movies[i].ContactSheetBigURLHam = ""
-		s.movies[i].ScreenshotURLsHam = make([]string, 0)
-		s.movies[i].ScreenshotBigURLsHam = make([]string, 0)
+		// Clear upload results of all hosts
+		s.movies[i].Uploads = make(map[string]*HostUploads)
+		s.movies[i].DeadLinks = nil
+		s.movies[i].CancelInfo = nil
 	}
 	s.emitState()
 }
 
-func (s *SpoilerService) ReorderMovies(newOrder []string) error {
+// ReorderMovies puts the movies in the new order. Multi-selected movies are moved as a block by
+// listing them together. With groupMove the moved movies also join the group they were dropped
+// into, which is gathered around its first member.
+func (s *SpoilerService) ReorderMovies(newOrder []string, groupMove *GroupMove) error {
 	movieMap := make(map[string]Movie)
 	for _, movie := range s.movies {
 		movieMap[movie.ID] = movie
@@ -373,18 +586,40 @@ func (s *SpoilerService) ReorderMovies(newOrder []string) error {
 			return fmt.Errorf("movie with ID %s not found", id)
 		}
 	}
+	if groupMove != nil {
+		if groupMove.GroupID != "" {
+			if _, exists := s.getGroupByID(groupMove.GroupID); !exists {
+				return fmt.Errorf("group not found")
+			}
+		}
+		for _, id := range groupMove.MovieIDs {
+			if _, exists := movieMap[id]; !exists {
+				return fmt.Errorf("movie with ID %s not found", id)
+			}
+		}
+	}
 
 	var reorderedMovies []Movie
 	for _, id := range newOrder {
-		reorderedMovies = append(reorderedMovies, movieMap[id])
+		movie := movieMap[id]
+		if groupMove != nil && slices.Contains(groupMove.MovieIDs, id) {
+			movie.GroupID = groupMove.GroupID
+		}
+		reorderedMovies = append(reorderedMovies, movie)
 	}
 
 	s.movies = reorderedMovies
+	if groupMove != nil && groupMove.GroupID != "" {
+		s.compactGroup(groupMove.GroupID)
+	}
 	s.emitState()
 	return nil
 }
 
 func (s *SpoilerService) CancelProcessing() {
+	if s.processing {
+		s.recordCancellation()
+	}
 	if s.cancelFn != nil {
 		s.cancelFn()
 	}
@@ -402,32 +637,40 @@ func (s *SpoilerService) getPendingMovies() []Movie {
 	return pending
 }
 
-// Improved concurrent processing with triple uploader support
-func (s *SpoilerService) processAllMoviesConcurrently() error {
-	pendingMovies := s.getPendingMovies()
+// Improved concurrent processing across all registered uploaders
+func (s *SpoilerService) processAllMoviesConcurrently(pendingMovies []Movie) error {
 	if len(pendingMovies) == 0 {
 		return nil
 	}
 
-	requirements := s.getUploaderRequirements()
-	tempDir, err := s.createTempDirectory()
+	tempDir, uploaders, err := s.prepareProcessingRun()
 	if err != nil {
 		return err
 	}
 	defer os.RemoveAll(tempDir)
 
-	uploaderServices, err := s.initializeUploaderServices(requirements)
-	if err != nil {
-		return err
-	}
-
-	log.Printf("Starting concurrent media processing for %d movies (screenshot limit: %d, upload limit: %d)",
+	log.Printf("Starting concurrent media processing for %d movies (screenshot limit: %d, default upload limit per host: %d)",
 		len(pendingMovies), s.settings.MaxConcurrentScreenshots, s.settings.MaxConcurrentUploads)
 
-	s.processMoviesConcurrently(pendingMovies, tempDir, uploaderServices, requirements)
+	s.processMoviesConcurrently(pendingMovies, tempDir, uploaders)
 	return nil
 }
 
+// prepareProcessingRun creates the run's temp directory and initializes the hosts the
+// template needs. The caller removes the temp directory.
+func (s *SpoilerService) prepareProcessingRun() (string, []*activeUploader, error) {
+	s.buildUploaders() // Pick up preset-specific uploader options
+	requirements := s.getUploaderRequirements()
+	tempDir, err := s.createTempDirectory()
+	if err != nil {
+		return "", nil, err
+	}
+	uploaders := s.initializeUploaders(requirements)
+	s.queue.reset(uploaders)
+	s.degradation.reset()
+	return tempDir, uploaders, nil
+}
+
 // Create temporary directory for processing
 func (s *SpoilerService) createTempDirectory() (string, error) {
 	tempDir, err := os.MkdirTemp("", "media_processing_*")
@@ -437,112 +680,192 @@ func (s *SpoilerService) createTempDirectory() (string, error) {
 	return tempDir, nil
 }
 
-// UploaderServices holds all uploader service instances
-type UploaderServices struct {
-	Fastpic *img_uploaders.FastpicService
-	Imgbox  *img_uploaders.ImgboxService
-	Hamster *img_uploaders.HamsterService
+// hostMiniatureSize returns the host-specific thumbnail size, falling back to the global one
+func (s *SpoilerService) hostMiniatureSize(hostSize int) int {
+	if hostSize > 0 {
+		return hostSize
+	}
+	return s.settings.ImageMiniatureSize
 }
 
-// Initialize required uploader services based on requirements
-func (s *SpoilerService) initializeUploaderServices(requirements UploaderRequirements) (*UploaderServices, error) {
-	services := &UploaderServices{}
-	imageMiniatureSize := s.configManager.GetConfig().ImageMiniatureSize
-
-	if requirements.NeedsFastpic {
-		services.Fastpic = img_uploaders.NewFastpicService(s.settings.FastpicSID, imageMiniatureSize)
-		err := services.Fastpic.GetFastpicUploadID(s.cancelCtx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get fastpic upload ID: %v", err)
-		}
-		log.Printf("Fastpic service initialized")
-	}
+// Process all movies concurrently
+func (s *SpoilerService) processMoviesConcurrently(movies []Movie, tempDir string, uploaders []*activeUploader) {
+	var wg sync.WaitGroup
+	movies = s.prioritizeMovies(movies)
 
-	if requirements.NeedsImgbox {
-		services.Imgbox = img_uploaders.NewImgboxService(imageMiniatureSize)
-		log.Printf("Imgbox service initialized")
+	// Outside list order, movies are started one lane at a time so earlier ones claim the
+	// generation slots first. A lane is freed once the movie's media has been generated.
+	var lanes chan struct{}
+	if s.settings.ProcessingOrder != ProcessingOrderList {
+		lanes = make(chan struct{}, max(s.settings.MaxConcurrentScreenshots, 1))
 	}
 
-	if requirements.NeedsHamster {
-		services.Hamster = img_uploaders.NewHamsterService(s.settings.HamsterEmail, s.settings.HamsterPassword)
-		err := services.Hamster.Login(s.cancelCtx)
-		if err != nil {
-			err = fmt.Errorf("failed to log in hamster: %v", err)
-			s.app.Event.Emit("error", map[string]string{
-				"message": err.Error(),
-			})
-
-		} else {
-			log.Printf("Hamster service initialized")
+	for _, movie := range movies {
+		if lanes != nil {
+			select {
+			case lanes <- struct{}{}:
+			case <-s.cancelCtx.Done():
+				wg.Wait()
+				return
+			}
 		}
 
-	}
-
-	return services, nil
-}
-
-// Process all movies concurrently
-func (s *SpoilerService) processMoviesConcurrently(movies []Movie, tempDir string, services *UploaderServices, requirements UploaderRequirements) {
-	var wg sync.WaitGroup
-	for _, movie := range movies {
 		wg.Add(1)
 		go func(movie Movie) {
 			defer wg.Done()
-			s.processMovieWithLimits(movie, tempDir, services.Fastpic, services.Imgbox, services.Hamster, requirements)
+
+			var once sync.Once
+			releaseLane := func() {
+				if lanes != nil {
+					once.Do(func() { <-lanes })
+				}
+			}
+			defer releaseLane()
+
+			s.processMovieWithLimits(movie, tempDir, uploaders, releaseLane)
 		}(movie)
 	}
 	wg.Wait()
 }
 
-func (s *SpoilerService) processMovieWithLimits(movie Movie, tempDir string, fastpicService *img_uploaders.FastpicService, imgboxService *img_uploaders.ImgboxService, hamsterService *img_uploaders.HamsterService, requirements UploaderRequirements) {
+func (s *SpoilerService) processMovieWithLimits(movie Movie, tempDir string, uploaders []*activeUploader, generationDone func()) {
+	startedAt := time.Now()
+	s.recordEvent(movie.ID, "processing", "Processing started", nil)
 	s.clearMovieErrors(movie.ID)
+	s.noteDegradation(movie.ID)
 	s.updateMovieState(movie.ID, StateWaitingForScreenshotSlot)
+	s.prepareHostUploads(movie.ID, uploaders)
+	waitChecksums := s.startChecksums(movie)
+	waitMetadata := s.startMetadataLookup(movie, tempDir, uploaders)
+
+	// Results kept from an earlier run or cached for the same video are not redone
+	s.applyCachedUploads(movie, uploaders)
+	allUploaders := uploaders
+	pending := s.missingUploads(movie.ID, uploaders)
+	if len(uploaders) > 0 && len(pending) == 0 {
+		waitChecksums()
+		waitMetadata()
+		s.finalizeMovieProcessing(movie.ID)
+		return
+	}
+	uploaders = pending
 
 	movieTempDir, err := s.createMovieTempDirectory(tempDir, movie.ID)
 	if err != nil {
 		s.setMovieError(movie.ID, fmt.Sprintf("Failed to create temp directory: %v", err))
 		return
 	}
+	s.artifacts.begin(movie.ID)
+	defer s.releaseMovieArtifacts(movie.ID, movieTempDir)
 
-	contactSheetPath, screenshotPaths, err := s.generateMediaConcurrently(movie, movieTempDir, requirements)
-	if err != nil {
-		s.setMovieError(movie.ID, fmt.Sprintf("Media generation failed: %v", err))
-		return
-	}
+	// Screenshot paths keep their position, skipped or failed ones are empty
+	var screenshotPaths []string
+	media, retained := s.retainedMedia[movie.ID]
+	fromLinks := s.imagesFromLinks(movie)
+	if s.settings.PipelinedUploads && !fromLinks && !retained {
+		var contactSheetPath string
+		contactSheetPath, screenshotPaths, err = s.generateAndUploadPipelined(movie, movieTempDir, uploaders)
+		if err != nil {
+			s.setMovieError(movie.ID, fmt.Sprintf("Processing failed: %v", err))
+			return
+		}
+		if !s.hasMediaToUpload(contactSheetPath, screenshotPaths) {
+			s.setMovieError(movie.ID, "No media generated")
+			return
+		}
+		s.saveLocalOutput(movie, contactSheetPath, screenshotPaths)
+	} else {
+		var contactSheetPath string
+		if fromLinks {
+			contactSheetPath, screenshotPaths, err = s.downloadRefreshSources(movie, movieTempDir)
+		} else if retained {
+			contactSheetPath, screenshotPaths, err = s.collectRetainedMedia(movie, movieTempDir, media, uploaders)
+		} else {
+			contactSheetPath, screenshotPaths, err = s.generateMediaConcurrently(movie, movieTempDir, uploaders)
+		}
+		generationDone()
+		if err != nil {
+			s.setMovieError(movie.ID, fmt.Sprintf("Media generation failed: %v", err))
+			return
+		}
 
-	if !s.hasMediaToUpload(contactSheetPath, screenshotPaths) {
-		s.setMovieError(movie.ID, "No media generated")
-		return
-	}
+		if !s.hasMediaToUpload(contactSheetPath, screenshotPaths) {
+			s.setMovieError(movie.ID, "No media generated")
+			return
+		}
+		if !fromLinks && !retained {
+			s.saveLocalOutput(movie, contactSheetPath, screenshotPaths)
+		}
 
-	s.updateMovieState(movie.ID, StateWaitingForUploadSlot)
+		s.updateMovieState(movie.ID, StateWaitingForUploadSlot)
 
-	err = s.uploadMediaConcurrently(movie, contactSheetPath, screenshotPaths, fastpicService, imgboxService, hamsterService, requirements)
-	if err != nil {
-		s.setMovieError(movie.ID, fmt.Sprintf("Upload failed: %v", err))
-		return
+		err = s.uploadMediaConcurrently(movie, contactSheetPath, screenshotPaths, uploaders)
+		if err != nil {
+			s.setMovieError(movie.ID, fmt.Sprintf("Upload failed: %v", err))
+			return
+		}
 	}
 
+	waitChecksums()
+	waitMetadata()
 	s.finalizeMovieProcessing(movie.ID)
+	s.cacheMovieUploads(movie.ID, allUploaders)
+	s.recordEvent(movie.ID, "processing", fmt.Sprintf("Processing finished in %s", time.Since(startedAt).Round(time.Second)), nil)
+	// Only runs that generated media predict how long the next ones take
+	if fromLinks || retained {
+		return
+	}
+	s.stats.Record(ProcessingSample{
+		SizeBytes:      movie.FileSizeBytes,
+		Screenshots:    len(s.filterValidScreenshots(screenshotPaths)),
+		ElapsedSeconds: time.Since(startedAt).Seconds(),
+		RecordedAt:     time.Now(),
+	})
 }
 
-// Clear previous movie errors
+// Clear previous movie errors, warnings and cancellation
 func (s *SpoilerService) clearMovieErrors(movieID string) {
 	s.updateMovieByID(movieID, func(m *Movie) {
 		m.Errors = make([]string, 0)
+		m.Warnings = nil
+		m.CancelInfo = nil
 	})
 }
 
 // Update movie processing state
 func (s *SpoilerService) updateMovieState(movieID string, state ProcessingState) {
+	s.transitionMovieState(movieID, state)
+	s.emitState()
+}
+
+// transitionMovieState changes a movie's state if the transition is legal, logging and
+// ignoring illegal ones
+func (s *SpoilerService) transitionMovieState(movieID string, state ProcessingState) bool {
+	changed := false
 	s.updateMovieByID(movieID, func(m *Movie) {
+		if !m.ProcessingState.CanTransitionTo(state) {
+			log.Printf("Ignoring illegal state transition for %s: %s -> %s", m.FileName, m.ProcessingState, state)
+			return
+		}
 		m.ProcessingState = state
+		changed = true
 	})
-	s.emitState()
+	return changed
 }
 
-// Set movie error state
+// GetProcessingStates returns all processing states so the frontend can handle them exhaustively
+func (s *SpoilerService) GetProcessingStates() []ProcessingState {
+	return AllProcessingStates
+}
+
+// Set movie error state. Once processing is cancelled, movies stopping with an error are
+// left to be reset to pending with their CancelInfo instead.
 func (s *SpoilerService) setMovieError(movieID string, errorMsg string) {
+	if s.cancelled.Load() {
+		log.Printf("Movie %s stopped after cancellation: %s", movieID, errorMsg)
+		return
+	}
+	s.recordEvent(movieID, "error", errorMsg, nil)
 	s.updateMovieByID(movieID, func(m *Movie) {
 		m.ProcessingState = StateError
 		m.ProcessingError = errorMsg
@@ -550,9 +873,21 @@ func (s *SpoilerService) setMovieError(movieID string, errorMsg string) {
 	s.emitState()
 }
 
+// releaseMovieArtifacts removes a processed movie's generated files, freeing temp space for
+// the movies still generating
+func (s *SpoilerService) releaseMovieArtifacts(movieID, movieTempDir string) {
+	if err := os.RemoveAll(movieTempDir); err != nil {
+		log.Printf("Failed to remove temp files of %s: %v", movieID, err)
+	}
+	s.artifacts.finish(movieID)
+}
+
 // Create movie-specific temporary directory
 func (s *SpoilerService) createMovieTempDirectory(tempDir, movieID string) (string, error) {
 	movieTempDir := filepath.Join(tempDir, movieID)
+	if err := s.guardSourceWrite(movieTempDir); err != nil {
+		return "", err
+	}
 	if err := os.MkdirAll(movieTempDir, 0755); err != nil {
 		return "", err
 	}
@@ -561,48 +896,49 @@ func (s *SpoilerService) createMovieTempDirectory(tempDir, movieID string) (stri
 
 // Check if we have any media to upload
 func (s *SpoilerService) hasMediaToUpload(contactSheetPath string, screenshotPaths []string) bool {
-	return contactSheetPath != "" || len(screenshotPaths) > 0
+	return contactSheetPath != "" || len(s.filterValidScreenshots(screenshotPaths)) > 0
 }
 
 // Finalize movie processing and set final state
 func (s *SpoilerService) finalizeMovieProcessing(movieID string) {
+	s.checkSimilarMovies(movieID)
 	movie, exists := s.getMovieByID(movieID)
 	if !exists {
 		return
 	}
 
 	finalState := StateCompleted
-	if len(movie.Errors) > 0 {
-		log.Printf("Movie %s completed with %d warnings/errors", movie.FileName, len(movie.Errors))
+	if len(movie.Errors) > 0 || len(movie.Warnings) > 0 {
+		finalState = StateCompletedWithWarnings
+		log.Printf("Movie %s completed with %d errors and %d warnings", movie.FileName, len(movie.Errors), len(movie.Warnings))
 	} else {
 		log.Printf("Successfully processed movie: %s", movie.FileName)
 	}
 
-	s.updateMovieByID(movieID, func(m *Movie) {
-		m.ProcessingState = finalState
-	})
+	s.transitionMovieState(movieID, finalState)
+	s.recordMovieHistory(movieID)
 	s.emitState()
 }
 
 // Generate contact sheet and screenshots with proper concurrency control
-func (s *SpoilerService) generateMediaConcurrently(movie Movie, tempDir string, requirements UploaderRequirements) (string, []string, error) {
+func (s *SpoilerService) generateMediaConcurrently(movie Movie, tempDir string, uploaders []*activeUploader) (string, []string, error) {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var generationStarted bool
 	var contactSheetPath string
 	var screenshotPaths []string
 
-	needsContactSheet := s.needsContactSheet(requirements)
-	needsScreenshots := s.needsScreenshots(requirements)
+	needsContactSheet := s.needsContactSheet(uploaders)
+	needsScreenshots := s.needsScreenshots(uploaders)
 
 	if needsContactSheet {
 		wg.Add(1)
 		go s.generateContactSheetAsync(&wg, &mu, &generationStarted, movie, tempDir, &contactSheetPath)
 	}
 
-	if needsScreenshots && s.settings.ScreenshotCount > 0 {
-		screenshotPaths = make([]string, s.settings.ScreenshotCount)
-		s.generateScreenshotsAsync(&wg, &mu, &generationStarted, movie, tempDir, screenshotPaths)
+	if count := s.movieScreenshotLimits(movie.ID).count; needsScreenshots && count > 0 {
+		screenshotPaths = make([]string, count)
+		s.generateScreenshotsAsync(&wg, &mu, &generationStarted, movie, tempDir, screenshotPaths, uploaders)
 	}
 
 	wg.Wait()
@@ -611,36 +947,118 @@ func (s *SpoilerService) generateMediaConcurrently(movie Movie, tempDir string,
 		return "", nil, s.cancelCtx.Err()
 	}
 
-	validScreenshots := s.filterValidScreenshots(screenshotPaths)
-	return contactSheetPath, validScreenshots, nil
+	return contactSheetPath, screenshotPaths, nil
+}
+
+// generateAndUploadPipelined hands every generated image to the uploaders as soon as it is ready,
+// so uploads overlap with the generation of the remaining screenshots
+func (s *SpoilerService) generateAndUploadPipelined(movie Movie, tempDir string, uploaders []*activeUploader) (string, []string, error) {
+	var generateWG, uploadWG sync.WaitGroup
+	var generateMu, uploadMu sync.Mutex
+	var generationStarted, uploadStarted bool
+	var contactSheetPath string
+	var screenshotPaths []string
+
+	baseFileName := strings.TrimSuffix(filepath.Base(movie.FilePath), filepath.Ext(movie.FilePath))
+
+	if s.needsContactSheet(uploaders) {
+		generateWG.Add(1)
+		go func() {
+			defer generateWG.Done()
+
+			var stepWG sync.WaitGroup
+			stepWG.Add(1)
+			s.generateContactSheetAsync(&stepWG, &generateMu, &generationStarted, movie, tempDir, &contactSheetPath)
+			// Uploads are registered before generateWG is released so the final wait sees them
+			s.uploadContactSheets(&uploadWG, &uploadMu, &uploadStarted, movie, contactSheetPath, baseFileName, uploaders)
+		}()
+	}
+
+	if count := s.movieScreenshotLimits(movie.ID).count; s.needsScreenshots(uploaders) && count > 0 {
+		screenshotPaths = make([]string, count)
+		for i, timestamp := range s.screenshotTimestamps(movie) {
+			if s.screenshotUploaded(movie.ID, i, uploaders) {
+				continue
+			}
+			generateWG.Add(1)
+			go func(index int, timestamp float64) {
+				defer generateWG.Done()
+
+				var stepWG sync.WaitGroup
+				stepWG.Add(1)
+				s.generateSingleScreenshotAsync(&stepWG, &generateMu, &generationStarted, movie, tempDir, screenshotPaths, index, timestamp)
+				if screenshotPaths[index] != "" {
+					s.uploadScreenshotAt(&uploadWG, &uploadMu, &uploadStarted, movie, screenshotPaths[index], baseFileName, index, uploaders)
+				}
+			}(i, timestamp)
+		}
+	}
+
+	generateWG.Wait()
+	uploadWG.Wait()
+
+	if s.cancelCtx.Err() != nil {
+		return "", nil, s.cancelCtx.Err()
+	}
+
+	return contactSheetPath, screenshotPaths, nil
 }
 
 // Check if contact sheet is needed
-func (s *SpoilerService) needsContactSheet(requirements UploaderRequirements) bool {
-	return requirements.FastpicContactSheet || requirements.ImgboxContactSheet || requirements.HamsterContactSheet
+func (s *SpoilerService) needsContactSheet(uploaders []*activeUploader) bool {
+	if s.settings.LocalOutputDir != "" {
+		return true
+	}
+	for _, uploader := range uploaders {
+		if uploader.contactSheet {
+			return true
+		}
+	}
+	return false
 }
 
 // Check if screenshots are needed
-func (s *SpoilerService) needsScreenshots(requirements UploaderRequirements) bool {
-	return requirements.FastpicScreenshots || requirements.ImgboxScreenshots || requirements.HamsterScreenshots
+func (s *SpoilerService) needsScreenshots(uploaders []*activeUploader) bool {
+	if s.settings.LocalOutputDir != "" {
+		return true
+	}
+	for _, uploader := range uploaders {
+		if uploader.screenshots {
+			return true
+		}
+	}
+	return false
 }
 
 // Generate contact sheet asynchronously
 func (s *SpoilerService) generateContactSheetAsync(wg *sync.WaitGroup, mu *sync.Mutex, generationStarted *bool, movie Movie, tempDir string, contactSheetPath *string) {
 	defer wg.Done()
 
+	job := s.queue.add(movie, QueueJobContactSheet, "", 0)
+	defer s.queue.done(job)
+
+	if err := s.artifacts.waitForRoom(s.cancelCtx, movie.ID, s.tempLimitBytes()); err != nil {
+		return
+	}
+
 	select {
-	case s.screenshotSemaphore <- struct{}{}:
-		defer func() { <-s.screenshotSemaphore }()
+	case s.contactSheetSemaphore <- struct{}{}:
+		defer func() { <-s.contactSheetSemaphore }()
 
+		s.queue.start(job)
 		s.markGenerationStarted(mu, generationStarted, movie.ID)
 
-		path, err := s.generateMovieContactSheet(movie.FilePath, tempDir)
+		s.recordEvent(movie.ID, "contact_sheet", "Contact sheet generation started", nil)
+		path, err := s.generateMovieContactSheet(movie, tempDir)
 		*contactSheetPath = path
+		s.recordEvent(movie.ID, "contact_sheet", "Contact sheet generation finished", err)
 
 		if err != nil {
 			s.addMovieError(movie.ID, fmt.Sprintf("Contact sheet generation failed: %v", err))
 			log.Printf("Failed to generate contact sheet for %s: %v", movie.FileName, err)
+		} else {
+			s.watermarkImage(movie, path, "Contact sheet")
+			s.artifacts.add(movie.ID, path)
 		}
 
 	case <-s.cancelCtx.Done():
@@ -649,31 +1067,62 @@ func (s *SpoilerService) generateContactSheetAsync(wg *sync.WaitGroup, mu *sync.
 }
 
 // Generate screenshots asynchronously
-func (s *SpoilerService) generateScreenshotsAsync(wg *sync.WaitGroup, mu *sync.Mutex, generationStarted *bool, movie Movie, tempDir string, screenshotPaths []string) {
-	interval := movie.Duration / float64(s.settings.ScreenshotCount+1)
-
-	for i := 0; i < s.settings.ScreenshotCount; i++ {
+func (s *SpoilerService) generateScreenshotsAsync(wg *sync.WaitGroup, mu *sync.Mutex, generationStarted *bool, movie Movie, tempDir string, screenshotPaths []string, uploaders []*activeUploader) {
+	for i, timestamp := range s.screenshotTimestamps(movie) {
+		if s.screenshotUploaded(movie.ID, i, uploaders) {
+			continue
+		}
 		wg.Add(1)
-		go s.generateSingleScreenshotAsync(wg, mu, generationStarted, movie, tempDir, screenshotPaths, i, interval)
+		go s.generateSingleScreenshotAsync(wg, mu, generationStarted, movie, tempDir, screenshotPaths, i, timestamp)
+	}
+}
+
+// screenshotTimestamps returns the position of every screenshot, evenly spread over the video.
+// Without a duration, as with corrupt files or still-image slideshows, the interval math is
+// meaningless, so a single frame at 0s is taken and the movie is flagged instead.
+func (s *SpoilerService) screenshotTimestamps(movie Movie) []float64 {
+	if movie.DurationSeconds <= 0 {
+		s.addMovieWarning(movie.ID, "Video duration is unknown, only a single screenshot at 0s was taken")
+		log.Printf("Unknown duration for %s, taking a single screenshot at 0s", movie.FileName)
+		return []float64{0}
 	}
+
+	start, end := s.screenshotRange(movie.DurationSeconds)
+	timestamps := spreadTimestamps(start, end, s.movieScreenshotLimits(movie.ID).count)
+	return jitterTimestamps(timestamps, movie, float64(s.settings.ScreenshotJitterSeconds))
 }
 
 // Generate a single screenshot asynchronously
-func (s *SpoilerService) generateSingleScreenshotAsync(wg *sync.WaitGroup, mu *sync.Mutex, generationStarted *bool, movie Movie, tempDir string, screenshotPaths []string, index int, interval float64) {
+func (s *SpoilerService) generateSingleScreenshotAsync(wg *sync.WaitGroup, mu *sync.Mutex, generationStarted *bool, movie Movie, tempDir string, screenshotPaths []string, index int, timestamp float64) {
 	defer wg.Done()
 
+	job := s.queue.add(movie, QueueJobScreenshot, "", index+1)
+	defer s.queue.done(job)
+
+	// Wait for uploaded movies to free temp space when over the cap
+	if err := s.artifacts.waitForRoom(s.cancelCtx, movie.ID, s.tempLimitBytes()); err != nil {
+		return
+	}
+
 	select {
 	case s.screenshotSemaphore <- struct{}{}:
 		defer func() { <-s.screenshotSemaphore }()
 
+		s.queue.start(job)
 		s.markGenerationStarted(mu, generationStarted, movie.ID)
 
-		timestamp := interval * float64(index+1)
-		outputPath := filepath.Join(tempDir, fmt.Sprintf("screenshot_%d.jpg", index+1))
+		outputPath := filepath.Join(tempDir, fmt.Sprintf("screenshot_%d%s", index+1, s.screenshotExtension()))
+		if s.settings.ScreenshotMode == ScreenshotModeSmart && movie.DurationSeconds > 0 {
+			timestamp = s.refineTimestamp(movie, timestamp)
+		}
 
-		err := s.generateScreenshot(movie.FilePath, outputPath, timestamp)
+		err := s.generateScreenshot(movie.mediaInput(), outputPath, timestamp, s.movieScreenshotLimits(movie.ID))
+		s.recordEvent(movie.ID, "screenshot", fmt.Sprintf("Screenshot %d at %.2fs", index+1, timestamp), err)
 		if err == nil {
+			s.hashScreenshot(movie, outputPath, index)
+			s.watermarkImage(movie, outputPath, fmt.Sprintf("Screenshot %d", index+1))
 			screenshotPaths[index] = outputPath
+			s.artifacts.add(movie.ID, outputPath)
 		} else {
 			s.addMovieError(movie.ID, fmt.Sprintf("Screenshot %d generation failed: %v", index+1, err))
 			log.Printf("Failed to generate screenshot %d for %s: %v", index+1, movie.FileName, err)
@@ -691,9 +1140,7 @@ func (s *SpoilerService) markGenerationStarted(mu *sync.Mutex, generationStarted
 
 	if !*generationStarted {
 		*generationStarted = true
-		s.updateMovieByID(movieID, func(m *Movie) {
-			m.ProcessingState = StateGeneratingScreenshots
-		})
+		s.transitionMovieState(movieID, StateGeneratingScreenshots)
 		s.emitState()
 	}
 }
@@ -710,15 +1157,15 @@ func (s *SpoilerService) filterValidScreenshots(screenshotPaths []string) []stri
 }
 
 // Upload media with proper concurrency control to all three services
-func (s *SpoilerService) uploadMediaConcurrently(movie Movie, contactSheetPath string, screenshotPaths []string, fastpicService *img_uploaders.FastpicService, imgboxService *img_uploaders.ImgboxService, hamsterService *img_uploaders.HamsterService, requirements UploaderRequirements) error {
+func (s *SpoilerService) uploadMediaConcurrently(movie Movie, contactSheetPath string, screenshotPaths []string, uploaders []*activeUploader) error {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var uploadStarted bool
 
 	baseFileName := strings.TrimSuffix(filepath.Base(movie.FilePath), filepath.Ext(movie.FilePath))
 
-	s.uploadContactSheets(&wg, &mu, &uploadStarted, movie, contactSheetPath, baseFileName, fastpicService, imgboxService, hamsterService, requirements)
-	s.uploadScreenshots(&wg, &mu, &uploadStarted, movie, screenshotPaths, baseFileName, fastpicService, imgboxService, hamsterService, requirements)
+	s.uploadContactSheets(&wg, &mu, &uploadStarted, movie, contactSheetPath, baseFileName, uploaders)
+	s.uploadScreenshots(&wg, &mu, &uploadStarted, movie, screenshotPaths, baseFileName, uploaders)
 
 	wg.Wait()
 
@@ -729,128 +1176,6 @@ func (s *SpoilerService) uploadMediaConcurrently(movie Movie, contactSheetPath s
 	return nil
 }
 
-// Upload contact sheets to all required services
-func (s *SpoilerService) uploadContactSheets(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, contactSheetPath, baseFileName string, fastpicService *img_uploaders.FastpicService, imgboxService *img_uploaders.ImgboxService, hamsterService *img_uploaders.HamsterService, requirements UploaderRequirements) {
-	if contactSheetPath == "" {
-		return
-	}
-
-	if requirements.FastpicContactSheet && fastpicService != nil {
-		wg.Add(1)
-		go s.uploadContactSheetToFastpic(wg, mu, uploadStarted, movie, contactSheetPath, baseFileName, fastpicService)
-	}
-
-	if requirements.ImgboxContactSheet && imgboxService != nil {
-		wg.Add(1)
-		go s.uploadContactSheetToImgbox(wg, mu, uploadStarted, movie, contactSheetPath, imgboxService)
-	}
-
-	if requirements.HamsterContactSheet && hamsterService != nil {
-		wg.Add(1)
-		go s.uploadContactSheetToHamster(wg, mu, uploadStarted, movie, contactSheetPath, hamsterService)
-	}
-}
-
-// Upload screenshots to all required services
-func (s *SpoilerService) uploadScreenshots(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, screenshotPaths []string, baseFileName string, fastpicService *img_uploaders.FastpicService, imgboxService *img_uploaders.ImgboxService, hamsterService *img_uploaders.HamsterService, requirements UploaderRequirements) {
-	if requirements.FastpicScreenshots && fastpicService != nil {
-		s.uploadScreenshotsToFastpic(wg, mu, uploadStarted, movie, screenshotPaths, baseFileName, fastpicService)
-	}
-
-	if requirements.ImgboxScreenshots && imgboxService != nil {
-		s.uploadScreenshotsToImgbox(wg, mu, uploadStarted, movie, screenshotPaths, imgboxService)
-	}
-
-	if requirements.HamsterScreenshots && hamsterService != nil {
-		s.uploadScreenshotsToHamster(wg, mu, uploadStarted, movie, screenshotPaths, hamsterService)
-	}
-}
-
-// Upload contact sheet to Fastpic
-func (s *SpoilerService) uploadContactSheetToFastpic(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, contactSheetPath, baseFileName string, fastpicService *img_uploaders.FastpicService) {
-	defer wg.Done()
-
-	select {
-	case s.uploadSemaphore <- struct{}{}:
-		defer func() { <-s.uploadSemaphore }()
-
-		s.markUploadStarted(mu, uploadStarted, movie.ID)
-
-		fileName := fmt.Sprintf("%s_contact_sheet.jpg", baseFileName)
-		result, err := fastpicService.UploadToFastpic(s.cancelCtx, contactSheetPath, fileName)
-		if err != nil {
-			s.addMovieError(movie.ID, fmt.Sprintf("Fastpic contact sheet upload failed: %v", err))
-			log.Printf("Failed to upload contact sheet to fastpic for %s: %v", movie.FileName, err)
-			return
-		}
-
-		s.updateMovieByID(movie.ID, func(m *Movie) {
-			m.ContactSheetURL = result.BBThumb
-			m.ContactSheetBigURL = result.BBBig
-			if m.ScreenshotAlbum == "" {
-				m.ScreenshotAlbum = result.AlbumLink
-			}
-		})
-
-	case <-s.cancelCtx.Done():
-		return
-	}
-}
-
-// Upload contact sheet to Imgbox
-func (s *SpoilerService) uploadContactSheetToImgbox(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, contactSheetPath string, imgboxService *img_uploaders.ImgboxService) {
-	defer wg.Done()
-
-	select {
-	case s.uploadSemaphore <- struct{}{}:
-		defer func() { <-s.uploadSemaphore }()
-
-		s.markUploadStarted(mu, uploadStarted, movie.ID)
-
-		result, err := imgboxService.UploadImage(s.cancelCtx, contactSheetPath)
-		if err != nil {
-			s.addMovieError(movie.ID, fmt.Sprintf("Imgbox contact sheet upload failed: %v", err))
-			log.Printf("Failed to upload contact sheet to imgbox for %s: %v", movie.FileName, err)
-			return
-		}
-
-		s.updateMovieByID(movie.ID, func(m *Movie) {
-			m.ContactSheetURLIB = result.BBThumb
-			m.ContactSheetBigURLIB = result.BBBig
-		})
-
-	case <-s.cancelCtx.Done():
-		return
-	}
-}
-
-// Upload contact sheet to Hamster
-func (s *SpoilerService) uploadContactSheetToHamster(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, contactSheetPath string, hamsterService *img_uploaders.HamsterService) {
-	defer wg.Done()
-
-	select {
-	case s.uploadSemaphore <- struct{}{}:
-		defer func() { <-s.uploadSemaphore }()
-
-		s.markUploadStarted(mu, uploadStarted, movie.ID)
-
-		result, err := hamsterService.UploadImage(s.cancelCtx, contactSheetPath)
-		if err != nil {
-			s.addMovieError(movie.ID, fmt.Sprintf("Hamster contact sheet upload failed: %v", err))
-			log.Printf("Failed to upload contact sheet to hamster for %s: %v", movie.FileName, err)
-			return
-		}
-
-		s.updateMovieByID(movie.ID, func(m *Movie) {
-			m.ContactSheetURLHam = result.BBThumb
-			m.ContactSheetBigURLHam = result.BBBig
-		})
-
-	case <-s.cancelCtx.Done():
-		return
-	}
-}
-
 // Mark upload as started (thread-safe)
 func (s *SpoilerService) markUploadStarted(mu *sync.Mutex, uploadStarted *bool, movieID string) {
 	mu.Lock()
@@ -858,149 +1183,47 @@ func (s *SpoilerService) markUploadStarted(mu *sync.Mutex, uploadStarted *bool,
 
 	if !*uploadStarted {
 		*uploadStarted = true
-		s.updateMovieByID(movieID, func(m *Movie) {
-			m.ProcessingState = StateUploadingScreenshots
-		})
+		s.transitionMovieState(movieID, StateUploadingScreenshots)
 		s.emitState()
 	}
 }
 
-// Upload screenshots to Fastpic
-func (s *SpoilerService) uploadScreenshotsToFastpic(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, screenshotPaths []string, baseFileName string, fastpicService *img_uploaders.FastpicService) {
-	for i, screenshotPath := range screenshotPaths {
-		wg.Add(1)
-		go s.uploadSingleScreenshotToFastpic(wg, mu, uploadStarted, movie, screenshotPath, baseFileName, i, fastpicService)
-	}
-}
-
-// Upload screenshots to Imgbox
-func (s *SpoilerService) uploadScreenshotsToImgbox(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, screenshotPaths []string, imgboxService *img_uploaders.ImgboxService) {
-	for i, screenshotPath := range screenshotPaths {
-		wg.Add(1)
-		go s.uploadSingleScreenshotToImgbox(wg, mu, uploadStarted, movie, screenshotPath, i, imgboxService)
-	}
-}
-
-// Upload screenshots to Hamster
-func (s *SpoilerService) uploadScreenshotsToHamster(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, screenshotPaths []string, hamsterService *img_uploaders.HamsterService) {
-	for i, screenshotPath := range screenshotPaths {
-		wg.Add(1)
-		go s.uploadSingleScreenshotToHamster(wg, mu, uploadStarted, movie, screenshotPath, i, hamsterService)
+// uploadFileName returns the name an image is uploaded under, randomized when anonymization is enabled
+func (s *SpoilerService) uploadFileName(fileName string) string {
+	if !s.settings.AnonymizeUploads {
+		return fileName
 	}
+	return uuid.New().String() + filepath.Ext(fileName)
 }
 
-// Upload single screenshot to Fastpic
-func (s *SpoilerService) uploadSingleScreenshotToFastpic(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, screenshotPath, baseFileName string, index int, fastpicService *img_uploaders.FastpicService) {
-	defer wg.Done()
-
-	select {
-	case s.uploadSemaphore <- struct{}{}:
-		defer func() { <-s.uploadSemaphore }()
-
-		s.markUploadStarted(mu, uploadStarted, movie.ID)
-
-		fileName := fmt.Sprintf("%s_screenshot_%d.jpg", baseFileName, index+1)
-		result, err := fastpicService.UploadToFastpic(s.cancelCtx, screenshotPath, fileName)
-		if err != nil {
-			s.addMovieError(movie.ID, fmt.Sprintf("Fastpic screenshot %d upload failed: %v", index+1, err))
-			log.Printf("Failed to upload screenshot %d to fastpic for %s: %v", index+1, movie.FileName, err)
-			return
-		}
-
-		s.updateMovieByID(movie.ID, func(m *Movie) {
-			s.ensureScreenshotSliceSize(&m.ScreenshotURLs, index)
-			s.ensureScreenshotSliceSize(&m.ScreenshotBigURLs, index)
-
-			m.ScreenshotURLs[index] = result.BBThumb
-			m.ScreenshotBigURLs[index] = result.BBBig
-			if m.ScreenshotAlbum == "" {
-				m.ScreenshotAlbum = result.AlbumLink
-			}
-		})
-
-	case <-s.cancelCtx.Done():
-		return
+// Ensure screenshot slice has enough capacity
+func (s *SpoilerService) ensureScreenshotSliceSize(slice *[]string, index int) {
+	for len(*slice) <= index {
+		*slice = append(*slice, "")
 	}
 }
 
-// Upload single screenshot to Imgbox
-func (s *SpoilerService) uploadSingleScreenshotToImgbox(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, screenshotPath string, index int, imgboxService *img_uploaders.ImgboxService) {
-	defer wg.Done()
-
-	select {
-	case s.uploadSemaphore <- struct{}{}:
-		defer func() { <-s.uploadSemaphore }()
-
-		s.markUploadStarted(mu, uploadStarted, movie.ID)
+func (s *SpoilerService) generateMovieContactSheet(movie Movie, tempDir string) (string, error) {
+	videoPath := movie.FilePath
 
-		result, err := imgboxService.UploadImage(s.cancelCtx, screenshotPath)
-		if err != nil {
-			s.addMovieError(movie.ID, fmt.Sprintf("Imgbox screenshot %d upload failed: %v", index+1, err))
-			log.Printf("Failed to upload screenshot %d to imgbox for %s: %v", index+1, movie.FileName, err)
-			return
-		}
-
-		s.updateMovieByID(movie.ID, func(m *Movie) {
-			s.ensureScreenshotSliceSize(&m.ScreenshotURLsIB, index)
-			s.ensureScreenshotSliceSize(&m.ScreenshotBigURLsIB, index)
-
-			m.ScreenshotURLsIB[index] = result.BBThumb
-			m.ScreenshotBigURLsIB[index] = result.BBBig
-		})
-
-	case <-s.cancelCtx.Done():
-		return
+	if err := s.guardSourceWrite(tempDir); err != nil {
+		return "", err
 	}
-}
-
-// Upload single screenshot to Hamster
-func (s *SpoilerService) uploadSingleScreenshotToHamster(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, screenshotPath string, index int, hamsterService *img_uploaders.HamsterService) {
-	defer wg.Done()
-
-	select {
-	case s.uploadSemaphore <- struct{}{}:
-		defer func() { <-s.uploadSemaphore }()
-
-		s.markUploadStarted(mu, uploadStarted, movie.ID)
 
-		result, err := hamsterService.UploadImage(s.cancelCtx, screenshotPath)
-		if err != nil {
-			s.addMovieError(movie.ID, fmt.Sprintf("Hamster screenshot %d upload failed: %v", index+1, err))
-			log.Printf("Failed to upload screenshot %d to hamster for %s: %v", index+1, movie.FileName, err)
-			return
-		}
-
-		s.updateMovieByID(movie.ID, func(m *Movie) {
-			s.ensureScreenshotSliceSize(&m.ScreenshotURLsHam, index)
-			s.ensureScreenshotSliceSize(&m.ScreenshotBigURLsHam, index)
-
-			m.ScreenshotURLsHam[index] = result.BBThumb
-			m.ScreenshotBigURLsHam[index] = result.BBBig
-		})
-
-	case <-s.cancelCtx.Done():
-		return
+	// mtn cannot spread frames over a video without duration, don't spawn a doomed run
+	if movie.DurationSeconds <= 0 {
+		return "", fmt.Errorf("video duration is unknown")
 	}
-}
 
-// Ensure screenshot slice has enough capacity
-func (s *SpoilerService) ensureScreenshotSliceSize(slice *[]string, index int) {
-	for len(*slice) <= index {
-		*slice = append(*slice, "")
+	// Without mtn the built-in generator is used
+	if !toolInstalled("mtn") {
+		log.Printf("MTN not found, using the built-in contact sheet generator for %s", filepath.Base(videoPath))
+		return s.generateNativeContactSheet(movie, tempDir)
 	}
-}
 
-func (s *SpoilerService) generateMovieContactSheet(videoPath, tempDir string) (string, error) {
-	// Check if mtn is available before trying to use it
-	if _, err := exec.LookPath("mtn"); err != nil {
-		// Emit event that mtn is missing (only once per processing session)
-		if s.app != nil {
-			s.app.Event.Emit("error", map[string]string{
-				"message": "MTN (Movie Thumbnailer) is not installed or not found in PATH. Contact sheet generation will be skipped.",
-			})
-		}
-		log.Printf("MTN not found, skipping contact sheet generation for %s", filepath.Base(videoPath))
-		return "", nil // Return empty string to skip contact sheet
+	// mtn reads a single file, split movies are joined by the built-in generator
+	if len(movie.Segments) > 1 {
+		return s.generateNativeContactSheet(movie, tempDir)
 	}
 
 	// Parse user-configured MTN arguments
@@ -1010,7 +1233,7 @@ func (s *SpoilerService) generateMovieContactSheet(videoPath, tempDir string) (s
 	cmdArgs := append([]string{}, mtnArgs...)
 	cmdArgs = append(cmdArgs, "-O", tempDir, videoPath)
 
-	cmd := exec.CommandContext(s.cancelCtx, "mtn", cmdArgs...)
+	cmd := exec.CommandContext(s.cancelCtx, toolPath("mtn"), cmdArgs...)
 
 	// Capture both stdout and stderr for better error reporting
 	output, err := cmd.CombinedOutput()
@@ -1061,15 +1284,19 @@ func (s *SpoilerService) generateMovieContactSheet(videoPath, tempDir string) (s
 	return "", fmt.Errorf("contact sheet file not found after generation - no .jpg files in %s", tempDir)
 }
 
-func (s *SpoilerService) generateScreenshot(videoPath, outputPath string, timestamp float64) error {
-	cmd := exec.CommandContext(s.cancelCtx, "ffmpeg",
+func (s *SpoilerService) generateScreenshot(videoPath, outputPath string, timestamp float64, limits screenshotLimits) error {
+	if err := s.guardSourceWrite(outputPath); err != nil {
+		return err
+	}
+
+	args := []string{
 		"-ss", fmt.Sprintf("%.2f", timestamp),
 		"-i", videoPath,
 		"-vframes", "1",
-		"-q:v", fmt.Sprintf("%d", s.settings.ScreenshotQuality),
-		"-y",
-		outputPath,
-	)
+	}
+	args = append(args, s.imageEncodeArgs(outputPath, limits)...)
+	args = append(args, "-y", outputPath)
+	cmd := exec.CommandContext(s.cancelCtx, toolPath("ffmpeg"), args...)
 
 	err := cmd.Run()
 	if err != nil {
@@ -1115,19 +1342,27 @@ func (s *SpoilerService) GetExpandedFilePaths(paths []string) ([]string, error)
 }
 
 func (s *SpoilerService) GenerateResultForMovie(movieID string) string {
-	var movie *Movie
-	for _, m := range s.movies {
-		if m.ID == movieID {
-			movie = &m
-			break
-		}
-	}
-
-	if movie == nil || movie.FileName == "" {
+	movie, exists := s.getMovieByID(movieID)
+	if !exists || movie.FileName == "" {
 		return ""
 	}
 
-	return s.generateMovieSpoiler(*movie)
+	return s.applyLineEndings(ConvertBBCode(s.generateMovieSpoiler(movie), s.outputFormat()))
+}
+
+// GenerateResults renders the spoilers of the selected completed movies in the given order,
+// for exporting part of the list. Unknown and unfinished movies are skipped.
+func (s *SpoilerService) GenerateResults(ids []string) string {
+	var result strings.Builder
+	for _, id := range ids {
+		movie, exists := s.getMovieByID(id)
+		if !exists || movie.FileName == "" || !movie.ProcessingState.IsCompleted() {
+			continue
+		}
+		result.WriteString(s.generateMovieSpoiler(movie))
+		result.WriteString("\n")
+	}
+	return s.applyLineEndings(ConvertBBCode(result.String(), s.outputFormat()))
 }
 
 func (s *SpoilerService) GenerateResult() string {
@@ -1136,113 +1371,109 @@ func (s *SpoilerService) GenerateResult() string {
 	}
 
 	var result strings.Builder
+	completed := s.completedMovies()
 
-	for _, movie := range s.movies {
-		if movie.FileName == "" || movie.ProcessingState != StateCompleted {
-			continue
-		}
-
-		result.WriteString(s.generateMovieSpoiler(movie))
+	if header := s.renderBatchTemplate(s.settings.BatchHeaderTemplate, completed); header != "" {
+		result.WriteString(header)
 		result.WriteString("\n")
 	}
 
-	return result.String()
-}
-
-func (s *SpoilerService) generateMovieSpoiler(movie Movie) string {
-	// Get current template from config
-	template := s.configManager.GetCurrentTemplate()
+	for _, run := range s.groupRuns(completed) {
+		var content strings.Builder
+		for _, movie := range run.movies {
+			content.WriteString(s.generateMovieSpoiler(movie))
+			content.WriteString("\n")
+		}
 
-	template = s.replaceBasicPlaceholders(template, movie)
-	template = s.replaceContactSheetPlaceholders(template, movie)
-	template = s.replaceScreenshotPlaceholders(template, movie)
-	template = s.replaceParameterPlaceholders(template, movie)
+		group, exists := s.runGroup(run)
+		if !exists {
+			result.WriteString(content.String())
+			continue
+		}
 
-	return template
-}
+		if s.settings.NestGroupSpoilers {
+			result.WriteString(s.renderCollectionSpoiler(group, run.movies, content.String()))
+			result.WriteString("\n")
+			continue
+		}
 
-// Replace basic movie information placeholders
-func (s *SpoilerService) replaceBasicPlaceholders(template string, movie Movie) string {
-	replacements := map[string]string{
-		"%FILE_NAME%":      movie.FileName,
-		"%FILE_SIZE%":      movie.FileSize,
-		"%DURATION%":       movie.DurationFormatted,
-		"%WIDTH%":          movie.Width,
-		"%HEIGHT%":         movie.Height,
-		"%BIT_RATE%":       movie.BitRate,
-		"%VIDEO_BIT_RATE%": movie.VideoBitRate,
-		"%AUDIO_BIT_RATE%": movie.AudioBitRate,
-		"%VIDEO_CODEC%":    movie.VideoCodec,
-		"%AUDIO_CODEC%":    movie.AudioCodec,
+		result.WriteString(s.renderGroupHeader(group, run.movies))
+		result.WriteString("\n")
+		result.WriteString(content.String())
 	}
 
-	for placeholder, value := range replacements {
-		template = strings.ReplaceAll(template, placeholder, value)
+	if footer := s.renderBatchTemplate(s.settings.BatchFooterTemplate, completed); footer != "" {
+		result.WriteString(footer)
+		result.WriteString("\n")
 	}
 
-	return template
-}
-
-// Replace contact sheet placeholders for all services
-func (s *SpoilerService) replaceContactSheetPlaceholders(template string, movie Movie) string {
-	// Fastpic contact sheets
-	template = s.replaceIfNotEmpty(template, "%CONTACT_SHEET_FP%", movie.ContactSheetURL)
-	template = s.replaceIfNotEmpty(template, "%CONTACT_SHEET_FP_BIG%", movie.ContactSheetBigURL)
-
-	// Imgbox contact sheets
-	template = s.replaceIfNotEmpty(template, "%CONTACT_SHEET_IB%", movie.ContactSheetURLIB)
-	template = s.replaceIfNotEmpty(template, "%CONTACT_SHEET_IB_BIG%", movie.ContactSheetBigURLIB)
-
-	// Hamster contact sheets
-	template = s.replaceIfNotEmpty(template, "%CONTACT_SHEET_HAM%", movie.ContactSheetURLHam)
-	template = s.replaceIfNotEmpty(template, "%CONTACT_SHEET_HAM_BIG%", movie.ContactSheetBigURLHam)
+	// The attribution goes after the last spoiler and the batch footer, never inside a spoiler
+	if footer := s.renderResultFooter(); footer != "" {
+		result.WriteString(footer)
+		result.WriteString("\n")
+	}
 
-	return template
+	return s.applyLineEndings(ConvertBBCode(result.String(), s.outputFormat()))
 }
 
-// Replace screenshot placeholders for all services
-func (s *SpoilerService) replaceScreenshotPlaceholders(template string, movie Movie) string {
-	template = s.replaceFastpicScreenshots(template, movie)
-	template = s.replaceImgboxScreenshots(template, movie)
-	template = s.replaceHamsterScreenshots(template, movie)
-	return template
+// completedMovies returns the movies that are ready to be rendered, in list order
+func (s *SpoilerService) completedMovies() []Movie {
+	var completed []Movie
+	for _, movie := range s.movies {
+		if movie.FileName == "" || !movie.ProcessingState.IsCompleted() {
+			continue
+		}
+		completed = append(completed, movie)
+	}
+	return completed
 }
 
-// Replace Fastpic screenshot placeholders
-func (s *SpoilerService) replaceFastpicScreenshots(template string, movie Movie) string {
-	// Regular screenshots (BBThumb)
-	fastpicScreenshots := s.filterNonEmptyStrings(movie.ScreenshotURLs)
-	template = s.replaceScreenshotGroup(template, "%SCREENSHOTS_FP%", "%SCREENSHOTS_FP_SPACED%", fastpicScreenshots)
-
-	// Big screenshots (BBBig)
-	fastpicScreenshotsBig := s.filterNonEmptyStrings(movie.ScreenshotBigURLs)
-	template = s.replaceScreenshotGroup(template, "%SCREENSHOTS_FP_BIG%", "%SCREENSHOTS_FP_BIG_SPACED%", fastpicScreenshotsBig)
-
-	return template
-}
+func (s *SpoilerService) generateMovieSpoiler(movie Movie) string {
+	template := s.currentTemplate()
+	movie = s.withGroupParams(movie)
+	movie = s.withNoteParams(movie)
+	preset, hasPreset := s.currentPreset()
 
-// Replace Imgbox screenshot placeholders
-func (s *SpoilerService) replaceImgboxScreenshots(template string, movie Movie) string {
-	// Regular screenshots (BBThumb)
-	imgboxScreenshots := s.filterNonEmptyStrings(movie.ScreenshotURLsIB)
-	template = s.replaceScreenshotGroup(template, "%SCREENSHOTS_IB%", "%SCREENSHOTS_IB_SPACED%", imgboxScreenshots)
+	if hasPreset && preset.usesGoTemplate() {
+		template = s.renderGoTemplate(template, movie)
+	} else {
+		template = s.renderConditionalBlocks(template, movie)
+		template = s.replaceBasicPlaceholders(template, movie)
+		template = s.replaceUploadPlaceholders(template, movie)
+		template = s.replaceParameterPlaceholders(template, movie)
+		template = replaceNFOPlaceholders(template, movie)
+	}
+	template = s.limitSpoilerTitles(template)
 
-	// Big screenshots (BBBig)
-	imgboxScreenshotsBig := s.filterNonEmptyStrings(movie.ScreenshotBigURLsIB)
-	template = s.replaceScreenshotGroup(template, "%SCREENSHOTS_IB_BIG%", "%SCREENSHOTS_IB_BIG_SPACED%", imgboxScreenshotsBig)
+	if hasPreset && preset.CollapseBlankLines {
+		template = collapseBlankLines(template)
+	}
 
 	return template
 }
 
-// Replace Hamster screenshot placeholders
-func (s *SpoilerService) replaceHamsterScreenshots(template string, movie Movie) string {
-	// Regular screenshots (BBThumb)
-	hamsterScreenshots := s.filterNonEmptyStrings(movie.ScreenshotURLsHam)
-	template = s.replaceScreenshotGroup(template, "%SCREENSHOTS_HAM%", "%SCREENSHOTS_HAM_SPACED%", hamsterScreenshots)
+// Replace basic movie information placeholders
+func (s *SpoilerService) replaceBasicPlaceholders(template string, movie Movie) string {
+	replacements := map[string]string{
+		"%FILE_NAME%":       movie.FileName,
+		"%FILE_SIZE%":       movie.FileSize,
+		"%DURATION%":        movie.DurationFormatted,
+		"%WIDTH%":           movie.Width,
+		"%HEIGHT%":          movie.Height,
+		"%BIT_RATE%":        movie.BitRate,
+		"%VIDEO_BIT_RATE%":  movie.VideoBitRate,
+		"%AUDIO_BIT_RATE%":  movie.AudioBitRate,
+		"%VIDEO_CODEC%":     movie.VideoCodec,
+		"%AUDIO_CODEC%":     movie.AudioCodec,
+		"%EXTERNAL_SUBS%":   formatExternalSubtitles(movie.ExternalSubtitles),
+		"%AUDIO_TRACKS%":    formatMediaTracks(movie.AudioTracks),
+		"%SUBTITLE_TRACKS%": formatMediaTracks(movie.SubtitleTracks),
+		"%HDR_FORMAT%":      movie.HDRFormat,
+	}
 
-	// Big screenshots (BBBig)
-	hamsterScreenshotsBig := s.filterNonEmptyStrings(movie.ScreenshotBigURLsHam)
-	template = s.replaceScreenshotGroup(template, "%SCREENSHOTS_HAM_BIG%", "%SCREENSHOTS_HAM_BIG_SPACED%", hamsterScreenshotsBig)
+	for placeholder, value := range replacements {
+		template = strings.ReplaceAll(template, placeholder, value)
+	}
 
 	return template
 }
@@ -1261,10 +1492,14 @@ func (s *SpoilerService) replaceScreenshotGroup(template, newlinePlaceholder, sp
 	return template
 }
 
-// Replace parameter placeholders with movie-specific parameters
+// Replace parameter placeholders with movie-specific parameters. The NFO placeholders are
+// left for replaceNFOPlaceholders.
 func (s *SpoilerService) replaceParameterPlaceholders(template string, movie Movie) string {
 	paramPattern := regexp.MustCompile(`%[^%]+%`)
 	return paramPattern.ReplaceAllStringFunc(template, func(param string) string {
+		if param == "%NFO%" || param == "%NFO_SPOILER%" {
+			return param
+		}
 		if value, exists := movie.Params[param]; exists && value != "" {
 			return value
 		}
@@ -1293,21 +1528,98 @@ func (s *SpoilerService) filterNonEmptyStrings(urls []string) []string {
 
 // Settings management
 func (s *SpoilerService) GetSettings() AppSettings {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	if s.pendingSettings != nil {
+		return *s.pendingSettings
+	}
 	return s.settings
 }
 
-func (s *SpoilerService) UpdateSettings(settings AppSettings) {
-	s.settings = settings
+// UpdateSettings saves the settings. During processing they only take effect once the run
+// finishes, as workers hold slots of the current semaphores and read the current limits.
+// Host credentials cannot change mid-batch.
+func (s *SpoilerService) UpdateSettings(settings AppSettings) error {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	if s.processing && credentialsChanged(s.settings, settings) {
+		return fmt.Errorf("host credentials cannot be changed while processing, wait for the batch to finish or cancel it")
+	}
+	if configLocked() && credentialsChanged(s.settings, settings) {
+		return fmt.Errorf("host credentials cannot be changed while the config is locked, unlock it first")
+	}
+	if configLocked() && metadataKeysChanged(s.settings, settings) {
+		return fmt.Errorf("metadata API keys cannot be changed while the config is locked, unlock it first")
+	}
 
 	// Save to config
 	config := s.configManager.GetConfig()
 	config.ScreenshotCount = settings.ScreenshotCount
 	config.FastpicSID = settings.FastpicSID
+	config.FastpicDeleteAfterDays = settings.FastpicDeleteAfterDays
+	config.FastpicOrigResize = settings.FastpicOrigResize
+	config.FastpicOptimization = settings.FastpicOptimization
+	config.FastpicBaseURL = settings.FastpicBaseURL
+	config.FastpicMirrors = settings.FastpicMirrors
 	config.ScreenshotQuality = settings.ScreenshotQuality
 	config.MaxConcurrentScreenshots = settings.MaxConcurrentScreenshots
+	config.MaxConcurrentContactSheets = settings.MaxConcurrentContactSheets
 	config.MaxConcurrentUploads = settings.MaxConcurrentUploads
 	config.MtnArgs = settings.MtnArgs
 	config.ImageMiniatureSize = settings.ImageMiniatureSize
+	config.FastpicMiniatureSize = settings.FastpicMiniatureSize
+	config.ImgboxMiniatureSize = settings.ImgboxMiniatureSize
+	config.AnonymizeUploads = settings.AnonymizeUploads
+	config.RenamePattern = settings.RenamePattern
+	config.ReadOnlySources = settings.ReadOnlySources
+	config.GroupHeaderTemplate = settings.GroupHeaderTemplate
+	config.NestGroupSpoilers = settings.NestGroupSpoilers
+	config.CollectionSpoilerTemplate = settings.CollectionSpoilerTemplate
+	config.OutputLineEnding = settings.OutputLineEnding
+	config.OutputBOM = settings.OutputBOM
+	config.SpoilerTitleMaxLength = settings.SpoilerTitleMaxLength
+	config.PipelinedUploads = settings.PipelinedUploads
+	config.ProcessingOrder = settings.ProcessingOrder
+	config.ImgboxFamilySafe = settings.ImgboxFamilySafe
+	config.HamsterResizeWidth = settings.HamsterResizeWidth
+	config.HamsterExpiration = settings.HamsterExpiration
+	config.CatboxUserHash = settings.CatboxUserHash
+	config.CatboxTemporary = settings.CatboxTemporary
+	config.LitterboxExpiry = settings.LitterboxExpiry
+	config.BatchHeaderTemplate = settings.BatchHeaderTemplate
+	config.BatchFooterTemplate = settings.BatchFooterTemplate
+	config.ComputeChecksums = settings.ComputeChecksums
+	config.MaxTempUsageMB = settings.MaxTempUsageMB
+	config.ScreenshotMode = settings.ScreenshotMode
+	config.ScreenshotJitterSeconds = settings.ScreenshotJitterSeconds
+	config.ScreenshotStartOffset = settings.ScreenshotStartOffset
+	config.ScreenshotEndOffset = settings.ScreenshotEndOffset
+	config.BBCodeDialect = settings.BBCodeDialect
+	config.WatermarkText = settings.WatermarkText
+	config.WatermarkImage = settings.WatermarkImage
+	config.WatermarkPosition = settings.WatermarkPosition
+	config.WatermarkOpacity = settings.WatermarkOpacity
+	config.ScreenshotFormat = settings.ScreenshotFormat
+	config.WebPQuality = settings.WebPQuality
+	config.WebPLossless = settings.WebPLossless
+	config.HostUploadLimits = settings.HostUploadLimits
+	config.ResultFooterEnabled = settings.ResultFooterEnabled
+	config.ResultFooterTemplate = settings.ResultFooterTemplate
+	config.DisableSimilarityCheck = settings.DisableSimilarityCheck
+	config.LocalOutputDir = settings.LocalOutputDir
+	config.DisableUploadCache = settings.DisableUploadCache
+	config.TMDBAPIKey = settings.TMDBAPIKey
+	config.OMDbAPIKey = settings.OMDbAPIKey
+	config.MetadataLanguage = settings.MetadataLanguage
+	config.DegradeOnUploadFailures = settings.DegradeOnUploadFailures
+	config.DegradeAfterFailures = settings.DegradeAfterFailures
+	config.DegradedScreenshotCount = settings.DegradedScreenshotCount
+	config.DegradedQuality = settings.DegradedQuality
+	config.ChecklistItems = settings.ChecklistItems
+	config.AddTorrentVideos = settings.AddTorrentVideos
+	config.GroupByFolder = settings.GroupByFolder
 	config.HamsterEmail = settings.HamsterEmail
 	config.HamsterPassword = settings.HamsterPassword
 
@@ -1315,40 +1627,51 @@ func (s *SpoilerService) UpdateSettings(settings AppSettings) {
 		log.Printf("Failed to save settings: %v", err)
 	}
 
+	if s.processing {
+		s.pendingSettings = &settings
+		return nil
+	}
+	s.applySettings(settings)
+	return nil
+}
+
+// applySettings switches to new settings, call only while no run holds semaphore slots
+func (s *SpoilerService) applySettings(settings AppSettings) {
+	s.settings = settings
 	s.initSemaphores() // Reinitialize semaphores with new limits
+	s.buildUploaders()
 }
 
-func (s *SpoilerService) parseMtnArgs() []string {
+// applyPendingSettings applies the settings staged during a run, settingsMu must be held
+func (s *SpoilerService) applyPendingSettings() {
+	if s.pendingSettings == nil {
+		return
+	}
+	settings := *s.pendingSettings
+	s.pendingSettings = nil
+	s.applySettings(settings)
+}
 
-	// Simple argument parsing - split on spaces but handle quoted arguments
-	args := []string{}
-	current := ""
-	inQuotes := false
-
-	for i, char := range s.settings.MtnArgs {
-		switch char {
-		case '"':
-			inQuotes = !inQuotes
-		case ' ':
-			if !inQuotes {
-				if current != "" {
-					args = append(args, current)
-					current = ""
-				}
-			} else {
-				current += string(char)
-			}
-		default:
-			current += string(char)
-		}
+// credentialsChanged reports whether any image host login differs between the settings
+func credentialsChanged(current, updated AppSettings) bool {
+	return current.FastpicSID != updated.FastpicSID ||
+		current.HamsterEmail != updated.HamsterEmail ||
+		current.HamsterPassword != updated.HamsterPassword ||
+		current.CatboxUserHash != updated.CatboxUserHash
+}
 
-		// Add the last argument if we're at the end
-		if i == len(s.settings.MtnArgs)-1 && current != "" {
-			args = append(args, current)
-		}
-	}
+func (s *SpoilerService) parseMtnArgs() []string {
+	return splitMtnArgs(s.settings.MtnArgs)
+}
+
+// GetWindowState returns the persisted window geometry and theme preference
+func (s *SpoilerService) GetWindowState() WindowState {
+	return s.configManager.GetConfig().Window
+}
 
-	return args
+// SaveWindowState persists the window geometry and theme preference
+func (s *SpoilerService) SaveWindowState(state WindowState) error {
+	return s.configManager.SaveWindowState(state)
 }
 
 // Template management
@@ -1356,6 +1679,24 @@ func (s *SpoilerService) GetTemplate() string {
 	return s.configManager.GetCurrentTemplate()
 }
 
+// currentPreset returns the preset used for rendering, honoring a per-run override
+func (s *SpoilerService) currentPreset() (TemplatePreset, bool) {
+	if s.presetOverride != "" {
+		if preset, ok := s.configManager.FindPreset(s.presetOverride); ok {
+			return preset, true
+		}
+	}
+	return s.configManager.GetCurrentPreset()
+}
+
+// currentTemplate returns the template of the preset used for rendering
+func (s *SpoilerService) currentTemplate() string {
+	if preset, ok := s.currentPreset(); ok {
+		return preset.Template
+	}
+	return getDefaultTemplate()
+}
+
 func (s *SpoilerService) SetTemplate(template string) {
 	// Update the current preset's template
 	config := s.configManager.GetConfig()
@@ -1420,3 +1761,39 @@ func (s *SpoilerService) DeleteTemplatePreset(presetID string) error {
 func (s *SpoilerService) SetCurrentPreset(presetID string) error {
 	return s.configManager.SetCurrentPreset(presetID)
 }
+
+// SetPresetCollapseBlankLines toggles the blank line cleanup of a preset's rendered output
+func (s *SpoilerService) SetPresetCollapseBlankLines(presetID string, collapse bool) error {
+	return s.configManager.updatePreset(presetID, func(p *TemplatePreset) {
+		p.CollapseBlankLines = collapse
+	})
+}
+
+// SetPresetTemplateMode switches a preset between %PLACEHOLDER% and Go text/template syntax.
+// Switching to text/template fails when the preset's template does not parse.
+func (s *SpoilerService) SetPresetTemplateMode(presetID string, mode string) error {
+	if mode != TemplateModePlaceholders && mode != TemplateModeGo {
+		return fmt.Errorf("unknown template mode %q", mode)
+	}
+
+	var parseErr error
+	err := s.configManager.updatePreset(presetID, func(p *TemplatePreset) {
+		if mode == TemplateModeGo {
+			if parseErr = validateGoTemplate(p.Template); parseErr != nil {
+				return
+			}
+		}
+		p.TemplateMode = mode
+	})
+	if parseErr != nil {
+		return fmt.Errorf("template does not parse: %v", parseErr)
+	}
+	return err
+}
+
+// SetPresetImgboxFamilySafe overrides the imgbox content flag for a preset, nil restores the global setting
+func (s *SpoilerService) SetPresetImgboxFamilySafe(presetID string, familySafe *bool) error {
+	return s.configManager.updatePreset(presetID, func(p *TemplatePreset) {
+		p.ImgboxFamilySafe = familySafe
+	})
+}
@@ -0,0 +1,134 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	maxStatsSamples = 200
+
+	// Fallback timings used until enough history has been collected
+	defaultSecondsPerGB         = 20.0
+	defaultSecondsPerScreenshot = 4.0
+)
+
+// ProcessingSample records how long processing a single movie took
+type ProcessingSample struct {
+	SizeBytes      int64     `json:"sizeBytes"`
+	Screenshots    int       `json:"screenshots"`
+	ElapsedSeconds float64   `json:"elapsedSeconds"`
+	RecordedAt     time.Time `json:"recordedAt"`
+}
+
+// ProcessingEstimate is a dry-run estimate of how long processing the pending movies will take
+type ProcessingEstimate struct {
+	MovieCount       int     `json:"movieCount"`
+	TotalSizeBytes   int64   `json:"totalSizeBytes"`
+	EstimatedSeconds float64 `json:"estimatedSeconds"`
+	Formatted        string  `json:"formatted"`
+	BasedOnHistory   bool    `json:"basedOnHistory"` // False when default timings were used
+}
+
+// StatsStore persists processing timings in the config directory
+type StatsStore struct {
+	mu      sync.Mutex
+	path    string
+	Samples []ProcessingSample `json:"samples"`
+}
+
+func NewStatsStore() *StatsStore {
+	store := &StatsStore{
+		path: filepath.Join(getConfigDir(), "stats.json"),
+	}
+	store.load()
+	return store
+}
+
+func (st *StatsStore) load() {
+	data, err := os.ReadFile(st.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		log.Printf("Failed to parse processing stats: %v", err)
+	}
+}
+
+func (st *StatsStore) save() error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(st.path), 0755); err != nil {
+		return fmt.Errorf("failed to create stats directory: %v", err)
+	}
+	return os.WriteFile(st.path, data, 0644)
+}
+
+// Record adds a processing sample and persists the store
+func (st *StatsStore) Record(sample ProcessingSample) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.Samples = append(st.Samples, sample)
+	if len(st.Samples) > maxStatsSamples {
+		st.Samples = st.Samples[len(st.Samples)-maxStatsSamples:]
+	}
+
+	if err := st.save(); err != nil {
+		log.Printf("Failed to save processing stats: %v", err)
+	}
+}
+
+// secondsPerGB returns the historical processing time per gigabyte, if any history exists
+func (st *StatsStore) secondsPerGB() (float64, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var totalSeconds, totalGB float64
+	for _, sample := range st.Samples {
+		totalSeconds += sample.ElapsedSeconds
+		totalGB += float64(sample.SizeBytes) / (1 << 30)
+	}
+	if len(st.Samples) == 0 || totalGB <= 0 {
+		return 0, false
+	}
+	return totalSeconds / totalGB, true
+}
+
+// EstimateProcessingTime estimates how long processing the pending movies will take,
+// based on historical per-GB timings when available
+func (s *SpoilerService) EstimateProcessingTime() ProcessingEstimate {
+	pending := s.getPendingMovies()
+	estimate := ProcessingEstimate{MovieCount: len(pending)}
+	if len(pending) == 0 {
+		estimate.Formatted = FormatDuration(0)
+		return estimate
+	}
+
+	perGB, fromHistory := s.stats.secondsPerGB()
+	estimate.BasedOnHistory = fromHistory
+
+	var totalSeconds float64
+	for _, movie := range pending {
+		estimate.TotalSizeBytes += movie.FileSizeBytes
+		sizeGB := float64(movie.FileSizeBytes) / (1 << 30)
+		if fromHistory {
+			totalSeconds += sizeGB * perGB
+		} else {
+			totalSeconds += sizeGB*defaultSecondsPerGB + float64(s.settings.ScreenshotCount)*defaultSecondsPerScreenshot
+		}
+	}
+
+	// Movies are processed in parallel, bounded by the screenshot slots
+	parallelism := min(max(s.settings.MaxConcurrentScreenshots, 1), len(pending))
+	estimate.EstimatedSeconds = totalSeconds / float64(parallelism)
+	estimate.Formatted = FormatDuration(time.Duration(estimate.EstimatedSeconds * float64(time.Second)))
+	return estimate
+}
@@ -0,0 +1,118 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// subtitleExtensions are the sidecar subtitle formats that are detected
+var subtitleExtensions = []string{".srt", ".ass", ".ssa", ".vtt", ".sub"}
+
+// subtitleDirs are checked next to the video for subtitles kept in a subfolder
+var subtitleDirs = []string{"", "Subs", "Subtitles"}
+
+// subtitleLanguages maps common ISO 639-1 and 639-2 codes to language names
+var subtitleLanguages = map[string]string{
+	"en": "English", "eng": "English",
+	"ru": "Russian", "rus": "Russian",
+	"uk": "Ukrainian", "ukr": "Ukrainian",
+	"de": "German", "ger": "German", "deu": "German",
+	"fr": "French", "fre": "French", "fra": "French",
+	"es": "Spanish", "spa": "Spanish",
+	"it": "Italian", "ita": "Italian",
+	"pt": "Portuguese", "por": "Portuguese",
+	"pl": "Polish", "pol": "Polish",
+	"cs": "Czech", "cze": "Czech", "ces": "Czech",
+	"nl": "Dutch", "dut": "Dutch", "nld": "Dutch",
+	"sv": "Swedish", "swe": "Swedish",
+	"fi": "Finnish", "fin": "Finnish",
+	"tr": "Turkish", "tur": "Turkish",
+	"ja": "Japanese", "jpn": "Japanese",
+	"ko": "Korean", "kor": "Korean",
+	"zh": "Chinese", "chi": "Chinese", "zho": "Chinese",
+	"ar": "Arabic", "ara": "Arabic",
+	"he": "Hebrew", "heb": "Hebrew",
+}
+
+// ExternalSubtitle is a sidecar subtitle file of a movie
+type ExternalSubtitle struct {
+	FilePath string `json:"filePath"`
+	Language string `json:"language"`        // Language name, the raw tag when unknown, empty without one
+	Format   string `json:"format"`          // SRT, ASS, ...
+	Flags    string `json:"flags,omitempty"` // Remaining tags such as "forced" or "sdh"
+}
+
+// String renders the subtitle as "English (SRT, forced)"
+func (e ExternalSubtitle) String() string {
+	language := e.Language
+	if language == "" {
+		language = "Unknown"
+	}
+	details := e.Format
+	if e.Flags != "" {
+		details += ", " + e.Flags
+	}
+	return language + " (" + details + ")"
+}
+
+// findExternalSubtitles lists subtitle files named after the video, like "Movie.srt" or
+// "Movie.en.forced.ass", next to it or in a Subs folder
+func findExternalSubtitles(videoPath, videoName string) []ExternalSubtitle {
+	dir := filepath.Dir(videoPath)
+	base := strings.TrimSuffix(videoName, filepath.Ext(videoName))
+
+	var subtitles []ExternalSubtitle
+	for _, subDir := range subtitleDirs {
+		entries, err := os.ReadDir(filepath.Join(dir, subDir))
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			ext := strings.ToLower(filepath.Ext(name))
+			if entry.IsDir() || !slices.Contains(subtitleExtensions, ext) {
+				continue
+			}
+
+			stem := strings.TrimSuffix(name, filepath.Ext(name))
+			if !strings.EqualFold(stem, base) && !strings.HasPrefix(strings.ToLower(stem), strings.ToLower(base)+".") {
+				continue
+			}
+
+			subtitle := ExternalSubtitle{
+				FilePath: filepath.Join(dir, subDir, name),
+				Format:   strings.ToUpper(strings.TrimPrefix(ext, ".")),
+			}
+			var flags []string
+			for _, tag := range strings.Split(strings.TrimPrefix(stem[len(base):], "."), ".") {
+				if tag == "" {
+					continue
+				}
+				if language, ok := subtitleLanguages[strings.ToLower(tag)]; ok && subtitle.Language == "" {
+					subtitle.Language = language
+				} else {
+					flags = append(flags, tag)
+				}
+			}
+			// A single unknown tag is most likely the language
+			if subtitle.Language == "" && len(flags) == 1 {
+				subtitle.Language, flags = flags[0], nil
+			}
+			subtitle.Flags = strings.Join(flags, ", ")
+			subtitles = append(subtitles, subtitle)
+		}
+	}
+	return subtitles
+}
+
+// formatExternalSubtitles renders the %EXTERNAL_SUBS% list, empty without subtitles
+func formatExternalSubtitles(subtitles []ExternalSubtitle) string {
+	parts := make([]string, len(subtitles))
+	for i, subtitle := range subtitles {
+		parts[i] = subtitle.String()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,66 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// TimelineEvent is a single entry in a movie's processing timeline
+type TimelineEvent struct {
+	Time    time.Time `json:"time"`
+	Stage   string    `json:"stage"` // e.g. "analysis", "screenshot", "upload"
+	Message string    `json:"message"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// movieTimelines keeps per-movie event logs, safe for concurrent use
+type movieTimelines struct {
+	mu     sync.Mutex
+	events map[string][]TimelineEvent
+}
+
+func newMovieTimelines() *movieTimelines {
+	return &movieTimelines{events: make(map[string][]TimelineEvent)}
+}
+
+func (t *movieTimelines) add(movieID string, event TimelineEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events[movieID] = append(t.events[movieID], event)
+}
+
+func (t *movieTimelines) get(movieID string) []TimelineEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TimelineEvent{}, t.events[movieID]...)
+}
+
+func (t *movieTimelines) remove(movieID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.events, movieID)
+}
+
+func (t *movieTimelines) clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = make(map[string][]TimelineEvent)
+}
+
+// recordEvent appends an event to a movie's timeline
+func (s *SpoilerService) recordEvent(movieID, stage, message string, err error) {
+	event := TimelineEvent{
+		Time:    time.Now(),
+		Stage:   stage,
+		Message: message,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	s.timelines.add(movieID, event)
+}
+
+// GetMovieTimeline returns the recorded processing events of a movie in chronological order
+func (s *SpoilerService) GetMovieTimeline(id string) []TimelineEvent {
+	return s.timelines.get(id)
+}
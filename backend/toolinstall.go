@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// packageManager installs tools through the system's package manager
+type packageManager struct {
+	Binary   string            // Looked up to detect the package manager
+	Install  string            // Command the package names are appended to
+	Packages map[string]string // Package per tool, tools it does not package are missing
+}
+
+// packageManagers lists the package managers per platform, the first one found is suggested
+var packageManagers = map[string][]packageManager{
+	"darwin": {
+		{Binary: "brew", Install: "brew install", Packages: map[string]string{"ffmpeg": "ffmpeg", "ffprobe": "ffmpeg", "mediainfo": "media-info"}},
+		{Binary: "port", Install: "sudo port install", Packages: map[string]string{"ffmpeg": "ffmpeg", "ffprobe": "ffmpeg", "mediainfo": "mediainfo"}},
+	},
+	"linux": {
+		{Binary: "apt-get", Install: "sudo apt install", Packages: map[string]string{"ffmpeg": "ffmpeg", "ffprobe": "ffmpeg", "mediainfo": "mediainfo"}},
+		{Binary: "dnf", Install: "sudo dnf install", Packages: map[string]string{"ffmpeg": "ffmpeg", "ffprobe": "ffmpeg", "mediainfo": "mediainfo"}},
+		{Binary: "pacman", Install: "sudo pacman -S", Packages: map[string]string{"ffmpeg": "ffmpeg", "ffprobe": "ffmpeg", "mediainfo": "mediainfo"}},
+		{Binary: "zypper", Install: "sudo zypper install", Packages: map[string]string{"ffmpeg": "ffmpeg", "ffprobe": "ffmpeg", "mediainfo": "mediainfo"}},
+	},
+}
+
+// toolHomepages is where tools that no package manager provides can be obtained
+var toolHomepages = map[string]string{
+	"ffmpeg":    "https://ffmpeg.org/download.html",
+	"ffprobe":   "https://ffmpeg.org/download.html",
+	"mtn":       "https://gitlab.com/movie_thumbnailer/mtn",
+	"mediainfo": "https://mediaarea.net/en/MediaInfo/Download",
+}
+
+// detectPackageManager returns the first package manager of this platform that is installed
+func detectPackageManager() (packageManager, bool) {
+	for _, manager := range packageManagers[runtime.GOOS] {
+		if _, err := lookPath(manager.Binary); err == nil {
+			return manager, true
+		}
+	}
+	return packageManager{}, false
+}
+
+// installCommand returns the package manager command installing the given tools, empty when
+// no package manager is found or none of the tools is packaged
+func installCommand(names []string) string {
+	manager, ok := detectPackageManager()
+	if !ok {
+		return ""
+	}
+	var packages []string
+	for _, name := range names {
+		if pkg, packaged := manager.Packages[name]; packaged && !slices.Contains(packages, pkg) {
+			packages = append(packages, pkg)
+		}
+	}
+	if len(packages) == 0 {
+		return ""
+	}
+	return manager.Install + " " + strings.Join(packages, " ")
+}
+
+// InstallInstructions explains how to install the given tools on this platform, with the
+// package manager command when there is one and the download pages otherwise
+func InstallInstructions(names []string) string {
+	var b strings.Builder
+	manager, hasManager := detectPackageManager()
+	if command := installCommand(names); command != "" {
+		fmt.Fprintf(&b, "Install with %s by running in a terminal:\n\n    %s\n", manager.Binary, command)
+	}
+
+	var pages []string
+	for _, name := range names {
+		if _, packaged := manager.Packages[name]; hasManager && packaged {
+			continue
+		}
+		if page := toolHomepages[name]; page != "" && !slices.Contains(pages, page) {
+			pages = append(pages, page)
+			fmt.Fprintf(&b, "\n%s: %s", name, page)
+		}
+	}
+	if runtime.GOOS == "darwin" && !hasManager {
+		b.WriteString("\n\nHomebrew (https://brew.sh) can install FFmpeg with: brew install ffmpeg")
+	}
+	return strings.TrimSpace(b.String())
+}
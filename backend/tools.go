@@ -0,0 +1,311 @@
+package backend
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Tools used for processing; ffmpeg and ffprobe are required, the others optional
+var (
+	requiredTools = []string{"ffmpeg", "ffprobe"}
+	optionalTools = []string{"mtn", "mediainfo"}
+)
+
+const toolDownloadTimeout = 10 * time.Minute
+
+// toolDownload is a zip archive with static builds of one or more tools
+type toolDownload struct {
+	URL    string
+	SHA256 string   // Checksum of the archive, downloads without one are not offered
+	Tools  []string // Binaries extracted from the archive
+}
+
+// toolDownloads lists the static builds per platform. The URLs point at fixed releases so the
+// pinned checksums stay valid; bump both together. Platforms without an entry are expected to
+// install the tools through their package manager; mtn has no static builds, the built-in
+// contact sheet generator is used without it.
+var toolDownloads = map[string][]toolDownload{
+	"windows/amd64": {
+		{URL: "https://github.com/GyanD/codexffmpeg/releases/download/7.1/ffmpeg-7.1-essentials_build.zip", Tools: []string{"ffmpeg", "ffprobe"}},
+		{URL: "https://mediaarea.net/download/binary/mediainfo/24.06/MediaInfo_CLI_24.06_Windows_x64.zip", Tools: []string{"mediainfo"}},
+	},
+	"darwin/amd64": {
+		{URL: "https://evermeet.cx/ffmpeg/ffmpeg-7.1.zip", Tools: []string{"ffmpeg"}},
+		{URL: "https://evermeet.cx/ffmpeg/ffprobe-7.1.zip", Tools: []string{"ffprobe"}},
+	},
+	"darwin/arm64": {
+		{URL: "https://www.osxexperts.net/ffmpeg71arm.zip", Tools: []string{"ffmpeg"}},
+		{URL: "https://www.osxexperts.net/ffprobe71arm.zip", Tools: []string{"ffprobe"}},
+	},
+}
+
+// ToolDownloadChecksums returns the pinned SHA-256 checksum of every tool archive by URL
+func ToolDownloadChecksums() map[string]string {
+	checksums := make(map[string]string)
+	for _, downloads := range toolDownloads {
+		for _, download := range downloads {
+			checksums[download.URL] = download.SHA256
+		}
+	}
+	return checksums
+}
+
+// extraToolDirs are searched after PATH. Apps started from the macOS Finder get a minimal
+// PATH without the Homebrew and MacPorts directories.
+var extraToolDirs = map[string][]string{
+	"darwin": {"/opt/homebrew/bin", "/usr/local/bin", "/opt/local/bin"},
+	"linux":  {"/usr/local/bin", "/snap/bin"},
+}
+
+// ToolStatus reports where a tool was found and whether it can be downloaded
+type ToolStatus struct {
+	Name           string `json:"name"`
+	Required       bool   `json:"required"`
+	Installed      bool   `json:"installed"`
+	Path           string `json:"path,omitempty"`
+	Bundled        bool   `json:"bundled"` // Found in the tools directory instead of PATH
+	Downloadable   bool   `json:"downloadable"`
+	InstallCommand string `json:"installCommand,omitempty"` // Package manager command, when one packages the tool
+}
+
+// toolsDir is where downloaded tools are kept
+func toolsDir() string {
+	return filepath.Join(getConfigDir(), "tools")
+}
+
+func toolFileName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// findTool resolves a tool, preferring the installed one over a downloaded build so system
+// updates are picked up
+func findTool(name string) (string, error) {
+	path, err := lookPath(name)
+	if err == nil {
+		return path, nil
+	}
+	bundled := filepath.Join(toolsDir(), toolFileName(name))
+	if info, statErr := os.Stat(bundled); statErr == nil && !info.IsDir() {
+		return bundled, nil
+	}
+	return "", err
+}
+
+// lookPath searches PATH and then the platform's usual install directories
+func lookPath(name string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err == nil {
+		return path, nil
+	}
+	for _, dir := range extraToolDirs[runtime.GOOS] {
+		candidate := filepath.Join(dir, toolFileName(name))
+		if info, statErr := os.Stat(candidate); statErr == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			return candidate, nil
+		}
+	}
+	return "", err
+}
+
+// toolPath returns the resolved path of a tool, or its bare name so a missing tool fails
+// with the usual "executable file not found" error when run
+func toolPath(name string) string {
+	if path, err := findTool(name); err == nil {
+		return path
+	}
+	return name
+}
+
+func toolInstalled(name string) bool {
+	_, err := findTool(name)
+	return err == nil
+}
+
+// toolDownloadFor returns the static build that provides a tool on this platform
+func toolDownloadFor(name string) (toolDownload, bool) {
+	for _, download := range toolDownloads[runtime.GOOS+"/"+runtime.GOARCH] {
+		if slices.Contains(download.Tools, name) && download.SHA256 != "" {
+			return download, true
+		}
+	}
+	return toolDownload{}, false
+}
+
+// CheckTools reports the status of every external tool
+func CheckTools() []ToolStatus {
+	var statuses []ToolStatus
+	for _, name := range append(append([]string{}, requiredTools...), optionalTools...) {
+		status := ToolStatus{Name: name, Required: slices.Contains(requiredTools, name)}
+		if path, err := findTool(name); err == nil {
+			status.Installed = true
+			status.Path = path
+			status.Bundled = strings.HasPrefix(path, toolsDir())
+		}
+		_, status.Downloadable = toolDownloadFor(name)
+		if !status.Installed {
+			status.InstallCommand = installCommand([]string{name})
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// MissingTools returns the names of missing tools, only the required ones unless all is set
+func MissingTools(all bool) []string {
+	var missing []string
+	for _, status := range CheckTools() {
+		if !status.Installed && (status.Required || all) {
+			missing = append(missing, status.Name)
+		}
+	}
+	return missing
+}
+
+// CanDownloadTools reports whether static builds of all the given tools are available
+func CanDownloadTools(names []string) bool {
+	for _, name := range names {
+		if _, ok := toolDownloadFor(name); !ok {
+			return false
+		}
+	}
+	return len(names) > 0
+}
+
+// DownloadTools fetches static builds of the given tools into the tools directory
+func DownloadTools(names []string) error {
+	var downloads []toolDownload
+	for _, name := range names {
+		download, ok := toolDownloadFor(name)
+		if !ok {
+			return fmt.Errorf("no static build of %s is available for %s/%s", name, runtime.GOOS, runtime.GOARCH)
+		}
+		if !slices.ContainsFunc(downloads, func(d toolDownload) bool { return d.URL == download.URL }) {
+			downloads = append(downloads, download)
+		}
+	}
+
+	if err := os.MkdirAll(toolsDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create tools directory: %v", err)
+	}
+	for _, download := range downloads {
+		log.Printf("Downloading %s from %s", strings.Join(download.Tools, ", "), download.URL)
+		if err := downloadToolArchive(download); err != nil {
+			return fmt.Errorf("failed to download %s: %v", strings.Join(download.Tools, ", "), err)
+		}
+	}
+	return nil
+}
+
+// downloadToolArchive downloads a zip archive and extracts the wanted binaries from it
+func downloadToolArchive(download toolDownload) error {
+	client := &http.Client{Timeout: toolDownloadTimeout}
+	resp, err := client.Get(download.URL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	archive, err := os.CreateTemp("", "spoilr_tool_*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(archive, hash), resp.Body)
+	if err != nil {
+		return err
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, download.SHA256) {
+		return fmt.Errorf("checksum mismatch: got %s, want %s", sum, download.SHA256)
+	}
+
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %v", err)
+	}
+
+	extracted := make(map[string]bool)
+	for _, file := range reader.File {
+		for _, name := range download.Tools {
+			if file.FileInfo().IsDir() || !strings.EqualFold(filepath.Base(file.Name), toolFileName(name)) {
+				continue
+			}
+			if err := extractToolFile(file, filepath.Join(toolsDir(), toolFileName(name))); err != nil {
+				return err
+			}
+			extracted[name] = true
+		}
+	}
+	for _, name := range download.Tools {
+		if !extracted[name] {
+			return fmt.Errorf("%s not found in archive", name)
+		}
+	}
+	return nil
+}
+
+// extractToolFile writes a single archive entry as an executable, through a temp file so an
+// interrupted download never leaves a broken binary behind
+func extractToolFile(file *zip.File, dest string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmpPath := dest + ".tmp"
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, dest)
+}
+
+// GetToolStatus reports the external tools to the frontend
+func (s *SpoilerService) GetToolStatus() []ToolStatus {
+	return CheckTools()
+}
+
+// DownloadMissingTools downloads static builds of every missing tool available for this platform
+func (s *SpoilerService) DownloadMissingTools() ([]ToolStatus, error) {
+	var names []string
+	for _, name := range MissingTools(true) {
+		if _, ok := toolDownloadFor(name); ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return CheckTools(), fmt.Errorf("no downloadable tools are missing")
+	}
+	if err := DownloadTools(names); err != nil {
+		return CheckTools(), err
+	}
+	return CheckTools(), nil
+}
@@ -0,0 +1,356 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxTorrentSize  = 16 << 20 // Larger files are not torrents
+	maxBencodeDepth = 64
+)
+
+// TorrentInfo is the metadata of a dropped .torrent file
+type TorrentInfo struct {
+	Path      string        `json:"path"`
+	Name      string        `json:"name"`
+	Size      int64         `json:"size"`
+	PieceSize int64         `json:"pieceSize"`
+	InfoHash  string        `json:"infoHash"` // Hex SHA-1 of the info dictionary
+	Trackers  []string      `json:"trackers,omitempty"`
+	Files     []TorrentFile `json:"-"` // Not kept with the session, large torrents list thousands
+}
+
+// TorrentFile is a file of a torrent, its path relative to the torrent's data folder
+type TorrentFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// params returns the %TORRENT_*% placeholders of the torrent
+func (t TorrentInfo) params() map[string]string {
+	tracker := ""
+	if len(t.Trackers) > 0 {
+		tracker = trackerHost(t.Trackers[0])
+	}
+	return map[string]string{
+		"%TORRENT_NAME%": t.Name,
+		"%TORRENT_SIZE%": FormatFileSize(t.Size),
+		"%PIECE_SIZE%":   FormatFileSize(t.PieceSize),
+		"%INFO_HASH%":    t.InfoHash,
+		"%TRACKER%":      tracker,
+	}
+}
+
+// trackerHost returns the host of an announce URL. Private trackers put the passkey in the
+// URL, so the full URL never ends up in a post.
+func trackerHost(announce string) string {
+	parsed, err := url.Parse(announce)
+	if err != nil || parsed.Hostname() == "" {
+		return ""
+	}
+	return parsed.Hostname()
+}
+
+// isTorrentFile reports whether path is a .torrent file by its extension
+func isTorrentFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".torrent")
+}
+
+// readTorrent reads and parses a .torrent file
+func readTorrent(path string) (*TorrentInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxTorrentSize {
+		return nil, fmt.Errorf("%s is too large for a torrent file", filepath.Base(path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	torrent, err := ParseTorrent(data)
+	if err != nil {
+		return nil, err
+	}
+	torrent.Path = path
+	return torrent, nil
+}
+
+// ParseTorrent parses the content of a .torrent file. Only v1 and hybrid torrents are
+// supported, v2-only torrents have no file list in the v1 format.
+func ParseTorrent(data []byte) (*TorrentInfo, error) {
+	decoder := &bencodeDecoder{data: data}
+	value, err := decoder.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid torrent file: %v", err)
+	}
+	root, _ := value.(map[string]any)
+	dict, ok := root["info"].(map[string]any)
+	if !ok || decoder.infoEnd == 0 {
+		return nil, fmt.Errorf("invalid torrent file: no info dictionary")
+	}
+
+	torrent := &TorrentInfo{}
+	torrent.Name, _ = dict["name"].(string)
+	torrent.PieceSize, _ = dict["piece length"].(int64)
+	if torrent.Name == "" || torrent.PieceSize <= 0 {
+		return nil, fmt.Errorf("invalid torrent file: missing name or piece length")
+	}
+	if !safePathSegment(torrent.Name) {
+		return nil, fmt.Errorf("invalid torrent file: unsafe name")
+	}
+	sum := sha1.Sum(data[decoder.infoStart:decoder.infoEnd])
+	torrent.InfoHash = hex.EncodeToString(sum[:])
+
+	if length, ok := dict["length"].(int64); ok {
+		torrent.Files = []TorrentFile{{Path: torrent.Name, Size: length}}
+	} else if files, ok := dict["files"].([]any); ok {
+		for _, entry := range files {
+			file, _ := entry.(map[string]any)
+			length, _ := file["length"].(int64)
+			segments, _ := file["path"].([]any)
+			parts := []string{torrent.Name}
+			for _, segment := range segments {
+				part, _ := segment.(string)
+				if !safePathSegment(part) {
+					return nil, fmt.Errorf("invalid torrent file: unsafe file path")
+				}
+				parts = append(parts, part)
+			}
+			if len(parts) == 1 {
+				continue
+			}
+			torrent.Files = append(torrent.Files, TorrentFile{Path: filepath.Join(parts...), Size: length})
+		}
+	} else {
+		return nil, fmt.Errorf("v2-only torrents are not supported")
+	}
+	for _, file := range torrent.Files {
+		torrent.Size += file.Size
+	}
+
+	if announce, ok := root["announce"].(string); ok && announce != "" {
+		torrent.Trackers = append(torrent.Trackers, announce)
+	}
+	if tiers, ok := root["announce-list"].([]any); ok {
+		for _, tier := range tiers {
+			urls, _ := tier.([]any)
+			for _, entry := range urls {
+				if announce, ok := entry.(string); ok && announce != "" && !slices.Contains(torrent.Trackers, announce) {
+					torrent.Trackers = append(torrent.Trackers, announce)
+				}
+			}
+		}
+	}
+	return torrent, nil
+}
+
+// safePathSegment reports whether a name from a torrent is a single path element, so paths
+// built from it stay inside the data folder
+func safePathSegment(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// localVideos returns the video files of the torrent that exist next to the .torrent file,
+// where clients usually save the data the torrent was created from
+func (t TorrentInfo) localVideos() []string {
+	dir := filepath.Dir(t.Path)
+	var videos []string
+	for _, file := range t.Files {
+		if !releaseExtensionPattern.MatchString(filepath.Ext(file.Path)) {
+			continue
+		}
+		path := filepath.Join(dir, file.Path)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && info.Size() == file.Size {
+			videos = append(videos, path)
+		}
+	}
+	return videos
+}
+
+// contains reports whether the local file at path belongs to the torrent
+func (t TorrentInfo) contains(path string) bool {
+	dir := filepath.Dir(t.Path)
+	for _, file := range t.Files {
+		if filepath.Clean(filepath.Join(dir, file.Path)) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTorrents separates the .torrent files from the dropped paths and parses them. With
+// AddTorrentVideos the torrents' local video files are added to the paths.
+func (s *SpoilerService) splitTorrents(paths []string) ([]string, []*TorrentInfo, []AnalysisFailure) {
+	var files []string
+	var torrents []*TorrentInfo
+	var failures []AnalysisFailure
+	for _, path := range paths {
+		if !isTorrentFile(path) {
+			files = append(files, path)
+			continue
+		}
+		torrent, err := readTorrent(path)
+		if err != nil {
+			log.Printf("Failed to read torrent %s: %v", filepath.Base(path), err)
+			failures = append(failures, AnalysisFailure{FilePath: path, Error: err.Error()})
+			continue
+		}
+		torrents = append(torrents, torrent)
+	}
+
+	if s.settings.AddTorrentVideos {
+		for _, torrent := range torrents {
+			for _, video := range torrent.localVideos() {
+				if !slices.Contains(files, video) {
+					files = append(files, video)
+				}
+			}
+		}
+	}
+	return files, torrents, failures
+}
+
+// setTorrentParams attaches the torrent a new movie's file belongs to, if any
+func setTorrentParams(movie *Movie, torrents []*TorrentInfo) {
+	for _, torrent := range torrents {
+		if !torrent.contains(movie.FilePath) {
+			continue
+		}
+		movie.Torrent = torrent
+		for key, value := range torrent.params() {
+			if value != "" {
+				movie.Params[key] = value
+			}
+		}
+		return
+	}
+}
+
+// bencodeDecoder decodes bencoded data, recording where the top-level info dictionary is so
+// its hash can be taken over the original bytes
+type bencodeDecoder struct {
+	data      []byte
+	pos       int
+	infoStart int
+	infoEnd   int
+}
+
+func (d *bencodeDecoder) decode(depth int) (any, error) {
+	if depth > maxBencodeDepth {
+		return nil, fmt.Errorf("nested too deeply")
+	}
+	if d.pos >= len(d.data) {
+		return nil, fmt.Errorf("unexpected end of data")
+	}
+
+	switch c := d.data[d.pos]; {
+	case c == 'i':
+		end := d.find('e', d.pos+1)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated integer")
+		}
+		value, err := strconv.ParseInt(string(d.data[d.pos+1:end]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer at %d", d.pos)
+		}
+		d.pos = end + 1
+		return value, nil
+	case c == 'l':
+		d.pos++
+		list := []any{}
+		for d.pos < len(d.data) && d.data[d.pos] != 'e' {
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		if d.pos >= len(d.data) {
+			return nil, fmt.Errorf("unterminated list")
+		}
+		d.pos++
+		return list, nil
+	case c == 'd':
+		d.pos++
+		dict := map[string]any{}
+		for d.pos < len(d.data) && d.data[d.pos] != 'e' {
+			key, err := d.decodeString()
+			if err != nil {
+				return nil, err
+			}
+			start := d.pos
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if depth == 0 && key == "info" {
+				if _, ok := value.(map[string]any); ok {
+					d.infoStart, d.infoEnd = start, d.pos
+				}
+			}
+			dict[key] = value
+		}
+		if d.pos >= len(d.data) {
+			return nil, fmt.Errorf("unterminated dictionary")
+		}
+		d.pos++
+		return dict, nil
+	case c >= '0' && c <= '9':
+		return d.decodeString()
+	default:
+		return nil, fmt.Errorf("unexpected %q at %d", c, d.pos)
+	}
+}
+
+func (d *bencodeDecoder) decodeString() (string, error) {
+	colon := d.find(':', d.pos)
+	if colon < 0 {
+		return "", fmt.Errorf("invalid string at %d", d.pos)
+	}
+	length, err := strconv.Atoi(string(d.data[d.pos:colon]))
+	if err != nil || length < 0 || length > len(d.data)-colon-1 {
+		return "", fmt.Errorf("invalid string length at %d", d.pos)
+	}
+	d.pos = colon + 1 + length
+	return string(d.data[colon+1 : d.pos]), nil
+}
+
+func (d *bencodeDecoder) find(b byte, from int) int {
+	if i := bytes.IndexByte(d.data[from:], b); i >= 0 {
+		return from + i
+	}
+	return -1
+}
+
+// unmatchedTorrents reports the torrents none of the added movies belongs to
+func (s *SpoilerService) unmatchedTorrents(torrents []*TorrentInfo, movieIDs []string) []AnalysisFailure {
+	var failures []AnalysisFailure
+	for _, torrent := range torrents {
+		matched := slices.ContainsFunc(movieIDs, func(id string) bool {
+			movie, exists := s.getMovieByID(id)
+			return exists && movie.Torrent == torrent
+		})
+		if matched {
+			continue
+		}
+		message := "none of its video files were added, drop them too or enable adding torrent videos"
+		if s.settings.AddTorrentVideos {
+			message = "none of its video files were found next to it"
+		}
+		log.Printf("Torrent %s: %s", filepath.Base(torrent.Path), message)
+		failures = append(failures, AnalysisFailure{FilePath: torrent.Path, Error: message})
+	}
+	return failures
+}
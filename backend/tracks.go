@@ -0,0 +1,134 @@
+package backend
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// trackCodecNames maps ffprobe codec names to the names posts usually show
+var trackCodecNames = map[string]string{
+	"aac":               "AAC",
+	"ac3":               "AC3",
+	"eac3":              "E-AC3",
+	"dts":               "DTS",
+	"truehd":            "TrueHD",
+	"flac":              "FLAC",
+	"opus":              "Opus",
+	"vorbis":            "Vorbis",
+	"mp3":               "MP3",
+	"mp2":               "MP2",
+	"pcm_s16le":         "PCM",
+	"pcm_s24le":         "PCM",
+	"subrip":            "SRT",
+	"ass":               "ASS",
+	"ssa":               "SSA",
+	"webvtt":            "WebVTT",
+	"mov_text":          "TX3G",
+	"hdmv_pgs_subtitle": "PGS",
+	"dvd_subtitle":      "VobSub",
+	"dvb_subtitle":      "DVB",
+}
+
+// MediaTrack is an audio or subtitle stream of a video
+type MediaTrack struct {
+	Language string `json:"language,omitempty"` // Language name, the raw tag when unknown
+	Codec    string `json:"codec"`              // Display name, e.g. "E-AC3" or "PGS"
+	Channels int    `json:"channels,omitempty"` // Audio only
+	BitRate  string `json:"bitRate,omitempty"`  // Bits per second, audio only
+	Title    string `json:"title,omitempty"`
+	Default  bool   `json:"default,omitempty"`
+	Forced   bool   `json:"forced,omitempty"`
+}
+
+// newMediaTrack builds a track from the fields of an ffprobe stream
+func newMediaTrack(codec, profile string, channels int, bitRate string, tags map[string]string, disposition map[string]int) MediaTrack {
+	track := MediaTrack{
+		Codec:    trackCodecName(codec, profile),
+		Channels: channels,
+		BitRate:  bitRate,
+		Default:  disposition["default"] == 1,
+		Forced:   disposition["forced"] == 1,
+	}
+	if track.BitRate == "" {
+		track.BitRate = tags["BPS"]
+	}
+	if language := strings.ToLower(tags["language"]); language != "" && language != "und" {
+		track.Language = language
+		if name, ok := subtitleLanguages[language]; ok {
+			track.Language = name
+		}
+	}
+	track.Title = strings.TrimSpace(tags["title"])
+	return track
+}
+
+// trackCodecName returns the display name of a codec, DTS carries its variant in the profile
+func trackCodecName(codec, profile string) string {
+	if codec == "dts" && strings.HasPrefix(profile, "DTS") {
+		return profile
+	}
+	if name, ok := trackCodecNames[codec]; ok {
+		return name
+	}
+	return strings.ToUpper(codec)
+}
+
+// channelLayout renders a channel count as "2.0" or "5.1"
+func channelLayout(channels int) string {
+	switch channels {
+	case 1:
+		return "1.0"
+	case 2:
+		return "2.0"
+	case 6:
+		return "5.1"
+	case 8:
+		return "7.1"
+	default:
+		return strconv.Itoa(channels) + "ch"
+	}
+}
+
+// String renders the track as "English, E-AC3 5.1, 640 kbps (Commentary, default)"
+func (t MediaTrack) String() string {
+	language := t.Language
+	if language == "" {
+		language = "Unknown"
+	}
+	parts := []string{language}
+	codec := t.Codec
+	if t.Channels > 0 {
+		codec += " " + channelLayout(t.Channels)
+	}
+	parts = append(parts, codec)
+	if t.BitRate != "" {
+		parts = append(parts, FormatBitRate(t.BitRate))
+	}
+
+	var notes []string
+	if t.Title != "" {
+		notes = append(notes, t.Title)
+	}
+	if t.Default {
+		notes = append(notes, "default")
+	}
+	if t.Forced {
+		notes = append(notes, "forced")
+	}
+	text := strings.Join(parts, ", ")
+	if len(notes) > 0 {
+		text += " (" + strings.Join(notes, ", ") + ")"
+	}
+	return text
+}
+
+// formatMediaTracks renders the %AUDIO_TRACKS% and %SUBTITLE_TRACKS% lists, one numbered track
+// per line, empty without tracks
+func formatMediaTracks(tracks []MediaTrack) string {
+	lines := make([]string, len(tracks))
+	for i, track := range tracks {
+		lines[i] = fmt.Sprintf("%d. %s", i+1, track)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,301 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"spoilr/backend/img_uploaders"
+	"sync"
+	"time"
+)
+
+const (
+	maxUploadCacheEntries = 5000
+	// Uploads expiring sooner are not reused, a post made from them would break right away
+	uploadExpiryMargin = time.Hour
+)
+
+// UploadCacheEntry is the upload result of a video on one host
+type UploadCacheEntry struct {
+	Signature string      `json:"signature"` // Settings the images were generated and uploaded with
+	Uploads   HostUploads `json:"uploads"`
+	CachedAt  time.Time   `json:"cachedAt"`
+}
+
+// UploadCache persists the upload results per video fingerprint and host in the config
+// directory, so processing the same video again reuses them without generating its media.
+// It sits between the other two reuse layers: UploadHistory is keyed by image content, so it
+// only helps once the screenshots were generated again, and MovieHistory keeps whole movies
+// for the user to import on request, whatever settings they were made with. The cache is
+// consulted automatically before any media is generated, so it has to be per host and tied
+// to the settings that shaped the uploads.
+type UploadCache struct {
+	mu      sync.Mutex
+	path    string
+	Entries map[string]UploadCacheEntry `json:"entries"`
+}
+
+func NewUploadCache() *UploadCache {
+	cache := &UploadCache{
+		path:    filepath.Join(getConfigDir(), "upload_cache.json"),
+		Entries: make(map[string]UploadCacheEntry),
+	}
+	cache.load()
+	return cache
+}
+
+func uploadCacheKey(fingerprint, host string) string {
+	return fingerprint + "|" + host
+}
+
+func (c *UploadCache) load() {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		log.Printf("Failed to parse upload cache: %v", err)
+	}
+	if c.Entries == nil {
+		c.Entries = make(map[string]UploadCacheEntry)
+	}
+}
+
+// save writes the cache through a temp file so a crash never leaves it truncated
+func (c *UploadCache) save() error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create upload cache directory: %v", err)
+	}
+
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.path)
+}
+
+// Lookup returns the cached uploads of a video on a host, if they were made with the same
+// settings. Uploads the host deleted or is about to delete are dropped from the cache.
+func (c *UploadCache) Lookup(fingerprint, host, signature string) (HostUploads, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := uploadCacheKey(fingerprint, host)
+	entry, exists := c.Entries[key]
+	if !exists || entry.Signature != signature {
+		return HostUploads{}, false
+	}
+	if uploadExpired(entry.Uploads.expiresAt()) {
+		delete(c.Entries, key)
+		if err := c.save(); err != nil {
+			log.Printf("Failed to save upload cache: %v", err)
+		}
+		return HostUploads{}, false
+	}
+	return entry.Uploads, true
+}
+
+// Store caches the uploads of a video on a host and persists the cache
+func (c *UploadCache) Store(fingerprint, host string, entry UploadCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Entries[uploadCacheKey(fingerprint, host)] = entry
+	c.prune()
+
+	if err := c.save(); err != nil {
+		log.Printf("Failed to save upload cache: %v", err)
+	}
+}
+
+// Forget drops the cached uploads of a video on a host, e.g. when the host purged them
+func (c *UploadCache) Forget(fingerprint, host string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := uploadCacheKey(fingerprint, host)
+	if _, exists := c.Entries[key]; !exists {
+		return
+	}
+	delete(c.Entries, key)
+	if err := c.save(); err != nil {
+		log.Printf("Failed to save upload cache: %v", err)
+	}
+}
+
+// prune drops the oldest entries once the cache grows past its limit
+func (c *UploadCache) prune() {
+	if len(c.Entries) <= maxUploadCacheEntries {
+		return
+	}
+
+	keys := make([]string, 0, len(c.Entries))
+	for key := range c.Entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.Entries[keys[i]].CachedAt.Before(c.Entries[keys[j]].CachedAt)
+	})
+	for _, key := range keys[:len(keys)-maxUploadCacheEntries] {
+		delete(c.Entries, key)
+	}
+}
+
+// uploadExpired reports whether an upload expiring at expiresAt is gone or about to be,
+// a zero time never expires
+func uploadExpired(expiresAt time.Time) bool {
+	return !expiresAt.IsZero() && time.Until(expiresAt) < uploadExpiryMargin
+}
+
+// expiresAt returns when the first of the uploaded images is deleted by the host, zero when
+// the host keeps them all
+func (h HostUploads) expiresAt() time.Time {
+	var earliest time.Time
+	results := append([]img_uploaders.UploadResult(nil), h.ScreenshotResults...)
+	for _, result := range []*img_uploaders.UploadResult{h.ContactSheetResult, h.PosterResult} {
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+	for _, result := range results {
+		if !result.ExpiresAt.IsZero() && (earliest.IsZero() || result.ExpiresAt.Before(earliest)) {
+			earliest = result.ExpiresAt
+		}
+	}
+	return earliest
+}
+
+// uploadCacheSignature hashes the settings that shape the images uploaded to a host and how
+// long the host keeps them, cached uploads made with other settings would not match the current
+// screenshots or could expire sooner than configured
+func (s *SpoilerService) uploadCacheSignature(movieID string, uploader *activeUploader) string {
+	limits := s.movieScreenshotLimits(movieID)
+	data, _ := json.Marshal(struct {
+		ScreenshotCount   int
+		ScreenshotMode    string
+		StartOffset       string
+		EndOffset         string
+		JitterSeconds     int
+		ScreenshotFormat  string
+		ScreenshotQuality int
+		WebPQuality       int
+		MtnArgs           string
+		Watermark         Watermark
+		SizeKey           int
+		HostOptions       string
+	}{
+		limits.count,
+		s.settings.ScreenshotMode,
+		s.settings.ScreenshotStartOffset,
+		s.settings.ScreenshotEndOffset,
+		s.settings.ScreenshotJitterSeconds,
+		s.settings.ScreenshotFormat,
+		limits.quality,
+		limits.webPQuality,
+		s.settings.MtnArgs,
+		s.watermark(),
+		uploader.sizeKey,
+		uploader.options,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// uploadCacheEnabled reports whether uploads of the movie may come from or go to the cache
+func (s *SpoilerService) uploadCacheEnabled(movie Movie) bool {
+	return !s.settings.DisableUploadCache && movie.Fingerprint != "" && !movie.Imported && !s.refreshing()
+}
+
+// applyCachedUploads fills in the hosts that have no results yet from the cache, when the
+// cached uploads cover everything the template needs from the host
+func (s *SpoilerService) applyCachedUploads(movie Movie, uploaders []*activeUploader) {
+	if !s.uploadCacheEnabled(movie) {
+		return
+	}
+
+	for _, uploader := range uploaders {
+		cached, found := s.uploadCache.Lookup(movie.Fingerprint, uploader.Name(), s.uploadCacheSignature(movie.ID, uploader))
+		if !found || !s.coversUploader(movie.ID, cached, uploader) {
+			continue
+		}
+
+		applied := false
+		s.uploadsMu.Lock()
+		s.updateMovieByID(movie.ID, func(m *Movie) {
+			if current := m.Uploads[uploader.Name()]; current != nil && current.hasResults() {
+				return
+			}
+			uploads := cached.clone()
+			m.Uploads[uploader.Name()] = &uploads
+			applied = true
+		})
+		s.uploadsMu.Unlock()
+		if applied {
+			s.recordEvent(movie.ID, "upload", "Reused cached "+uploader.Name()+" uploads", nil)
+		}
+	}
+}
+
+// cacheMovieUploads stores the complete upload results of a processed movie per host
+func (s *SpoilerService) cacheMovieUploads(movieID string, uploaders []*activeUploader) {
+	s.uploadsMu.Lock()
+	movie, exists := s.getMovieByID(movieID)
+	results := make(map[string]HostUploads)
+	if exists {
+		for host, result := range movie.Uploads {
+			if result != nil {
+				results[host] = result.clone()
+			}
+		}
+	}
+	s.uploadsMu.Unlock()
+	if !exists || !s.uploadCacheEnabled(movie) {
+		return
+	}
+
+	for _, uploader := range uploaders {
+		uploads := results[uploader.Name()]
+		if !uploads.hasResults() || !s.coversUploader(movieID, uploads, uploader) {
+			continue
+		}
+		s.uploadCache.Store(movie.Fingerprint, uploader.Name(), UploadCacheEntry{
+			Signature: s.uploadCacheSignature(movieID, uploader),
+			Uploads:   uploads,
+			CachedAt:  time.Now(),
+		})
+	}
+}
+
+// clone copies the uploads, so later changes to a movie's results never alter the cache
+func (h HostUploads) clone() HostUploads {
+	h.ScreenshotURLs = slices.Clone(h.ScreenshotURLs)
+	h.ScreenshotBigURLs = slices.Clone(h.ScreenshotBigURLs)
+	h.ScreenshotDirectURLs = slices.Clone(h.ScreenshotDirectURLs)
+	h.ScreenshotResults = slices.Clone(h.ScreenshotResults)
+	if h.ContactSheetResult != nil {
+		result := *h.ContactSheetResult
+		h.ContactSheetResult = &result
+	}
+	if h.PosterResult != nil {
+		result := *h.PosterResult
+		h.PosterResult = &result
+	}
+	return h
+}
+
+// coversUploader reports whether the uploads include everything the host has to provide
+func (s *SpoilerService) coversUploader(movieID string, uploads HostUploads, uploader *activeUploader) bool {
+	if uploader.contactSheet && uploads.ContactSheetURL == "" {
+		return false
+	}
+	return !uploader.screenshots || s.screenshotsComplete(movieID, uploads.ScreenshotURLs)
+}
@@ -0,0 +1,648 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"spoilr/backend/img_uploaders"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostUploader is a configured image host
+type hostUploader struct {
+	img_uploaders.ImageUploader
+	sizeKey int    // Size setting that changes upload results (thumbnail or resize width), part of the idempotency key
+	options string // Other host settings that change upload results, like expiry, part of the idempotency key too
+	// How long the host keeps uploads, 0 when it keeps them
+	retention time.Duration
+}
+
+// expiresAt returns when an upload made now is deleted by the host, zero when it is kept
+func (u *hostUploader) expiresAt() time.Time {
+	if u.retention <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(u.retention)
+}
+
+// uploaderRegistry lists the available image hosts in placeholder order.
+// Adding a host only needs a new entry here.
+var uploaderRegistry = []func(s *SpoilerService) *hostUploader{
+	func(s *SpoilerService) *hostUploader {
+		size := s.hostMiniatureSize(s.settings.FastpicMiniatureSize)
+		service := img_uploaders.NewFastpicService(s.settings.FastpicSID, size, img_uploaders.FastpicOptions{
+			DeleteAfterDays: s.settings.FastpicDeleteAfterDays,
+			OrigResizeWidth: s.settings.FastpicOrigResize,
+			Optimization:    s.settings.FastpicOptimization,
+			BaseURL:         s.settings.FastpicBaseURL,
+			Mirrors:         s.settings.FastpicMirrors,
+		})
+		options := fmt.Sprintf("delete=%d resize=%d optimization=%v", s.settings.FastpicDeleteAfterDays, s.settings.FastpicOrigResize, s.settings.FastpicOptimization)
+		retention := time.Duration(s.settings.FastpicDeleteAfterDays) * 24 * time.Hour
+		return &hostUploader{ImageUploader: service, sizeKey: size, options: options, retention: retention}
+	},
+	func(s *SpoilerService) *hostUploader {
+		size := s.hostMiniatureSize(s.settings.ImgboxMiniatureSize)
+		service := img_uploaders.NewImgboxService(size, s.imgboxFamilySafe())
+		if service == nil {
+			return nil
+		}
+		return &hostUploader{ImageUploader: service, sizeKey: size, options: fmt.Sprintf("familySafe=%t", s.imgboxFamilySafe())}
+	},
+	func(s *SpoilerService) *hostUploader {
+		service := img_uploaders.NewHamsterService(s.settings.HamsterEmail, s.settings.HamsterPassword)
+		if service == nil {
+			return nil
+		}
+		service.SetOptions(img_uploaders.HamsterOptions{
+			ResizeWidth: s.settings.HamsterResizeWidth,
+			Expiration:  s.settings.HamsterExpiration,
+		})
+		return &hostUploader{
+			ImageUploader: service,
+			sizeKey:       s.settings.HamsterResizeWidth,
+			options:       "expiration=" + s.settings.HamsterExpiration,
+			retention:     img_uploaders.HamsterExpirationDuration(s.settings.HamsterExpiration),
+		}
+	},
+	func(s *SpoilerService) *hostUploader {
+		service := img_uploaders.NewCatboxService(img_uploaders.CatboxOptions{
+			UserHash:  s.settings.CatboxUserHash,
+			Temporary: s.settings.CatboxTemporary,
+			Expiry:    s.settings.LitterboxExpiry,
+		})
+		options := "permanent"
+		var retention time.Duration
+		if s.settings.CatboxTemporary {
+			options = "litterbox=" + s.settings.LitterboxExpiry
+			retention, _ = time.ParseDuration(s.settings.LitterboxExpiry)
+		}
+		return &hostUploader{ImageUploader: service, options: options, retention: retention}
+	},
+}
+
+// buildUploaders configures every registered host from the current settings
+func (s *SpoilerService) buildUploaders() {
+	uploaders := make([]*hostUploader, 0, len(uploaderRegistry))
+	for _, create := range uploaderRegistry {
+		if uploader := create(s); uploader != nil {
+			uploaders = append(uploaders, uploader)
+		}
+	}
+	s.uploaders = uploaders
+}
+
+// hostUploadLimit returns the number of parallel uploads allowed to a host, so a slow host
+// does not hold the upload slots of the others
+func (s *SpoilerService) hostUploadLimit(name string) int {
+	if limit := s.settings.HostUploadLimits[name]; limit > 0 {
+		return limit
+	}
+	return max(s.settings.MaxConcurrentUploads, 1)
+}
+
+// imgboxFamilySafe returns the imgbox content flag of the current preset, or the global setting
+func (s *SpoilerService) imgboxFamilySafe() bool {
+	if preset, ok := s.currentPreset(); ok && preset.ImgboxFamilySafe != nil {
+		return *preset.ImgboxFamilySafe
+	}
+	return s.settings.ImgboxFamilySafe
+}
+
+// hostAllowed reports whether the host may upload in this run
+func (s *SpoilerService) hostAllowed(name string) bool {
+	if len(s.hostFilter) == 0 {
+		return true
+	}
+	for _, allowed := range s.hostFilter {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HostRequirement tracks what a single image host is needed for
+type HostRequirement struct {
+	Name         string
+	ContactSheet bool
+	Screenshots  bool
+	Poster       bool
+}
+
+// UploaderRequirements tracks what uploaders are needed based on template
+type UploaderRequirements struct {
+	Hosts []HostRequirement
+}
+
+// Host returns the requirement for the named host, if the template uses it
+func (r UploaderRequirements) Host(name string) (HostRequirement, bool) {
+	for _, host := range r.Hosts {
+		if host.Name == name {
+			return host, true
+		}
+	}
+	return HostRequirement{}, false
+}
+
+// NeedsContactSheet reports whether any host needs the contact sheet
+func (r UploaderRequirements) NeedsContactSheet() bool {
+	for _, host := range r.Hosts {
+		if host.ContactSheet {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsScreenshots reports whether any host needs screenshots
+func (r UploaderRequirements) NeedsScreenshots() bool {
+	for _, host := range r.Hosts {
+		if host.Screenshots {
+			return true
+		}
+	}
+	return false
+}
+
+// getUploaderRequirements analyzes template to determine which uploaders are needed
+func (s *SpoilerService) getUploaderRequirements() UploaderRequirements {
+	req := UploaderRequirements{}
+
+	template := s.currentTemplate()
+	preset, _ := s.currentPreset()
+
+	// Check what types of content are needed first
+	needsContactSheet := strings.Contains(template, "CONTACT_SHEET")
+	needsScreenshots := strings.Contains(template, "SCREENSHOTS")
+	needsPoster := strings.Contains(template, "%POSTER_")
+	if preset.usesGoTemplate() {
+		needsContactSheet = strings.Contains(template, "ContactSheet")
+		needsScreenshots = strings.Contains(template, "Screenshot")
+		needsPoster = strings.Contains(template, "Poster")
+	}
+	needsPoster = needsPoster && s.metadataEnabled()
+
+	// Early return if no image content is needed
+	if !needsContactSheet && !needsScreenshots && !needsPoster {
+		return req
+	}
+
+	// Check for each host's placeholder suffix
+	for _, uploader := range s.uploaders {
+		if !s.hostAllowed(uploader.Name()) {
+			continue
+		}
+		suffix := uploader.PlaceholderSuffix()
+		used := strings.Contains(template, "_"+suffix+"_") || strings.Contains(template, "_"+suffix+"%")
+		if preset.usesGoTemplate() {
+			// Hosts are referenced as .Uploads.fastpic or host "fastpic" / host "FP"
+			used = strings.Contains(template, uploader.Name()) || strings.Contains(template, `"`+suffix+`"`)
+		}
+		if used {
+			req.Hosts = append(req.Hosts, HostRequirement{
+				Name:         uploader.Name(),
+				ContactSheet: needsContactSheet,
+				Screenshots:  needsScreenshots,
+				Poster:       needsPoster,
+			})
+		}
+	}
+
+	return req
+}
+
+// activeUploader is a host together with what it is needed for in the current run
+type activeUploader struct {
+	*hostUploader
+	contactSheet bool
+	screenshots  bool
+	poster       bool // Uploads the looked up poster, see uploadPosters
+
+	slots chan struct{} // Limits concurrent uploads to this host
+	init  *hostInit     // Shared by copies narrowed to the missing uploads, see missingUploads
+}
+
+// hostInit is the warm-up of a host in the current run
+type hostInit struct {
+	ready chan struct{} // Closed once Init returned
+	err   error         // Set before ready is closed
+}
+
+// waitReady blocks until the host finished initializing and returns its init error
+func (u *activeUploader) waitReady(ctx context.Context) error {
+	select {
+	case <-u.init.ready:
+		return u.init.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// initializeUploaders starts warming up the hosts required by the template, such as the
+// fastpic upload ID or the imgbox tokens. All hosts initialize concurrently in the background
+// while media is generated, uploads wait for their host to be ready. A host that fails to
+// initialize is reported and its uploads fail, the remaining hosts still upload.
+func (s *SpoilerService) initializeUploaders(requirements UploaderRequirements) []*activeUploader {
+	var active []*activeUploader
+	for _, uploader := range s.uploaders {
+		host, needed := requirements.Host(uploader.Name())
+		if !needed {
+			continue
+		}
+
+		activeHost := &activeUploader{
+			hostUploader: uploader,
+			contactSheet: host.ContactSheet,
+			screenshots:  host.Screenshots,
+			poster:       host.Poster,
+			slots:        make(chan struct{}, s.hostUploadLimit(uploader.Name())),
+			init:         &hostInit{ready: make(chan struct{})},
+		}
+		go func() {
+			warmUp := activeHost.init
+			defer close(warmUp.ready)
+
+			if err := uploader.Init(s.cancelCtx); err != nil {
+				warmUp.err = fmt.Errorf("failed to initialize %s: %v", uploader.Name(), err)
+				log.Print(warmUp.err)
+				if s.app != nil {
+					s.app.Event.Emit("error", map[string]string{
+						"message": warmUp.err.Error(),
+					})
+				}
+				return
+			}
+			log.Printf("%s service initialized", uploader.Name())
+		}()
+		active = append(active, activeHost)
+	}
+	return active
+}
+
+// HostUploads holds the upload results of a movie on a single image host
+type HostUploads struct {
+	ContactSheetURL    string   `json:"contactSheetUrl"`    // MTN-generated contact sheet (small)
+	ContactSheetBigURL string   `json:"contactSheetBigUrl"` // MTN-generated contact sheet (big)
+	ScreenshotURLs     []string `json:"screenshotUrls"`     // Individual screenshots (small)
+	ScreenshotBigURLs  []string `json:"screenshotBigUrls"`  // Individual screenshots (big)
+	AlbumLink          string   `json:"albumLink,omitempty"`
+
+	// Direct image links
+	ContactSheetDirectURL string   `json:"contactSheetDirectUrl,omitempty"`
+	ScreenshotDirectURLs  []string `json:"screenshotDirectUrls,omitempty"`
+
+	// Poster of the looked up metadata
+	PosterURL       string `json:"posterUrl,omitempty"`
+	PosterDirectURL string `json:"posterDirectUrl,omitempty"`
+
+	// Results as the host returned them, the BBCode above is rendered from these. Missing for
+	// imported movies, see uploadresults.go.
+	ContactSheetResult *img_uploaders.UploadResult  `json:"contactSheetResult,omitempty"`
+	ScreenshotResults  []img_uploaders.UploadResult `json:"screenshotResults,omitempty"`
+	PosterResult       *img_uploaders.UploadResult  `json:"posterResult,omitempty"`
+}
+
+// hasResults reports whether any image was uploaded to the host
+func (h HostUploads) hasResults() bool {
+	if h.ContactSheetURL != "" {
+		return true
+	}
+	for _, url := range h.ScreenshotURLs {
+		if url != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// link returns the album link, or the direct link of the first uploaded image
+func (h HostUploads) link() string {
+	if h.AlbumLink != "" {
+		return h.AlbumLink
+	}
+	if h.ContactSheetDirectURL != "" {
+		return h.ContactSheetDirectURL
+	}
+	for _, url := range h.ScreenshotDirectURLs {
+		if url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+// prepareHostUploads creates the result entries of the active hosts before uploads start,
+// so concurrent uploads only ever modify existing entries
+func (s *SpoilerService) prepareHostUploads(movieID string, uploaders []*activeUploader) {
+	s.uploadsMu.Lock()
+	defer s.uploadsMu.Unlock()
+
+	s.updateMovieByID(movieID, func(m *Movie) {
+		if m.Uploads == nil {
+			m.Uploads = make(map[string]*HostUploads)
+		}
+		for _, uploader := range uploaders {
+			if m.Uploads[uploader.Name()] == nil {
+				m.Uploads[uploader.Name()] = &HostUploads{
+					ScreenshotURLs:    make([]string, 0),
+					ScreenshotBigURLs: make([]string, 0),
+				}
+			}
+		}
+	})
+}
+
+// updateHostUploads applies updateFn to a movie's results on a host (thread-safe)
+func (s *SpoilerService) updateHostUploads(movieID, host string, updateFn func(*HostUploads)) {
+	s.uploadsMu.Lock()
+	defer s.uploadsMu.Unlock()
+
+	s.updateMovieByID(movieID, func(m *Movie) {
+		if m.Uploads == nil {
+			m.Uploads = make(map[string]*HostUploads)
+		}
+		uploads := m.Uploads[host]
+		if uploads == nil {
+			uploads = &HostUploads{}
+			m.Uploads[host] = uploads
+		}
+		updateFn(uploads)
+	})
+	s.scheduleSessionSave()
+}
+
+// hostLabel returns the host name for messages, e.g. "Fastpic"
+func hostLabel(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// Upload contact sheets to all required services
+func (s *SpoilerService) uploadContactSheets(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, contactSheetPath, baseFileName string, uploaders []*activeUploader) {
+	if contactSheetPath == "" {
+		return
+	}
+
+	for _, uploader := range uploaders {
+		if uploader.contactSheet {
+			wg.Add(1)
+			go s.uploadContactSheet(wg, mu, uploadStarted, movie, contactSheetPath, baseFileName, uploader)
+		}
+	}
+}
+
+// Upload screenshots to all required services
+func (s *SpoilerService) uploadScreenshots(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, screenshotPaths []string, baseFileName string, uploaders []*activeUploader) {
+	for i, screenshotPath := range screenshotPaths {
+		if screenshotPath == "" {
+			continue
+		}
+		s.uploadScreenshotAt(wg, mu, uploadStarted, movie, screenshotPath, baseFileName, i, uploaders)
+	}
+}
+
+// uploadScreenshotAt uploads one screenshot to all required services, keeping its position in the list
+func (s *SpoilerService) uploadScreenshotAt(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, screenshotPath, baseFileName string, index int, uploaders []*activeUploader) {
+	for _, uploader := range uploaders {
+		if uploader.screenshots && !s.hasScreenshotUpload(movie.ID, uploader.Name(), index) {
+			wg.Add(1)
+			go s.uploadScreenshot(wg, mu, uploadStarted, movie, screenshotPath, baseFileName, index, uploader)
+		}
+	}
+}
+
+// Upload contact sheet to a single host
+func (s *SpoilerService) uploadContactSheet(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, contactSheetPath, baseFileName string, uploader *activeUploader) {
+	defer wg.Done()
+
+	job := s.queue.add(movie, QueueJobUpload, uploader.Name(), 0)
+	defer s.queue.done(job)
+
+	if !s.acquireUploadSlot(uploader) {
+		return
+	}
+	defer func() { <-uploader.slots }()
+
+	s.queue.start(job)
+	s.markUploadStarted(mu, uploadStarted, movie.ID)
+
+	label := hostLabel(uploader.Name()) + " contact sheet upload"
+	fileName := s.uploadFileName(fmt.Sprintf("%s_contact_sheet%s", baseFileName, filepath.Ext(contactSheetPath)))
+	result, reused, err := s.uploadOnce(uploader, contactSheetPath, fileName, s.uploadProgress(job, movie.ID, uploader.Name(), 0))
+	s.queue.recordUpload(uploader.Name(), err)
+	s.recordEvent(movie.ID, "upload", uploadEventMessage(label, reused), err)
+	if err != nil {
+		s.addMovieError(movie.ID, fmt.Sprintf("%s failed: %v", label, err))
+		log.Printf("Failed to upload contact sheet to %s for %s: %v", uploader.Name(), movie.FileName, err)
+		return
+	}
+
+	s.updateHostUploads(movie.ID, uploader.Name(), func(h *HostUploads) {
+		h.setContactSheet(*result, s.bbCodeDialect())
+	})
+}
+
+// Upload single screenshot to a single host
+func (s *SpoilerService) uploadScreenshot(wg *sync.WaitGroup, mu *sync.Mutex, uploadStarted *bool, movie Movie, screenshotPath, baseFileName string, index int, uploader *activeUploader) {
+	defer wg.Done()
+
+	job := s.queue.add(movie, QueueJobUpload, uploader.Name(), index+1)
+	defer s.queue.done(job)
+
+	if !s.acquireUploadSlot(uploader) {
+		return
+	}
+	defer func() { <-uploader.slots }()
+
+	s.queue.start(job)
+	s.markUploadStarted(mu, uploadStarted, movie.ID)
+
+	label := fmt.Sprintf("%s screenshot %d upload", hostLabel(uploader.Name()), index+1)
+	fileName := s.uploadFileName(fmt.Sprintf("%s_screenshot_%d%s", baseFileName, index+1, filepath.Ext(screenshotPath)))
+	result, reused, err := s.uploadOnce(uploader, screenshotPath, fileName, s.uploadProgress(job, movie.ID, uploader.Name(), index+1))
+	s.queue.recordUpload(uploader.Name(), err)
+	s.recordEvent(movie.ID, "upload", uploadEventMessage(label, reused), err)
+	if err != nil {
+		s.addMovieError(movie.ID, fmt.Sprintf("%s failed: %v", label, err))
+		log.Printf("Failed to upload screenshot %d to %s for %s: %v", index+1, uploader.Name(), movie.FileName, err)
+		return
+	}
+
+	s.updateHostUploads(movie.ID, uploader.Name(), func(h *HostUploads) {
+		h.setScreenshot(index, *result, s.bbCodeDialect())
+	})
+}
+
+// acquireUploadSlot waits until uploads are not paused and takes a slot of the host. It
+// returns false when processing was cancelled.
+func (s *SpoilerService) acquireUploadSlot(uploader *activeUploader) bool {
+	for {
+		if err := s.queue.waitResumed(s.cancelCtx); err != nil {
+			return false
+		}
+		select {
+		case uploader.slots <- struct{}{}:
+		case <-s.cancelCtx.Done():
+			return false
+		}
+		// Paused while waiting for the slot, give it back until resumed
+		if !s.queue.isPaused() {
+			return true
+		}
+		<-uploader.slots
+	}
+}
+
+// uploadEventMessage marks timeline entries for uploads that were reused from the history
+func uploadEventMessage(message string, reused bool) string {
+	if reused {
+		return message + " (reused from upload history)"
+	}
+	return message
+}
+
+// replaceUploadPlaceholders replaces the contact sheet and screenshot placeholders of every host
+func (s *SpoilerService) replaceUploadPlaceholders(template string, movie Movie) string {
+	template = s.renderHostSections(template, movie)
+
+	for _, uploader := range s.uploaders {
+		suffix := uploader.PlaceholderSuffix()
+
+		uploads := s.hostUploads(movie, uploader.Name())
+
+		template = s.replaceIfNotEmpty(template, "%CONTACT_SHEET_"+suffix+"%", uploads.ContactSheetURL)
+		template = s.replaceIfNotEmpty(template, "%CONTACT_SHEET_"+suffix+"_BIG%", uploads.ContactSheetBigURL)
+		template = s.replaceIfNotEmpty(template, "%POSTER_"+suffix+"%", uploads.PosterURL)
+
+		// Regular screenshots (BBThumb)
+		screenshots := s.filterNonEmptyStrings(uploads.ScreenshotURLs)
+		template = s.replaceScreenshotGroup(template, "%SCREENSHOTS_"+suffix+"%", "%SCREENSHOTS_"+suffix+"_SPACED%", screenshots)
+
+		// Big screenshots (BBBig)
+		screenshotsBig := s.filterNonEmptyStrings(uploads.ScreenshotBigURLs)
+		template = s.replaceScreenshotGroup(template, "%SCREENSHOTS_"+suffix+"_BIG%", "%SCREENSHOTS_"+suffix+"_BIG_SPACED%", screenshotsBig)
+	}
+
+	template = s.replaceLocalPlaceholders(template, movie)
+	return strings.ReplaceAll(template, "%MIRRORS%", s.renderMirrors(movie))
+}
+
+const hostSectionOpen = "[["
+
+// renderHostSections keeps the content of "[[SUFFIX: ...]]" sections whose host uploaded
+// something for the movie and removes the others entirely. Unknown suffixes are left as is.
+func (s *SpoilerService) renderHostSections(template string, movie Movie) string {
+	return ExpandHostSections(template, func(suffix string) (bool, bool) {
+		for _, uploader := range s.uploaders {
+			if uploader.PlaceholderSuffix() != suffix {
+				continue
+			}
+			uploads := movie.Uploads[uploader.Name()]
+			return uploads != nil && uploads.hasResults(), true
+		}
+		return false, false
+	})
+}
+
+// ExpandHostSections resolves the "[[SUFFIX: ...]]" sections of a template. keep reports
+// whether the section of a host suffix is kept and whether the suffix is known at all: kept
+// sections are replaced by their content, dropped ones are removed together with the line break
+// that follows them, and unknown ones are left as is. Brackets inside a section are counted,
+// so content ending in a BBCode tag like "[/url]]]" keeps the tag's bracket.
+func ExpandHostSections(template string, keep func(suffix string) (bool, bool)) string {
+	var result strings.Builder
+	for {
+		start := strings.Index(template, hostSectionOpen)
+		if start < 0 {
+			result.WriteString(template)
+			return result.String()
+		}
+		suffix, content, end, ok := parseHostSection(template[start:])
+		if !ok {
+			// Not a section, keep the first bracket and look further
+			result.WriteString(template[:start+1])
+			template = template[start+1:]
+			continue
+		}
+		end += start
+		lineBreak := 0
+		if strings.HasPrefix(template[end:], "\r\n") {
+			lineBreak = 2
+		} else if strings.HasPrefix(template[end:], "\n") {
+			lineBreak = 1
+		}
+
+		result.WriteString(template[:start])
+		switch kept, known := keep(suffix); {
+		case !known:
+			result.WriteString(template[start : end+lineBreak])
+		case kept:
+			result.WriteString(content)
+			result.WriteString(template[end : end+lineBreak])
+		}
+		template = template[end+lineBreak:]
+	}
+}
+
+// parseHostSection reads the "[[SUFFIX: ...]]" section at the start of text and returns its
+// suffix, its content and the length of the section. The section ends at the first "]]" that
+// is not closing a bracket opened inside it.
+func parseHostSection(text string) (string, string, int, bool) {
+	colon := strings.IndexByte(text, ':')
+	if colon <= len(hostSectionOpen) {
+		return "", "", 0, false
+	}
+	suffix := text[len(hostSectionOpen):colon]
+	for _, r := range suffix {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return "", "", 0, false
+		}
+	}
+
+	contentStart := colon + 1
+	if contentStart < len(text) && (text[contentStart] == ' ' || text[contentStart] == '\t') {
+		contentStart++
+	}
+	depth := 0
+	for i := contentStart; i < len(text); i++ {
+		switch text[i] {
+		case '[':
+			depth++
+		case ']':
+			if depth == 0 {
+				if strings.HasPrefix(text[i:], "]]") {
+					return suffix, text[contentStart:i], i + 2, true
+				}
+				return "", "", 0, false
+			}
+			depth--
+		}
+	}
+	return "", "", 0, false
+}
+
+// renderMirrors renders a compact "Mirrors: FP | IB" line linking every host that has
+// uploads for the movie. Empty when no host succeeded.
+func (s *SpoilerService) renderMirrors(movie Movie) string {
+	var mirrors []string
+	for _, uploader := range s.uploaders {
+		uploads := movie.Uploads[uploader.Name()]
+		if uploads == nil {
+			continue
+		}
+		if link := uploads.link(); link != "" {
+			mirrors = append(mirrors, fmt.Sprintf("[url=%s]%s[/url]", link, uploader.PlaceholderSuffix()))
+		}
+	}
+
+	if len(mirrors) == 0 {
+		return ""
+	}
+	return "Mirrors: " + strings.Join(mirrors, " | ")
+}
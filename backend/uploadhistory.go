@@ -0,0 +1,218 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"spoilr/backend/img_uploaders"
+	"sync"
+	"time"
+)
+
+const maxUploadHistoryEntries = 5000
+
+// UploadRecord is a completed upload remembered by its idempotency key
+type UploadRecord struct {
+	Host       string    `json:"host"`
+	Direct     string    `json:"direct,omitempty"`
+	BBThumb    string    `json:"bbThumb"`
+	BBBig      string    `json:"bbBig"`
+	AlbumLink  string    `json:"albumLink,omitempty"`
+	UploadedAt time.Time `json:"uploadedAt"`
+	ExpiresAt  time.Time `json:"expiresAt,omitempty"` // When the host deletes the image, zero when it keeps it
+}
+
+// UploadHistory persists completed uploads in the config directory so a resumed batch
+// reuses existing links instead of posting the same image again
+type UploadHistory struct {
+	mu      sync.Mutex
+	path    string
+	Uploads map[string]UploadRecord `json:"uploads"`
+}
+
+func NewUploadHistory() *UploadHistory {
+	history := &UploadHistory{
+		path:    filepath.Join(getConfigDir(), "uploads.json"),
+		Uploads: make(map[string]UploadRecord),
+	}
+	history.load()
+	return history
+}
+
+func (h *UploadHistory) load() {
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		log.Printf("Failed to parse upload history: %v", err)
+	}
+	if h.Uploads == nil {
+		h.Uploads = make(map[string]UploadRecord)
+	}
+}
+
+// save writes the history through a temp file so a crash never leaves it truncated
+func (h *UploadHistory) save() error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create upload history directory: %v", err)
+	}
+
+	tmpPath := h.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, h.path)
+}
+
+// Lookup returns the recorded upload for an idempotency key. Uploads the host deleted or is
+// about to delete are dropped from the history.
+func (h *UploadHistory) Lookup(key string) (UploadRecord, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	record, exists := h.Uploads[key]
+	if exists && uploadExpired(record.ExpiresAt) {
+		delete(h.Uploads, key)
+		if err := h.save(); err != nil {
+			log.Printf("Failed to save upload history: %v", err)
+		}
+		return UploadRecord{}, false
+	}
+	return record, exists
+}
+
+// Record stores a completed upload and persists the history immediately
+func (h *UploadHistory) Record(key string, record UploadRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.Uploads[key] = record
+	h.prune()
+
+	if err := h.save(); err != nil {
+		log.Printf("Failed to save upload history: %v", err)
+	}
+}
+
+// prune drops the oldest records once the history grows past its limit
+func (h *UploadHistory) prune() {
+	if len(h.Uploads) <= maxUploadHistoryEntries {
+		return
+	}
+
+	keys := make([]string, 0, len(h.Uploads))
+	for key := range h.Uploads {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return h.Uploads[keys[i]].UploadedAt.Before(h.Uploads[keys[j]].UploadedAt)
+	})
+	for _, key := range keys[:len(keys)-maxUploadHistoryEntries] {
+		delete(h.Uploads, key)
+	}
+}
+
+// fileContentHash returns the hex SHA-256 of a file's content
+func fileContentHash(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// uploadIdempotencyKey identifies an upload by host, size setting, the host options that
+// change results, such as Litterbox's expiry, and content hash
+func uploadIdempotencyKey(host string, sizeKey int, options, filePath string) (string, error) {
+	contentHash, err := fileContentHash(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %v", filepath.Base(filePath), err)
+	}
+	return fmt.Sprintf("%s:%d:%s:%s", host, sizeKey, options, contentHash), nil
+}
+
+// uploadOnce reuses a recorded upload of the same content to the same host, or performs
+// the upload and records it, reporting the bytes sent to progress. The bool result reports
+// whether the record was reused. The result is in the uploader's BBCode, HostUploads
+// renders it in the current dialect.
+func (s *SpoilerService) uploadOnce(uploader *activeUploader, filePath, fileName string, progress img_uploaders.ProgressFunc) (*img_uploaders.UploadResult, bool, error) {
+	ctx := img_uploaders.WithProgress(s.cancelCtx, progress)
+
+	key, err := uploadIdempotencyKey(uploader.Name(), uploader.sizeKey, uploader.options, filePath)
+	if err != nil {
+		log.Printf("Upload idempotency check skipped: %v", err)
+		if err := uploader.waitReady(s.cancelCtx); err != nil {
+			return nil, false, err
+		}
+		uploadDone := s.artifacts.trackUpload(filePath)
+		result, err := uploader.Upload(ctx, filePath, fileName)
+		uploadDone()
+		s.recordUploadResult(uploader.Name(), err)
+		if err != nil {
+			return nil, false, err
+		}
+		result.ExpiresAt = uploader.expiresAt()
+		return result, false, nil
+	}
+
+	// A refresh replaces links that may have been purged, so recorded uploads are not reused
+	if record, exists := s.uploadHistory.Lookup(key); exists && !s.refreshing() {
+		result := img_uploaders.UploadResult{
+			Direct:    record.Direct,
+			BBThumb:   record.BBThumb,
+			BBBig:     record.BBBig,
+			AlbumLink: record.AlbumLink,
+			ExpiresAt: record.ExpiresAt,
+		}
+		return &result, true, nil
+	}
+
+	if err := uploader.waitReady(s.cancelCtx); err != nil {
+		return nil, false, err
+	}
+	uploadDone := s.artifacts.trackUpload(filePath)
+	result, err := uploader.Upload(ctx, filePath, fileName)
+	uploadDone()
+	s.recordUploadResult(uploader.Name(), err)
+	if err != nil {
+		return nil, false, err
+	}
+	result.ExpiresAt = uploader.expiresAt()
+
+	s.uploadHistory.Record(key, UploadRecord{
+		Host:       uploader.Name(),
+		Direct:     result.Direct,
+		BBThumb:    result.BBThumb,
+		BBBig:      result.BBBig,
+		AlbumLink:  result.AlbumLink,
+		UploadedAt: time.Now(),
+		ExpiresAt:  result.ExpiresAt,
+	})
+	return result, false, nil
+}
+
+// bbCodeDialect returns the BBCode dialect of the current preset, or the configured one.
+// Results are stored in the uploaders' uppercase BBCode, so reused uploads follow a changed
+// dialect too.
+func (s *SpoilerService) bbCodeDialect() img_uploaders.BBCodeDialect {
+	if preset, ok := s.currentPreset(); ok && preset.BBCodeDialect != "" {
+		return img_uploaders.BBCodeDialect(preset.BBCodeDialect)
+	}
+	return img_uploaders.BBCodeDialect(s.settings.BBCodeDialect)
+}
@@ -0,0 +1,95 @@
+package backend
+
+import (
+	"fmt"
+	"spoilr/backend/img_uploaders"
+)
+
+// Upload results are kept as the hosts returned them next to their rendered BBCode. The
+// placeholders render from the raw results with the dialect of the current preset, so
+// switching presets or dialects re-renders the spoilers without uploading again.
+
+// setContactSheet stores the contact sheet upload of the host
+func (h *HostUploads) setContactSheet(result img_uploaders.UploadResult, dialect img_uploaders.BBCodeDialect) {
+	h.ContactSheetResult = &result
+	dialected := result.WithDialect(dialect)
+	h.ContactSheetURL = dialected.BBThumb
+	h.ContactSheetBigURL = dialected.BBBig
+	h.ContactSheetDirectURL = result.Direct
+	if h.AlbumLink == "" {
+		h.AlbumLink = result.AlbumLink
+	}
+}
+
+// setScreenshot stores the upload of the screenshot at index
+func (h *HostUploads) setScreenshot(index int, result img_uploaders.UploadResult, dialect img_uploaders.BBCodeDialect) {
+	for _, urls := range []*[]string{&h.ScreenshotURLs, &h.ScreenshotBigURLs, &h.ScreenshotDirectURLs} {
+		for len(*urls) <= index {
+			*urls = append(*urls, "")
+		}
+	}
+	for len(h.ScreenshotResults) <= index {
+		h.ScreenshotResults = append(h.ScreenshotResults, img_uploaders.UploadResult{})
+	}
+
+	h.ScreenshotResults[index] = result
+	dialected := result.WithDialect(dialect)
+	h.ScreenshotURLs[index] = dialected.BBThumb
+	h.ScreenshotBigURLs[index] = dialected.BBBig
+	h.ScreenshotDirectURLs[index] = result.Direct
+	if h.AlbumLink == "" {
+		h.AlbumLink = result.AlbumLink
+	}
+}
+
+// setPoster stores the poster upload of the host
+func (h *HostUploads) setPoster(result img_uploaders.UploadResult, dialect img_uploaders.BBCodeDialect) {
+	h.PosterResult = &result
+	h.PosterURL = result.WithDialect(dialect).BBBig
+	h.PosterDirectURL = result.Direct
+}
+
+// rendered returns the uploads with their BBCode rendered from the raw results in the dialect.
+// Images without a raw result, like those of imported movies, keep their stored BBCode.
+func (h HostUploads) rendered(dialect img_uploaders.BBCodeDialect) HostUploads {
+	h = h.clone()
+	if h.ContactSheetResult != nil && h.ContactSheetURL != "" {
+		dialected := h.ContactSheetResult.WithDialect(dialect)
+		h.ContactSheetURL = dialected.BBThumb
+		h.ContactSheetBigURL = dialected.BBBig
+	}
+	for i, result := range h.ScreenshotResults {
+		if result == (img_uploaders.UploadResult{}) || i >= len(h.ScreenshotURLs) || h.ScreenshotURLs[i] == "" {
+			continue
+		}
+		dialected := result.WithDialect(dialect)
+		h.ScreenshotURLs[i] = dialected.BBThumb
+		if i < len(h.ScreenshotBigURLs) {
+			h.ScreenshotBigURLs[i] = dialected.BBBig
+		}
+	}
+	if h.PosterResult != nil && h.PosterURL != "" {
+		h.PosterURL = h.PosterResult.WithDialect(dialect).BBBig
+	}
+	return h
+}
+
+// hostUploads returns the uploads of a movie on a host rendered for the current preset, empty
+// when the host has none
+func (s *SpoilerService) hostUploads(movie Movie, host string) HostUploads {
+	if uploads := movie.Uploads[host]; uploads != nil {
+		return uploads.rendered(s.bbCodeDialect())
+	}
+	return HostUploads{}
+}
+
+// SetPresetBBCodeDialect overrides the BBCode dialect of the upload placeholders for a preset,
+// an empty dialect uses the global setting
+func (s *SpoilerService) SetPresetBBCodeDialect(presetID string, dialect string) error {
+	if dialect != "" && !img_uploaders.IsValidBBCodeDialect(dialect) {
+		return fmt.Errorf("unknown BBCode dialect %q", dialect)
+	}
+	return s.configManager.updatePreset(presetID, func(p *TemplatePreset) {
+		p.BBCodeDialect = dialect
+	})
+}
@@ -0,0 +1,144 @@
+package backend
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// retainedMedia are the images of a movie left from an earlier run
+type retainedMedia struct {
+	contactSheet string                 // Copy in the local output directory, empty if gone
+	screenshots  []string               // Copies in the local output directory by position
+	hosts        map[string]HostUploads // Uploads to download the images without a local copy from
+}
+
+// RetryUploads re-runs only the failed uploads of a movie without generating its media again.
+// The images are taken from the local output folder, or downloaded from a host they were
+// uploaded to. Images available from neither fail, RetryMovie generates them again.
+func (s *SpoilerService) RetryUploads(id string) error {
+	if s.processing {
+		return fmt.Errorf("processing already in progress")
+	}
+
+	movie, exists := s.getMovieByID(id)
+	if !exists {
+		return fmt.Errorf("movie with ID %s not found", id)
+	}
+	if movie.Imported {
+		return fmt.Errorf("%s was imported from BBCode, refresh its uploads instead", movie.FileName)
+	}
+	if !movie.ProcessingState.IsFinished() {
+		return fmt.Errorf("%s has not been processed yet", movie.FileName)
+	}
+
+	media := movieRetainedMedia(movie)
+	if media.contactSheet == "" && len(media.hosts) == 0 && !slices.ContainsFunc(media.screenshots, func(path string) bool { return path != "" }) {
+		return fmt.Errorf("no images of %s are left, retry the movie to generate them", movie.FileName)
+	}
+
+	s.transitionMovieState(id, StatePending)
+	s.updateMovieByID(id, func(m *Movie) {
+		m.ProcessingError = ""
+	})
+	movie, _ = s.getMovieByID(id)
+
+	s.recordEvent(id, "processing", "Upload retry requested", nil)
+	s.retainedMedia = map[string]retainedMedia{id: media}
+	s.applyPresetGeneration()
+	s.startProcessing([]Movie{movie}, func() {
+		s.retainedMedia = nil
+	})
+	return nil
+}
+
+// movieRetainedMedia collects the local copies and uploads a movie's images can be taken from
+func movieRetainedMedia(movie Movie) retainedMedia {
+	exists := func(path string) bool {
+		if path == "" {
+			return false
+		}
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	var media retainedMedia
+	if exists(movie.LocalContactSheet) {
+		media.contactSheet = movie.LocalContactSheet
+	}
+	media.screenshots = make([]string, len(movie.LocalScreenshots))
+	for i, path := range movie.LocalScreenshots {
+		if exists(path) {
+			media.screenshots[i] = path
+		}
+	}
+
+	media.hosts = make(map[string]HostUploads)
+	for host, uploads := range movie.Uploads {
+		if uploads != nil && uploads.hasResults() {
+			media.hosts[host] = uploads.clone()
+		}
+	}
+	return media
+}
+
+// collectRetainedMedia gathers the images the pending uploads need instead of generating them.
+// Local copies are used as they are, the others are downloaded from the first host serving them.
+func (s *SpoilerService) collectRetainedMedia(movie Movie, tempDir string, media retainedMedia, uploaders []*activeUploader) (string, []string, error) {
+	hosts := slices.Sorted(maps.Keys(media.hosts))
+
+	contactSheetPath := ""
+	if slices.ContainsFunc(uploaders, func(u *activeUploader) bool { return u.contactSheet }) {
+		contactSheetPath = media.contactSheet
+		if contactSheetPath == "" {
+			var urls []string
+			for _, host := range hosts {
+				if direct := media.hosts[host].ContactSheetDirectURL; direct != "" {
+					urls = append(urls, direct)
+				}
+			}
+			contactSheetPath = s.downloadRetainedImage(movie.ID, "Contact sheet", urls, filepath.Join(tempDir, "contact_sheet"))
+		}
+	}
+
+	screenshotPaths := make([]string, s.movieScreenshotLimits(movie.ID).count)
+	for i := range screenshotPaths {
+		if s.screenshotUploaded(movie.ID, i, uploaders) {
+			continue
+		}
+		if i < len(media.screenshots) && media.screenshots[i] != "" {
+			screenshotPaths[i] = media.screenshots[i]
+			continue
+		}
+		var urls []string
+		for _, host := range hosts {
+			if direct := media.hosts[host].ScreenshotDirectURLs; i < len(direct) && direct[i] != "" {
+				urls = append(urls, direct[i])
+			}
+		}
+		screenshotPaths[i] = s.downloadRetainedImage(movie.ID, fmt.Sprintf("Screenshot %d", i+1), urls, filepath.Join(tempDir, fmt.Sprintf("screenshot_%03d", i+1)))
+	}
+
+	if !s.hasMediaToUpload(contactSheetPath, screenshotPaths) {
+		return "", nil, fmt.Errorf("none of the missing images is left, retry the movie to generate them")
+	}
+	return contactSheetPath, screenshotPaths, nil
+}
+
+// downloadRetainedImage downloads an image without a local copy, reporting it as a movie error
+// when no host serves it
+func (s *SpoilerService) downloadRetainedImage(movieID, label string, urls []string, basePath string) string {
+	if len(urls) == 0 {
+		s.addMovieError(movieID, label+" is not available, retry the movie to generate it")
+		return ""
+	}
+	path, err := s.downloadFirstAvailable(movieID, urls, basePath)
+	s.recordEvent(movieID, "upload", label+" downloaded for the upload retry", err)
+	if err != nil {
+		s.addMovieError(movieID, fmt.Sprintf("%s download failed: %v", label, err))
+		return ""
+	}
+	return path
+}
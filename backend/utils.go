@@ -7,10 +7,11 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 func GetVideoMediaInfo(filePath string) (MediaInfo, bool, error) {
-	cmd := exec.Command("ffprobe",
+	cmd := exec.Command(toolPath("ffprobe"),
 		"-v", "quiet",
 		"-print_format", "json",
 		"-show_format",
@@ -42,7 +43,11 @@ func GetVideoMediaInfo(filePath string) (MediaInfo, bool, error) {
 			SampleRate    string            `json:"sample_rate"`
 			Channels      int               `json:"channels"`
 			ChannelLayout string            `json:"channel_layout"`
+			Profile       string            `json:"profile"`
 			Tags          map[string]string `json:"tags"`
+			Disposition   map[string]int    `json:"disposition"`
+			ColorTransfer string            `json:"color_transfer"`
+			SideDataList  []map[string]any  `json:"side_data_list"`
 		} `json:"streams"`
 	}
 
@@ -110,6 +115,15 @@ func GetVideoMediaInfo(filePath string) (MediaInfo, bool, error) {
 				mediaInfo.Video["avg_frame_rate"] = stream.AvgFrameRate
 			}
 
+			// HDR signalling, see detectHDRFormat
+			if stream.ColorTransfer != "" {
+				mediaInfo.Video["color_transfer"] = stream.ColorTransfer
+			}
+			if profile, compatibility := dolbyVisionConfig(stream.SideDataList); profile != "" {
+				mediaInfo.Video["dv_profile"] = profile
+				mediaInfo.Video["dv_compatibility"] = compatibility
+			}
+
 		case "audio":
 			mediaInfo.Audio["codec_name"] = stream.CodecName
 			if stream.Duration != "" {
@@ -134,6 +148,12 @@ func GetVideoMediaInfo(filePath string) (MediaInfo, bool, error) {
 			if stream.ChannelLayout != "" {
 				mediaInfo.Audio["channel_layout"] = stream.ChannelLayout
 			}
+			mediaInfo.AudioTracks = append(mediaInfo.AudioTracks,
+				newMediaTrack(stream.CodecName, stream.Profile, stream.Channels, stream.BitRate, stream.Tags, stream.Disposition))
+
+		case "subtitle":
+			mediaInfo.SubtitleTracks = append(mediaInfo.SubtitleTracks,
+				newMediaTrack(stream.CodecName, stream.Profile, 0, "", stream.Tags, stream.Disposition))
 		}
 	}
 
@@ -160,13 +180,42 @@ func parseFrameRate(frameRate string) float64 {
 	return numerator / denominator
 }
 
-func ExtractMediaInfo(movie *Movie, mediaInfo MediaInfo) {
-	if duration, ok := mediaInfo.General["duration"]; ok {
-		if dur, err := strconv.ParseFloat(duration, 64); err == nil {
-			movie.DurationFormatted = FormatDuration(time.Duration(dur * float64(time.Second)))
-			movie.Duration = dur
+// parseDurationSeconds returns the first positive duration found in the general, video or audio info
+func parseDurationSeconds(mediaInfo MediaInfo) float64 {
+	for _, info := range []map[string]string{mediaInfo.General, mediaInfo.Video, mediaInfo.Audio} {
+		if dur, err := strconv.ParseFloat(strings.TrimSpace(info["duration"]), 64); err == nil && dur > 0 {
+			return dur
 		}
 	}
+	return 0
+}
+
+// ProbeDuration asks ffprobe for the container duration only, used for the segments of split movies
+func ProbeDuration(filePath string) (float64, error) {
+	cmd := exec.Command(toolPath("ffprobe"),
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		filePath,
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %v", err)
+	}
+
+	dur, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse duration %q: %v", strings.TrimSpace(string(output)), err)
+	}
+	return dur, nil
+}
+
+func ExtractMediaInfo(movie *Movie, mediaInfo MediaInfo) {
+	if dur := parseDurationSeconds(mediaInfo); dur > 0 {
+		movie.DurationFormatted = FormatDuration(time.Duration(dur * float64(time.Second)))
+		movie.DurationSeconds = dur
+	}
 
 	if width, ok := mediaInfo.Video["width"]; ok {
 		movie.Width = width
@@ -179,19 +228,23 @@ func ExtractMediaInfo(movie *Movie, mediaInfo MediaInfo) {
 	// Extract bitrates
 	if bitRate, ok := mediaInfo.Video["bit_rate"]; ok && bitRate != "" {
 		movie.VideoBitRate = FormatBitRate(bitRate)
+		movie.VideoBitRateKbps, _ = ParseBitRateKbps(bitRate)
 	} else if overallBitRateStr, ok := mediaInfo.General["bit_rate"]; ok && overallBitRateStr != "" {
 		if overall, err := strconv.ParseFloat(overallBitRateStr, 64); err == nil {
 			estimatedVideoBitRate := overall * 0.8
 			movie.VideoBitRate = FormatBitRate(fmt.Sprintf("%.0f", estimatedVideoBitRate))
+			movie.VideoBitRateKbps = estimatedVideoBitRate / 1000
 		}
 	}
 
 	if bitRate, ok := mediaInfo.Audio["bit_rate"]; ok && bitRate != "" {
 		movie.AudioBitRate = FormatBitRate(bitRate)
+		movie.AudioBitRateKbps, _ = ParseBitRateKbps(bitRate)
 	} else if overallBitRateStr, ok := mediaInfo.General["bit_rate"]; ok && overallBitRateStr != "" {
 		if overall, err := strconv.ParseFloat(overallBitRateStr, 64); err == nil {
 			estimatedAudioBitRate := overall * 0.1
 			movie.AudioBitRate = FormatBitRate(fmt.Sprintf("%.0f", estimatedAudioBitRate))
+			movie.AudioBitRateKbps = estimatedAudioBitRate / 1000
 		}
 	}
 
@@ -202,14 +255,18 @@ func ExtractMediaInfo(movie *Movie, mediaInfo MediaInfo) {
 	if codec, ok := mediaInfo.Audio["codec_name"]; ok {
 		movie.AudioCodec = codec
 	}
+	movie.AudioTracks = mediaInfo.AudioTracks
+	movie.SubtitleTracks = mediaInfo.SubtitleTracks
 
 	if overallBitRate, ok := mediaInfo.General["bit_rate"]; ok {
 		movie.BitRate = FormatBitRate(overallBitRate)
+		movie.BitRateKbps, _ = ParseBitRateKbps(overallBitRate)
 	}
 
 	// Store formatted video info
 	if rFrameRate, ok := mediaInfo.Video["r_frame_rate"]; ok {
 		movie.Params["%VIDEO_FPS_FRACTIONAL%"] = rFrameRate
+		movie.FPS, _ = ParseFPS(rFrameRate)
 	}
 	if fpsDecimal, ok := mediaInfo.Video["fps_decimal"]; ok {
 		movie.Params["%VIDEO_FPS%"] = fpsDecimal
@@ -235,6 +292,8 @@ func ExtractMediaInfo(movie *Movie, mediaInfo MediaInfo) {
 	for key, value := range mediaInfo.Audio {
 		movie.Params[fmt.Sprintf("%%Audio@%s%%", key)] = value
 	}
+
+	normalizeMediaNumbers(movie)
 }
 
 func formatSampleRate(sampleRateStr string) string {
@@ -317,3 +376,68 @@ func FormatFileSize(bytes int64) string {
 	}
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
+
+// TruncateGraphemes shortens text to at most maxLen user-perceived characters, appending an
+// ellipsis when truncated. Combining marks, variation selectors, emoji modifiers, ZWJ sequences
+// and flag pairs are kept together so multi-codepoint characters are never split.
+func TruncateGraphemes(text string, maxLen int) string {
+	if maxLen <= 0 {
+		return text
+	}
+
+	clusters := splitGraphemes(text)
+	if len(clusters) <= maxLen {
+		return text
+	}
+	if maxLen == 1 {
+		return "…"
+	}
+
+	return strings.TrimRight(strings.Join(clusters[:maxLen-1], ""), " ") + "…"
+}
+
+// splitGraphemes splits text into approximate grapheme clusters
+func splitGraphemes(text string) []string {
+	var clusters []string
+	var current []rune
+	joinNext := false
+	regionalCount := 0
+
+	for _, r := range text {
+		extend := len(current) > 0 && (joinNext || isGraphemeExtender(r))
+		if isRegionalIndicator(r) {
+			if len(current) > 0 && regionalCount%2 == 1 {
+				extend = true
+			}
+			regionalCount++
+		} else {
+			regionalCount = 0
+		}
+
+		if !extend && len(current) > 0 {
+			clusters = append(clusters, string(current))
+			current = current[:0]
+		}
+		current = append(current, r)
+		joinNext = r == '\u200d'
+	}
+
+	if len(current) > 0 {
+		clusters = append(clusters, string(current))
+	}
+	return clusters
+}
+
+func isGraphemeExtender(r rune) bool {
+	return unicode.Is(unicode.Mn, r) ||
+		unicode.Is(unicode.Me, r) ||
+		unicode.Is(unicode.Mc, r) ||
+		r == '\u200d' ||
+		(r >= 0xFE00 && r <= 0xFE0F) || // variation selectors
+		(r >= 0x1F3FB && r <= 0x1F3FF) || // emoji skin tone modifiers
+		(r >= 0xE0020 && r <= 0xE007F) // emoji tag sequences
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
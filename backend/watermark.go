@@ -0,0 +1,157 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Watermark positions
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+	WatermarkCenter      = "center"
+)
+
+// watermarkMargin is the distance of the watermark from the image edges in pixels
+const watermarkMargin = 10
+
+// Watermark is a text or PNG overlay stamped on generated images before upload
+type Watermark struct {
+	Text     string `json:"text,omitempty" koanf:"text"`
+	Image    string `json:"image,omitempty" koanf:"image"` // PNG file, drawn instead of the text when set
+	Position string `json:"position" koanf:"position"`
+	Opacity  int    `json:"opacity" koanf:"opacity"` // Percent
+}
+
+// enabled reports whether there is anything to stamp
+func (w Watermark) enabled() bool {
+	return w.Text != "" || w.Image != ""
+}
+
+// validate checks the position and opacity
+func (w Watermark) validate() error {
+	if !isValidWatermarkPosition(w.Position) {
+		return fmt.Errorf("unknown watermark position %q", w.Position)
+	}
+	if w.Opacity < 0 || w.Opacity > 100 {
+		return fmt.Errorf("watermark opacity must be between 0 and 100")
+	}
+	return nil
+}
+
+func isValidWatermarkPosition(position string) bool {
+	switch position {
+	case WatermarkTopLeft, WatermarkTopRight, WatermarkBottomLeft, WatermarkBottomRight, WatermarkCenter:
+		return true
+	}
+	return false
+}
+
+// watermark returns the watermark of the current preset, or the global one
+func (s *SpoilerService) watermark() Watermark {
+	if preset, ok := s.currentPreset(); ok && preset.Watermark != nil {
+		return *preset.Watermark
+	}
+	return Watermark{
+		Text:     s.settings.WatermarkText,
+		Image:    s.settings.WatermarkImage,
+		Position: s.settings.WatermarkPosition,
+		Opacity:  s.settings.WatermarkOpacity,
+	}
+}
+
+// watermarkOffsets returns the x and y expressions of a position. The prefixes name the
+// frame and watermark dimensions, "W"/"w" for overlay and "w"/"t" for drawtext.
+func watermarkOffsets(position, frameW, frameH, markW, markH string) (string, string) {
+	left := fmt.Sprintf("%d", watermarkMargin)
+	right := fmt.Sprintf("%s-%s-%d", frameW, markW, watermarkMargin)
+	top := fmt.Sprintf("%d", watermarkMargin)
+	bottom := fmt.Sprintf("%s-%s-%d", frameH, markH, watermarkMargin)
+
+	switch position {
+	case WatermarkTopLeft:
+		return left, top
+	case WatermarkTopRight:
+		return right, top
+	case WatermarkBottomLeft:
+		return left, bottom
+	case WatermarkCenter:
+		return fmt.Sprintf("(%s-%s)/2", frameW, markW), fmt.Sprintf("(%s-%s)/2", frameH, markH)
+	default:
+		return right, bottom
+	}
+}
+
+// escapeDrawtext escapes a drawtext option value for both the option and the filtergraph level
+func escapeDrawtext(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(value)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `,`, `\,`, `;`, `\;`, `[`, `\[`, `]`, `\]`).Replace(value)
+}
+
+// applyWatermark stamps the watermark on an image in place
+func (s *SpoilerService) applyWatermark(path string, watermark Watermark, limits screenshotLimits) error {
+	alpha := fmt.Sprintf("%.2f", float64(watermark.Opacity)/100)
+	ext := filepath.Ext(path)
+	outputPath := strings.TrimSuffix(path, ext) + "_wm" + ext
+
+	args := []string{"-hide_banner", "-i", path}
+	if watermark.Image != "" {
+		if _, err := os.Stat(watermark.Image); err != nil {
+			return fmt.Errorf("watermark image not found: %v", err)
+		}
+		x, y := watermarkOffsets(watermark.Position, "W", "H", "w", "h")
+		args = append(args,
+			"-i", watermark.Image,
+			"-filter_complex", fmt.Sprintf("[1]format=rgba,colorchannelmixer=aa=%s[wm];[0][wm]overlay=%s:%s", alpha, x, y),
+		)
+	} else {
+		x, y := watermarkOffsets(watermark.Position, "w", "h", "tw", "th")
+		args = append(args, "-vf", fmt.Sprintf(
+			"drawtext=text=%s:expansion=none:fontsize=h/25:fontcolor=white@%s:borderw=2:bordercolor=black@%s:x=%s:y=%s",
+			escapeDrawtext(watermark.Text), alpha, alpha, x, y,
+		))
+	}
+	args = append(args, s.imageEncodeArgs(path, limits)...)
+	args = append(args, "-y", outputPath)
+
+	output, err := exec.CommandContext(s.cancelCtx, toolPath("ffmpeg"), args...).CombinedOutput()
+	if err != nil {
+		os.Remove(outputPath)
+		if s.cancelCtx.Err() != nil {
+			return fmt.Errorf("watermark cancelled: %v", s.cancelCtx.Err())
+		}
+		return fmt.Errorf("ffmpeg command failed: %v\nOutput: %s", err, strings.TrimSpace(string(output)))
+	}
+	return os.Rename(outputPath, path)
+}
+
+// watermarkImage stamps the current watermark on a generated image. A failure is recorded
+// as a warning and the image is uploaded without the watermark.
+func (s *SpoilerService) watermarkImage(movie Movie, path, label string) {
+	watermark := s.watermark()
+	if !watermark.enabled() {
+		return
+	}
+	err := s.applyWatermark(path, watermark, s.movieScreenshotLimits(movie.ID))
+	s.recordEvent(movie.ID, "watermark", label+" watermarked", err)
+	if err != nil && s.cancelCtx.Err() == nil {
+		s.addMovieWarning(movie.ID, fmt.Sprintf("%s watermark failed: %v", label, err))
+	}
+}
+
+// SetPresetWatermark overrides the watermark for a preset, nil restores the global setting
+func (s *SpoilerService) SetPresetWatermark(presetID string, watermark *Watermark) error {
+	if watermark != nil {
+		if err := watermark.validate(); err != nil {
+			return err
+		}
+	}
+	return s.configManager.updatePreset(presetID, func(p *TemplatePreset) {
+		p.Watermark = watermark
+	})
+}
@@ -8,18 +8,26 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/sqweek/dialog"
 	"golang.org/x/sys/windows/registry"
 )
 
 const (
 	kMinimumCompatibleVersion = "86.0.616.0"
 	kInstallKeyPath           = "Software\\Microsoft\\EdgeUpdate\\ClientState\\"
+	kFixedRuntimeBinary       = "msedgewebview2.exe"
 )
 
+// kFixedRuntimeDirs are the folder names searched next to the executable and in the tools
+// directory for a fixed-version runtime, either the extracted runtime itself or its parent
+var kFixedRuntimeDirs = []string{"WebView2Runtime", "webview2"}
+
+var fixedRuntimeVersionPattern = regexp.MustCompile(`\d+\.\d+\.\d+\.\d+`)
+
 var (
 	// WebView2 channel UUIDs (stable, beta, dev, canary)
 	kChannelUuids = []string{
@@ -35,6 +43,7 @@ type WebView2Version struct {
 	Major, Minor, Build, Patch int
 	Channel                    string
 	Path                       string
+	Fixed                      bool // Fixed-version runtime shipped with the app, Path is passed to the web view
 }
 
 // String returns the version as a string
@@ -188,6 +197,44 @@ func (h *WebView2Handler) checkRegistryPath(rootKey registry.Key, keyPath string
 	return &version, nil
 }
 
+// findFixedRuntime looks for a fixed-version runtime next to the executable or in the tools
+// directory. Its version is read from the folder name Microsoft ships it in, when present.
+func (h *WebView2Handler) findFixedRuntime() (*WebView2Version, error) {
+	var roots []string
+	if exe, err := os.Executable(); err == nil {
+		roots = append(roots, filepath.Dir(exe))
+	}
+	roots = append(roots, toolsDir())
+
+	for _, root := range roots {
+		for _, name := range kFixedRuntimeDirs {
+			dir := filepath.Join(root, name)
+			candidates := []string{dir}
+			if entries, err := os.ReadDir(dir); err == nil {
+				for _, entry := range entries {
+					if entry.IsDir() {
+						candidates = append(candidates, filepath.Join(dir, entry.Name()))
+					}
+				}
+			}
+
+			for _, candidate := range candidates {
+				if _, err := os.Stat(filepath.Join(candidate, kFixedRuntimeBinary)); err != nil {
+					continue
+				}
+				version := WebView2Version{Channel: "fixed", Path: candidate, Fixed: true}
+				if match := fixedRuntimeVersionPattern.FindString(filepath.Base(candidate)); match != "" {
+					if parsed, err := parseVersion(match); err == nil {
+						version.Major, version.Minor, version.Build, version.Patch = parsed.Major, parsed.Minor, parsed.Build, parsed.Patch
+					}
+				}
+				return &version, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no fixed-version WebView2 runtime found")
+}
+
 // CheckWebView2 checks if WebView2 is available on the system
 func (h *WebView2Handler) CheckWebView2() (*WebView2Version, error) {
 	return h.findWebView2Installation()
@@ -195,6 +242,30 @@ func (h *WebView2Handler) CheckWebView2() (*WebView2Version, error) {
 
 // RunInstaller extracts and runs the embedded WebView2 installer
 func (h *WebView2Handler) RunInstaller() error {
+	return h.runInstaller()
+}
+
+// InstallSilently runs the installer without any UI for scripted deployments and verifies
+// the installed runtime. Progress is only logged.
+func (h *WebView2Handler) InstallSilently() (*WebView2Version, error) {
+	log.Println("Installing WebView2 Runtime silently...")
+	startedAt := time.Now()
+	if err := h.runInstaller("/silent", "/install"); err != nil {
+		log.Printf("Silent WebView2 install failed after %s: %v", time.Since(startedAt).Round(time.Second), err)
+		return nil, err
+	}
+	log.Printf("WebView2 installer finished in %s, verifying", time.Since(startedAt).Round(time.Second))
+
+	version, err := h.CheckWebView2()
+	if err != nil {
+		return nil, fmt.Errorf("installer finished, but WebView2 Runtime could not be detected: %w", err)
+	}
+	log.Printf("WebView2 Runtime %s installed", version.String())
+	return version, nil
+}
+
+// runInstaller extracts the embedded installer and runs it with the given arguments
+func (h *WebView2Handler) runInstaller(args ...string) error {
 	if len(h.installerData) == 0 {
 		return fmt.Errorf("no installer data provided")
 	}
@@ -215,7 +286,7 @@ func (h *WebView2Handler) RunInstaller() error {
 	log.Printf("Running WebView2 installer: %s", installerPath)
 
 	// Run the installer
-	cmd := exec.Command(installerPath)
+	cmd := exec.Command(installerPath, args...)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("installer failed: %w", err)
 	}
@@ -231,10 +302,14 @@ WebView2 Runtime is not installed on your system. Would you like to install it n
 
 Click "Yes" to run the installer, or "No" to close the application.`
 
+	// Automation runs install without asking and without the installer UI
+	if !Dialogs.Interactive() {
+		_, err := h.InstallSilently()
+		return err
+	}
+
 	// Show dialog with Install/Exit options
-	result := dialog.Message("%s", message).
-		Title("WebView2 Runtime Required").
-		YesNo()
+	result := Dialogs.YesNo("WebView2 Runtime Required", message, true)
 
 	if !result {
 		// User chose "No" (Exit)
@@ -242,16 +317,10 @@ Click "Yes" to run the installer, or "No" to close the application.`
 		os.Exit(1)
 	}
 
-	// User chose "Yes" (Install)
+	// User chose "Yes" (Install). No progress dialog, it would stay open over the installer
+	// and block the dialogs queued after it.
 	log.Println("User chose to install WebView2. Running installer...")
 
-	// Show progress dialog
-	go func() {
-		dialog.Message("Installing WebView2 Runtime...\n\nPlease wait while the installer completes.").
-			Title("Installing WebView2").
-			Info()
-	}()
-
 	// Run the installer
 	if err := h.RunInstaller(); err != nil {
 		log.Printf("Failed to run WebView2 installer: %v", err)
@@ -265,9 +334,7 @@ Click "Yes" to run the installer, or "No" to close the application.`
 	}
 
 	log.Println("WebView2 installation completed successfully")
-	dialog.Message("WebView2 Runtime has been installed successfully!\n\nThe application will now continue loading.").
-		Title("Installation Complete").
-		Info()
+	Dialogs.Info("Installation Complete", "WebView2 Runtime has been installed successfully!\n\nThe application will now continue loading.")
 
 	return nil
 }
@@ -280,6 +347,13 @@ func (h *WebView2Handler) EnsureWebView2Available() (*WebView2Version, error) {
 	if err != nil {
 		log.Printf("WebView2 not found: %v", err)
 
+		// Offline machines and accounts without admin rights cannot install the runtime, a
+		// fixed-version runtime shipped with the app is used before asking to install
+		if fixed, fixedErr := h.findFixedRuntime(); fixedErr == nil {
+			log.Printf("Using the fixed-version WebView2 runtime at %s", fixed.Path)
+			return fixed, nil
+		}
+
 		if installErr := h.HandleMissingWebView2(); installErr != nil {
 			return nil, installErr
 		}
@@ -295,3 +369,15 @@ func (h *WebView2Handler) EnsureWebView2Available() (*WebView2Version, error) {
 	log.Printf("WebView2 is available: %s (%s channel) at %s", version.String(), version.Channel, version.Path)
 	return version, nil
 }
+
+// webViewVersion reports the installed WebView2 runtime version
+func webViewVersion() string {
+	handler := NewWebView2Handler(nil)
+	version, err := handler.CheckWebView2()
+	if err != nil {
+		if version, err = handler.findFixedRuntime(); err != nil {
+			return "not found"
+		}
+	}
+	return fmt.Sprintf("%s (%s)", version.String(), version.Channel)
+}
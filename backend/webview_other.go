@@ -0,0 +1,8 @@
+//go:build !windows
+
+package backend
+
+// webViewVersion reports the web view in use; macOS and Linux use the system WebKit
+func webViewVersion() string {
+	return "system WebKit"
+}
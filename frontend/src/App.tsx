@@ -138,7 +138,7 @@ function AppContent() {
     try {
       // Extract just the IDs in the new order
       const newOrder = newMovies.map((movie) => movie.id);
-      await SpoilerService.ReorderMovies(newOrder);
+      await SpoilerService.ReorderMovies(newOrder, null);
       // State will be updated via the event listener
     } catch (error) {
       console.error(t("errors.reorderMovies"), error);
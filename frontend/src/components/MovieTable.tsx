@@ -12,6 +12,9 @@ import { Trash2, Copy, FileVideo2Icon, AlertCircle, AlertTriangle } from "lucide
 import { SpoilerService, Movie } from "@bindings/spoilr/backend";
 import { useTranslation } from "@/contexts/LanguageContext";
 
+// Movies that finished with warnings have their results like completed ones
+const isCompleted = (state: string) => state === "completed" || state === "completed_with_warnings";
+
 interface MovieTableProps {
   movies: Movie[];
   processing: boolean;
@@ -146,6 +149,15 @@ export default function MovieTable({
             {renderErrorIcon()}
           </div>
         );
+      case "completed_with_warnings":
+        return (
+          <div className="flex items-center">
+            <Badge variant="outline" className="border-yellow-400/50 text-yellow-400">
+              {t("movieTable.status.completedWithWarnings")}
+            </Badge>
+            {renderErrorIcon()}
+          </div>
+        );
       case "error":
         return (
           <div className="flex items-center">
@@ -187,7 +199,7 @@ export default function MovieTable({
         return (
           <div className="font-medium text-white">
             <div className="max-w-[400px] truncate">
-              {movie.processingState === "completed" ? (
+              {isCompleted(movie.processingState) ? (
                 <HoverCard>
                   <HoverCardTrigger asChild>
                     <span className="cursor-pointer hover:underline inline-block w-full truncate">{movie.fileName}</span>
@@ -308,7 +320,7 @@ export default function MovieTable({
         const movie = row.original;
         return (
           <div className="flex gap-1">
-            {movie.processingState === "completed" && (
+            {isCompleted(movie.processingState) && (
               <Button
                 size="sm"
                 variant="ghost"
@@ -365,7 +377,7 @@ export default function MovieTable({
     }
   }, [table.getRowModel().rows, movies, onReorderMovies]);
 
-  const completedMovies = movies.filter((m) => m.processingState === "completed");
+  const completedMovies = movies.filter((m) => isCompleted(m.processingState));
 
   return (
     <Card className="bg-black/10 border-white/5 wails-no-drag">
@@ -434,7 +446,7 @@ export default function MovieTable({
                     <TableRow
                       key={row.id}
                       className="border-white/5 hover:bg-white/2"
-                      onMouseEnter={() => row.original.processingState === "completed" && handleRowHover(row.original.id)}
+                      onMouseEnter={() => isCompleted(row.original.processingState) && handleRowHover(row.original.id)}
                     >
                       {row.getVisibleCells().map((cell) => (
                         <TableCell key={cell.id}>{flexRender(cell.column.columnDef.cell, cell.getContext())}</TableCell>
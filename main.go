@@ -3,13 +3,12 @@ package main
 import (
 	"embed"
 	"errors"
+	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"spoilr/backend"
 	"strings"
 
-	"github.com/sqweek/dialog"
 	"github.com/wailsapp/wails/v3/pkg/application"
 	"github.com/wailsapp/wails/v3/pkg/events"
 )
@@ -19,28 +18,12 @@ var assets embed.FS
 
 func showErrorDialog(title, message string) {
 	log.Printf("FATAL ERROR: %s - %s", title, message)
-	dialog.Message("%s", message).Title(title).Error()
+	backend.Dialogs.Error(title, message)
 	os.Exit(1)
 }
 
-func checkExecutable(name string) error {
-	_, err := exec.LookPath(name)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 func ensureFFmpeg() error {
-	var missing []string
-
-	if err := checkExecutable("ffmpeg"); err != nil {
-		missing = append(missing, "ffmpeg")
-	}
-
-	if err := checkExecutable("ffprobe"); err != nil {
-		missing = append(missing, "ffprobe")
-	}
+	missing := backend.MissingTools(false)
 
 	if len(missing) > 0 {
 		var errorMsg strings.Builder
@@ -62,20 +45,101 @@ func ensureFFmpeg() error {
 	return nil
 }
 
+// offerToolDownload asks to download static builds of the missing required tools into the
+// config directory, and reports whether they are available afterwards
+func offerToolDownload() bool {
+	missing := backend.MissingTools(false)
+	if !backend.CanDownloadTools(missing) {
+		return false
+	}
+
+	message := fmt.Sprintf("%s not found.\n\nDownload a static build into the Spoilr config directory now?", strings.Join(missing, " and "))
+	if !backend.Dialogs.YesNo("FFmpeg Components Missing", message, false) {
+		return false
+	}
+	if err := backend.DownloadTools(missing); err != nil {
+		log.Printf("Failed to download tools: %v", err)
+		backend.Dialogs.Error("Download Failed", err.Error())
+		return false
+	}
+	return len(backend.MissingTools(false)) == 0
+}
+
+// offerToolInstall shows how to install the missing required tools with the system package
+// manager and checks again after the user installed them, reporting whether they are available
+func offerToolInstall() bool {
+	for {
+		missing := backend.MissingTools(false)
+		if len(missing) == 0 {
+			return true
+		}
+		message := fmt.Sprintf("%s not found.\n\n%s\n\nCheck again after installing?",
+			strings.Join(missing, " and "), backend.InstallInstructions(missing))
+		if !backend.Dialogs.YesNo("FFmpeg Components Missing", message, false) {
+			return false
+		}
+	}
+}
+
+// applyWindowState restores the saved window geometry onto the window options
+func applyWindowState(options *application.WebviewWindowOptions, state backend.WindowState) {
+	if state.Width > 0 && state.Height > 0 {
+		options.Width = min(state.Width, options.MaxWidth)
+		options.Height = min(state.Height, options.MaxHeight)
+		options.InitialPosition = application.WindowXY
+		options.X = state.X
+		options.Y = state.Y
+	}
+	if state.Maximized {
+		options.StartState = application.WindowStateMaximised
+	}
+}
+
+// installWebView2Flag installs the WebView2 Runtime without any UI and exits
+const installWebView2Flag = "--install-webview2"
+
 func main() {
-	if err := ensureWebView2(); err != nil {
+	backend.InstallLogBuffer()
+
+	// Silent WebView2 install for enterprise deployment scripts, exits without starting the app
+	if len(os.Args) > 1 && os.Args[1] == installWebView2Flag {
+		os.Exit(installWebView2())
+	}
+
+	// Headless mode for scripted batch generation, no window is created
+	if len(os.Args) > 1 && os.Args[1] == backend.CLIFlag {
+		if err := ensureFFmpeg(); err != nil {
+			log.Print(err)
+			log.Print(backend.InstallInstructions(backend.MissingTools(false)))
+			os.Exit(1)
+		}
+		os.Exit(backend.RunCLI(os.Args[2:]))
+	}
+
+	webviewBrowserPath, err := ensureWebView2()
+	if err != nil {
 		showErrorDialog("WebView2 Required", err.Error())
 		return
 	}
 
-	// Check for ffmpeg and ffprobe
-	if err := ensureFFmpeg(); err != nil {
+	// Check for ffmpeg and ffprobe, offering to download them when missing or guiding the
+	// install through the package manager where no static build is available
+	if err := ensureFFmpeg(); err != nil && !offerToolDownload() && !offerToolInstall() {
 		showErrorDialog("FFmpeg Components Missing", err.Error())
 		return
 	}
+	if optional := backend.MissingTools(true); len(optional) > 0 {
+		log.Printf("Optional tools not found: %s", strings.Join(optional, ", "))
+	}
 
 	spoilerService := backend.NewSpoilerService()
+	spoilerService.RestoreSession()
+
+	// Files passed on the command line, e.g. by "Open with" in the file manager
+	workingDir, _ := os.Getwd()
+	spoilerService.OpenFiles(backend.LaunchPaths(os.Args[1:], workingDir))
 
+	var window *application.WebviewWindow
 	app := application.New(application.Options{
 		Name:        "Spoilr",
 		Description: "Advanced media analyzer with automatic screenshot generation and FastPic upload",
@@ -85,14 +149,31 @@ func main() {
 		Assets: application.AssetOptions{
 			Handler: application.AssetFileServerFS(assets),
 		},
+		Windows: application.WindowsOptions{
+			WebviewBrowserPath: webviewBrowserPath, // Fixed-version runtime, empty for the installed one
+		},
 		Mac: application.MacOptions{
 			ApplicationShouldTerminateAfterLastWindowClosed: true,
 		},
+		// Opening several files with the app starts an instance per file on Windows and Linux,
+		// later instances hand their files to the first one and exit
+		SingleInstance: &application.SingleInstanceOptions{
+			UniqueID: "local.spoilr",
+			OnSecondInstanceLaunch: func(data application.SecondInstanceData) {
+				if window != nil {
+					window.Restore()
+					window.Focus()
+				}
+				if len(data.Args) > 1 {
+					spoilerService.OpenFiles(backend.LaunchPaths(data.Args[1:], data.WorkingDir))
+				}
+			},
+		},
 	})
 
 	spoilerService.SetApp(app)
 
-	window := app.Window.NewWithOptions(application.WebviewWindowOptions{
+	windowOptions := application.WebviewWindowOptions{
 		Title:             "Spoilr",
 		EnableDragAndDrop: true,
 		DisableResize:     true,
@@ -103,6 +184,32 @@ func main() {
 		Height:            800,
 		MaxWidth:          1200,
 		MaxHeight:         800,
+	}
+	applyWindowState(&windowOptions, spoilerService.GetWindowState())
+
+	window = app.Window.NewWithOptions(windowOptions)
+
+	// Files opened before the frontend loaded are added once it can show them
+	window.OnWindowEvent(events.Common.WindowRuntimeReady, func(event *application.WindowEvent) {
+		go spoilerService.WindowReady()
+	})
+
+	// macOS delivers files opened with the app as events rather than arguments
+	app.Event.OnApplicationEvent(events.Common.ApplicationOpenedWithFile, func(event *application.ApplicationEvent) {
+		go spoilerService.OpenFiles([]string{event.Context().Filename()})
+	})
+
+	// Remember window geometry for the next start
+	window.OnWindowEvent(events.Common.WindowClosing, func(event *application.WindowEvent) {
+		state := spoilerService.GetWindowState()
+		state.Maximized = window.IsMaximised()
+		if !state.Maximized {
+			state.X, state.Y = window.Position()
+			state.Width, state.Height = window.Size()
+		}
+		if err := spoilerService.SaveWindowState(state); err != nil {
+			log.Printf("Failed to save window state: %v", err)
+		}
 	})
 
 	// Handle drag and drop events
@@ -110,14 +217,17 @@ func main() {
 		paths := event.Context().DroppedFiles()
 		log.Printf("Files dropped: %v", paths)
 
-		// Just add files to the list without processing
-		err := spoilerService.AddMovies(paths)
+		// Just add files to the list (or the group they were dropped on) without processing
+		err := spoilerService.HandleDroppedFiles(paths)
 		if err != nil {
 			log.Printf("Error adding movies: %v", err)
 		}
 	})
 
-	err := app.Run()
+	// Dialogs from now on open in front of the window
+	backend.Dialogs.AttachWindow(app, window)
+
+	err = app.Run()
 	if err != nil {
 		log.Fatal(err)
 	}
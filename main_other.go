@@ -2,7 +2,14 @@
 
 package main
 
-func ensureWebView2() error {
+import "log"
+
+func installWebView2() int {
+	log.Println("WebView2 is only needed on Windows, nothing to install")
+	return 0
+}
+
+func ensureWebView2() (string, error) {
 	// No-op on macOS and Linux
-	return nil
+	return "", nil
 }
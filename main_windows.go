@@ -5,17 +5,36 @@ package main
 import (
 	_ "embed"
 	"errors"
+	"log"
 	"spoilr/backend"
 )
 
 //go:embed build/windows/nsis/MicrosoftEdgeWebview2Setup.exe
 var webview2Installer []byte
 
-func ensureWebView2() error {
+// installWebView2 silently installs the WebView2 Runtime when it is missing and returns the exit code
+func installWebView2() int {
 	handler := backend.NewWebView2Handler(webview2Installer)
-	_, err := handler.EnsureWebView2Available()
+	if version, err := handler.CheckWebView2(); err == nil {
+		log.Printf("WebView2 Runtime %s is already installed", version.String())
+		return 0
+	}
+	if _, err := handler.InstallSilently(); err != nil {
+		log.Printf("WebView2 Runtime install failed: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// ensureWebView2 returns the fixed-version runtime directory to use, empty for the installed runtime
+func ensureWebView2() (string, error) {
+	handler := backend.NewWebView2Handler(webview2Installer)
+	version, err := handler.EnsureWebView2Available()
 	if err != nil {
-		return errors.New("WebView2 runtime is not available: " + err.Error())
+		return "", errors.New("WebView2 runtime is not available: " + err.Error())
+	}
+	if version.Fixed {
+		return version.Path, nil
 	}
-	return nil
+	return "", nil
 }
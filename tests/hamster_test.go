@@ -211,3 +211,25 @@ func TestHamsterService_InvalidFile(t *testing.T) {
 		}
 	}
 }
+
+func TestHamsterExpirationDuration(t *testing.T) {
+	tests := []struct {
+		expiration string
+		want       time.Duration
+	}{
+		{"", 0},
+		{"PT5M", 5 * time.Minute},
+		{"PT2H", 2 * time.Hour},
+		{"P1D", 24 * time.Hour},
+		{"P2W", 14 * 24 * time.Hour},
+		{"P1M", 30 * 24 * time.Hour},
+		{"P1Y", 365 * 24 * time.Hour},
+		{"P1H", 0},
+		{"1D", 0},
+	}
+	for _, tt := range tests {
+		if got := img_uploaders.HamsterExpirationDuration(tt.expiration); got != tt.want {
+			t.Errorf("HamsterExpirationDuration(%q) = %v, want %v", tt.expiration, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package img_uploaders
+
+import (
+	"spoilr/backend"
+	"strings"
+	"testing"
+)
+
+func TestConvertBBCodeBracketedTitle(t *testing.T) {
+	input := `[spoiler="[SubsPlease] Show - 01 [1080p].mkv | 1.2 GB"][b]Info[/b][/spoiler]`
+
+	discourse := backend.ConvertBBCode(input, backend.OutputFormatDiscourse)
+	if !strings.HasPrefix(discourse, `[details="[SubsPlease] Show - 01 [1080p].mkv | 1.2 GB"]`+"\n") {
+		t.Errorf("Discourse title lost its brackets: %q", discourse)
+	}
+	if !strings.Contains(discourse, "**Info**") || strings.Contains(discourse, "1080p].mkv | 1.2 GB\"]**") {
+		t.Errorf("Discourse body is wrong: %q", discourse)
+	}
+
+	ipb := backend.ConvertBBCode(input, backend.OutputFormatIPB)
+	if !strings.Contains(ipb, `<span>[SubsPlease] Show - 01 [1080p].mkv | 1.2 GB</span>`) {
+		t.Errorf("IPB title lost its brackets: %q", ipb)
+	}
+}
+
+func TestConvertBBCodeArguments(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`[spoiler=Plain title]x[/spoiler]`, "[details=\"Plain title\"]\nx\n[/details]"},
+		{`[spoiler="Quoted"]x[/spoiler]`, "[details=\"Quoted\"]\nx\n[/details]"},
+		{`[spoiler="A"]x[/spoiler] [spoiler="B"]y[/spoiler]`, "[details=\"A\"]\nx\n[/details] [details=\"B\"]\ny\n[/details]"},
+		{`[b]bold[/b]`, "**bold**"},
+	}
+	for _, tt := range tests {
+		if got := backend.ConvertBBCode(tt.input, backend.OutputFormatDiscourse); got != tt.want {
+			t.Errorf("ConvertBBCode(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package img_uploaders
+
+import (
+	"spoilr/backend"
+	"testing"
+)
+
+func TestLimitSpoilerTitles(t *testing.T) {
+	tests := []struct {
+		text      string
+		maxLength int
+		want      string
+	}{
+		{`[spoiler="Short"]x[/spoiler]`, 10, `[spoiler="Short"]x[/spoiler]`},
+		{`[spoiler="A long spoiler title"]x[/spoiler]`, 10, `[spoiler="A long sp…"]x[/spoiler]`},
+		{`[spoiler=A long spoiler title]x[/spoiler]`, 10, `[spoiler=A long sp…]x[/spoiler]`},
+		{
+			`[spoiler="[SubsPlease] Show - 01 [1080p].mkv | 1.2 GB"]x[/spoiler]`, 20,
+			`[spoiler="[SubsPlease] Show -…"]x[/spoiler]`,
+		},
+		{
+			`[spoiler="[Group] Show [720p]"]x[/spoiler]`, 40,
+			`[spoiler="[Group] Show [720p]"]x[/spoiler]`,
+		},
+		{
+			`[spoiler="[A] First title here"]x[/spoiler] [spoiler="[B] Second title here"]y[/spoiler]`, 8,
+			`[spoiler="[A] Fir…"]x[/spoiler] [spoiler="[B] Sec…"]y[/spoiler]`,
+		},
+	}
+	for _, tt := range tests {
+		if got := backend.LimitSpoilerTitles(tt.text, tt.maxLength); got != tt.want {
+			t.Errorf("LimitSpoilerTitles(%q, %d) = %q, want %q", tt.text, tt.maxLength, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package img_uploaders
+
+import (
+	"spoilr/backend"
+	"testing"
+)
+
+func TestParseReleaseName(t *testing.T) {
+	tests := []struct {
+		name string
+		want backend.ReleaseInfo
+	}{
+		{
+			name: "The.Matrix.1999.1080p.BluRay.x264-SPARKS.mkv",
+			want: backend.ReleaseInfo{Title: "The Matrix", Year: "1999", Resolution: "1080p", Source: "BluRay", Group: "SPARKS"},
+		},
+		{
+			name: "Blade.Runner.2049.2017.2160p.UHD.BluRay.x265-TERMiNAL.mkv",
+			want: backend.ReleaseInfo{Title: "Blade Runner 2049", Year: "2017", Resolution: "2160p", Source: "BluRay", Group: "TERMiNAL"},
+		},
+		{
+			name: "Game.of.Thrones.S08E03.The.Long.Night.1080p.AMZN.WEB-DL.DDP5.1.H.264-GoT.mkv",
+			want: backend.ReleaseInfo{Title: "Game of Thrones", Season: "08", Episode: "03", Resolution: "1080p", Source: "WEB-DL", Group: "GoT"},
+		},
+		{
+			name: "the_office_us_2x05_720p_hdtv.avi",
+			want: backend.ReleaseInfo{Title: "the office us", Season: "02", Episode: "05", Resolution: "720p", Source: "HDTV"},
+		},
+		{
+			name: "Show.Name.S01E01E02.720p.WEBRip.x264-GRP[rarbg].mp4",
+			want: backend.ReleaseInfo{Title: "Show Name", Season: "01", Episode: "01-02", Resolution: "720p", Source: "WEBRip", Group: "GRP"},
+		},
+		{
+			name: "Show Name S03 1080p BluRay x265-GRP",
+			want: backend.ReleaseInfo{Title: "Show Name", Season: "03", Resolution: "1080p", Source: "BluRay", Group: "GRP"},
+		},
+		{
+			name: "[SubsPlease] Jujutsu Kaisen - 24 (1080p) [ABCD1234].mkv",
+			want: backend.ReleaseInfo{Title: "Jujutsu Kaisen", Episode: "24", Resolution: "1080p", Group: "SubsPlease"},
+		},
+		{
+			name: "Spider-Man.2002.DVDRip.XviD.avi",
+			want: backend.ReleaseInfo{Title: "Spider-Man", Year: "2002", Source: "DVDRip"},
+		},
+		{
+			name: "Some Movie (2010) [1920x1080].mp4",
+			want: backend.ReleaseInfo{Title: "Some Movie", Year: "2010", Resolution: "1080p"},
+		},
+		{
+			name: "Movie.Title.2019.1080p.WEB-DL",
+			want: backend.ReleaseInfo{Title: "Movie Title", Year: "2019", Resolution: "1080p", Source: "WEB-DL"},
+		},
+		{
+			name: "2012.mkv",
+			want: backend.ReleaseInfo{Title: "2012"},
+		},
+		{
+			name: "Holiday video.mov",
+			want: backend.ReleaseInfo{Title: "Holiday video"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backend.ParseReleaseName(tt.name); got != tt.want {
+				t.Errorf("ParseReleaseName(%q)\n got %+v\nwant %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
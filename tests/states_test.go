@@ -0,0 +1,55 @@
+package img_uploaders
+
+import (
+	"spoilr/backend"
+	"testing"
+)
+
+func TestProcessingStatePaths(t *testing.T) {
+	paths := map[string][]backend.ProcessingState{
+		"uploads": {
+			backend.StatePending, backend.StateWaitingForScreenshotSlot, backend.StateGeneratingScreenshots,
+			backend.StateWaitingForUploadSlot, backend.StateUploadingScreenshots, backend.StateCompleted,
+		},
+		"pipelined uploads": {
+			backend.StatePending, backend.StateWaitingForScreenshotSlot, backend.StateGeneratingScreenshots,
+			backend.StateUploadingScreenshots, backend.StateCompletedWithWarnings,
+		},
+		"pipelined local output only": {
+			backend.StatePending, backend.StateWaitingForScreenshotSlot, backend.StateGeneratingScreenshots,
+			backend.StateCompleted,
+		},
+		"pipelined local output only with warnings": {
+			backend.StatePending, backend.StateWaitingForScreenshotSlot, backend.StateGeneratingScreenshots,
+			backend.StateCompletedWithWarnings,
+		},
+		"nothing left to do": {
+			backend.StatePending, backend.StateWaitingForScreenshotSlot, backend.StateCompleted,
+		},
+		"imported previous results": {
+			backend.StateError, backend.StatePending, backend.StateCompleted,
+		},
+	}
+	for name, path := range paths {
+		for i := 1; i < len(path); i++ {
+			if !path[i-1].CanTransitionTo(path[i]) {
+				t.Errorf("%s: %s -> %s is not allowed", name, path[i-1], path[i])
+			}
+		}
+	}
+}
+
+func TestProcessingStateIllegalTransitions(t *testing.T) {
+	tests := [][2]backend.ProcessingState{
+		{backend.StatePending, backend.StateUploadingScreenshots},
+		{backend.StateCompleted, backend.StateGeneratingScreenshots},
+		{backend.StateError, backend.StateCompleted},
+		{backend.StateUploadingScreenshots, backend.StateGeneratingScreenshots},
+		{backend.StatePending, backend.ProcessingState("unknown")},
+	}
+	for _, tt := range tests {
+		if tt[0].CanTransitionTo(tt[1]) {
+			t.Errorf("%s -> %s is allowed", tt[0], tt[1])
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package img_uploaders
+
+import (
+	"spoilr/backend"
+	"testing"
+)
+
+func TestExpandHostSections(t *testing.T) {
+	hosts := map[string]bool{"FP": true, "IB": false}
+	keep := func(suffix string) (bool, bool) {
+		kept, known := hosts[suffix]
+		return kept, known
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"kept", "A\n[[FP: fastpic %FP%]]\nB\n", "A\nfastpic %FP%\nB\n"},
+		{"dropped", "A\n[[IB: imgbox %IB%]]\nB\n", "A\nB\n"},
+		{"dropped crlf", "A\r\n[[IB: imgbox]]\r\nB", "A\r\nB"},
+		{"unknown", "A\n[[XX: other]]\nB", "A\n[[XX: other]]\nB"},
+		{"trailing tag", "A\n[[FP: [url=%FP%]link[/url]]]\nB\n", "A\n[url=%FP%]link[/url]\nB\n"},
+		{"dropped trailing tag", "A\n[[IB: [url=%IB%]link[/url]]]\nB\n", "A\nB\n"},
+		{"nested tags", "[[FP: [b][i]x[/i][/b]]] y", "[b][i]x[/i][/b] y"},
+		{"two sections", "[[FP:a]][[IB:b]]c", "ac"},
+		{"not a section", "[[lowercase: x]] [b]y[/b]", "[[lowercase: x]] [b]y[/b]"},
+		{"unclosed", "A [[FP: [b]x", "A [[FP: [b]x"},
+	}
+	for _, tt := range tests {
+		if got := backend.ExpandHostSections(tt.template, keep); got != tt.want {
+			t.Errorf("%s: ExpandHostSections(%q) = %q, want %q", tt.name, tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestRenderConditionalBlocks(t *testing.T) {
+	values := map[string]bool{"%SET%": true, "%EMPTY%": false}
+	holds := func(condition string) bool {
+		if condition != "" && condition[0] == '!' {
+			return !values[condition[1:]]
+		}
+		return values[condition]
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"kept", "A\n[if:%SET%]x[/if]\nB", "A\nx\nB"},
+		{"dropped", "A\n[if:%EMPTY%]x[/if]\nB", "A\nB"},
+		{"dropped crlf", "A\r\n[if:%EMPTY%]x[/if]\r\nB", "A\r\nB"},
+		{"negated", "[if:!%EMPTY%]x[/if]", "x"},
+		{"nested", "[if:%SET%]a[if:%EMPTY%]b[/if]c[/if]", "ac"},
+		{"nested dropped", "[if:%EMPTY%]a[if:%SET%]b[/if]c[/if]d", "d"},
+		{"siblings", "[if:%SET%]a[/if][if:%EMPTY%]b[/if][if:%SET%]c[/if]", "ac"},
+		{"bbcode inside", "[if:%SET%][b]x[/b][/if]", "[b]x[/b]"},
+		{"unclosed", "[if:%SET%]x", "[if:%SET%]x"},
+	}
+	for _, tt := range tests {
+		if got := backend.RenderConditionalBlocks(tt.template, holds); got != tt.want {
+			t.Errorf("%s: RenderConditionalBlocks(%q) = %q, want %q", tt.name, tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestParseBitRateKbps(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		ok    bool
+	}{
+		{"4 500 kb/s", 4500, true},
+		{"4 500 kb/s", 4500, true},
+		{"4,500 kbps", 4500, true},
+		{"4.500 Kbps", 4500, true},
+		{"4,5 Mbps", 4500, true},
+		{"4.5 Mb/s", 4500, true},
+		{"1.2 Gbit/s", 1.2e6, true},
+		{"640 kb/s", 640, true},
+		{"4500000", 4500, true},
+		{"1 536 000 b/s", 1536, true},
+		{"", 0, false},
+		{"N/A", 0, false},
+		{"0 kb/s", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := backend.ParseBitRateKbps(tt.value)
+		if ok != tt.ok || !closeTo(got, tt.want) {
+			t.Errorf("ParseBitRateKbps(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseFPS(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		ok    bool
+	}{
+		{"23.976", 23.976, true},
+		{"23,976 fps", 23.976, true},
+		{"24000/1001", 24000.0 / 1001, true},
+		{"25 FPS", 25, true},
+		{"29.970 (30000/1001) FPS", 29.97, true},
+		{"0/0", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := backend.ParseFPS(tt.value)
+		if ok != tt.ok || !closeTo(got, tt.want) {
+			t.Errorf("ParseFPS(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func closeTo(a, b float64) bool {
+	diff := a - b
+	return diff < 1e-6 && diff > -1e-6
+}
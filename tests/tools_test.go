@@ -0,0 +1,16 @@
+package img_uploaders
+
+import (
+	"regexp"
+	"spoilr/backend"
+	"testing"
+)
+
+func TestToolDownloadChecksums(t *testing.T) {
+	checksum := regexp.MustCompile(`^[0-9a-f]{64}$`)
+	for url, sum := range backend.ToolDownloadChecksums() {
+		if !checksum.MatchString(sum) {
+			t.Errorf("%s has no pinned SHA-256 checksum: %q", url, sum)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package img_uploaders
+
+import (
+	"path/filepath"
+	"spoilr/backend"
+	"strings"
+	"testing"
+)
+
+func TestParseTorrent(t *testing.T) {
+	single := "d8:announce23:http://tracker.test/ann4:infod6:lengthi1024e4:name9:movie.mkv12:piece lengthi16384e6:pieces0:ee"
+	torrent, err := backend.ParseTorrent([]byte(single))
+	if err != nil {
+		t.Fatalf("ParseTorrent(single file) failed: %v", err)
+	}
+	if torrent.Name != "movie.mkv" || torrent.Size != 1024 || torrent.PieceSize != 16384 || len(torrent.Files) != 1 {
+		t.Errorf("ParseTorrent(single file) = %+v", torrent)
+	}
+	if len(torrent.Trackers) != 1 || torrent.Trackers[0] != "http://tracker.test/ann" {
+		t.Errorf("ParseTorrent(single file) trackers = %v", torrent.Trackers)
+	}
+
+	multi := "d4:infod5:filesld6:lengthi10e4:pathl3:sub5:a.mkveed6:lengthi20e4:pathl5:b.mkveee4:name4:Show12:piece lengthi16384eee"
+	torrent, err = backend.ParseTorrent([]byte(multi))
+	if err != nil {
+		t.Fatalf("ParseTorrent(multi file) failed: %v", err)
+	}
+	if torrent.Size != 30 || len(torrent.Files) != 2 || torrent.Files[0].Path != filepath.Join("Show", "sub", "a.mkv") {
+		t.Errorf("ParseTorrent(multi file) = %+v", torrent)
+	}
+}
+
+func TestParseTorrentInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"truncated dictionary", "d4:infod6:lengthi1e"},
+		{"truncated string", "d4:info5:ab"},
+		{"truncated integer", "d4:infoi12"},
+		{"truncated key", "d4:inf"},
+		{"huge string length", "d4:info9223372036854775807:xe"},
+		{"overflowing string length", "d4:info99999999999999999999:xe"},
+		{"negative string length", "d4:info-1:xe"},
+		{"invalid integer", "d4:infoi1x2ee"},
+		{"deep nesting", strings.Repeat("l", 100000) + strings.Repeat("e", 100000)},
+		{"no info dictionary", "d4:name4:testee"},
+		{"missing piece length", "d4:infod6:lengthi1e4:name4:testee"},
+		{"unsafe name", "d4:infod6:lengthi1e4:name2:..12:piece lengthi1eee"},
+		{"name with separator", "d4:infod6:lengthi1e4:name5:a/b.c12:piece lengthi1eee"},
+		{"unsafe file path", "d4:infod5:filesld6:lengthi1e4:pathl2:..5:a.mkveee4:name4:Show12:piece lengthi1eee"},
+	}
+	for _, tt := range tests {
+		if torrent, err := backend.ParseTorrent([]byte(tt.data)); err == nil {
+			t.Errorf("%s: ParseTorrent() = %+v, want error", tt.name, torrent)
+		}
+	}
+}